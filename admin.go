@@ -0,0 +1,374 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+var (
+	errChannelExists  = errors.New("channel already monitored")
+	errChannelUnknown = errors.New("channel not monitored")
+	errChannelInvalid = errors.New("invalid channel ID")
+)
+
+// ChannelManager adds and removes the monitored channels at runtime, the
+// changes being persisted to the database when any.
+type ChannelManager struct {
+	ctx       context.Context
+	server    *Server
+	scheduler *onyt.Scheduler
+	database  *Database
+
+	// NewPoller creates the poller of an added channel, and Run starts the
+	// tasks following a poller until its context is done.
+	NewPoller func(channelId string) *onyt.Poller
+	Run       func(ctx context.Context, poller *onyt.Poller)
+
+	mu      sync.Mutex
+	sources map[string]onyt.Source
+	cancels map[string]context.CancelFunc
+}
+
+func NewChannelManager(ctx context.Context, server *Server, scheduler *onyt.Scheduler, database *Database, sources []onyt.Source) *ChannelManager {
+	m := &ChannelManager{
+		ctx:       ctx,
+		server:    server,
+		scheduler: scheduler,
+		database:  database,
+		sources:   make(map[string]onyt.Source, len(sources)),
+		cancels:   make(map[string]context.CancelFunc),
+	}
+
+	for _, source := range sources {
+		m.sources[onyt.SourceKey(source)] = source
+	}
+
+	return m
+}
+
+// Track runs the tasks following a poller, until its channel is removed.
+func (m *ChannelManager) Track(poller *onyt.Poller) {
+	if m.Run == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+
+	m.mu.Lock()
+	m.cancels[poller.ChannelId] = cancel
+	m.mu.Unlock()
+
+	m.Run(ctx, poller)
+}
+
+// validChannel tells whether the value is a channel ID or a handle.
+func validChannel(channel string) bool {
+	if strings.HasPrefix(channel, "@") {
+		return len(channel) > 1 && !strings.ContainsAny(channel, "/?# ")
+	}
+
+	return len(channel) == 24 && strings.HasPrefix(channel, "UC")
+}
+
+// Add monitors the channel, resolving it first when a handle.
+func (m *ChannelManager) Add(ctx context.Context, channel string) (*onyt.Poller, error) {
+	return m.add(ctx, channel, true)
+}
+
+// add monitors the channel, persisting the change when asked to.
+func (m *ChannelManager) add(ctx context.Context, channel string, persist bool) (*onyt.Poller, error) {
+	if !validChannel(channel) {
+		return nil, errChannelInvalid
+	}
+
+	channelId := channel
+
+	if strings.HasPrefix(channel, "@") {
+		var err error
+
+		if channelId, err = onyt.ResolveHandle(ctx, channel); err != nil {
+			return nil, err
+		}
+	}
+
+	poller := m.NewPoller(channelId)
+
+	if !m.server.addPoller(poller) {
+		return nil, errChannelExists
+	}
+
+	source := onyt.NewYouTubeSource(poller)
+
+	if err := m.scheduler.Add(source); err != nil {
+		m.server.removePoller(channelId)
+		return nil, err
+	}
+
+	m.server.sources.Add(source)
+
+	m.mu.Lock()
+	m.sources[channelId] = source
+	m.mu.Unlock()
+
+	m.Track(poller)
+
+	if m.database != nil && persist {
+		if err := m.database.SetChannel(channelId, false); err != nil {
+			log.Err(err).Str("channel", channelId).Msg("Unable to persist channel")
+		}
+	}
+
+	log.Info().Str("channel", channelId).Msg("Channel added")
+
+	return poller, nil
+}
+
+// Remove stops monitoring the channel.
+func (m *ChannelManager) Remove(channelId string) error {
+	return m.remove(channelId, true)
+}
+
+// remove stops monitoring the channel, persisting the change when asked to.
+func (m *ChannelManager) remove(channelId string, persist bool) error {
+	if _, ok := m.server.removePoller(channelId); !ok {
+		return errChannelUnknown
+	}
+
+	m.scheduler.Remove(channelId)
+
+	m.mu.Lock()
+
+	if source, ok := m.sources[channelId]; ok {
+		m.server.sources.Remove(source)
+	}
+
+	if cancel, ok := m.cancels[channelId]; ok {
+		cancel()
+	}
+
+	delete(m.sources, channelId)
+	delete(m.cancels, channelId)
+
+	m.mu.Unlock()
+
+	if m.database != nil && persist {
+		if err := m.database.SetChannel(channelId, true); err != nil {
+			log.Err(err).Str("channel", channelId).Msg("Unable to persist channel")
+		}
+	}
+
+	log.Info().Str("channel", channelId).Msg("Channel removed")
+
+	return nil
+}
+
+// mergeChannels applies the channels added and removed at runtime to the
+// configured ones.
+func mergeChannels(channels, added, removed []string) []string {
+	result := make([]string, 0, len(channels)+len(added))
+
+	for _, channel := range append(channels, added...) {
+		if !contains(removed, channel) && !contains(result, channel) {
+			result = append(result, channel)
+		}
+	}
+
+	return result
+}
+
+// authorized tells whether the request bears the admin token, answering
+// with a 401 otherwise.
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("authorization"), "Bearer ")
+
+	if ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) == 1 {
+		return true
+	}
+
+	w.Header().Set("www-authenticate", "Bearer")
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+	return false
+}
+
+func (s *Server) serveAdminChannels(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/channels"), "/")
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		pollers := s.Pollers()
+		summaries := make([]*ChannelSummary, 0, len(pollers))
+
+		for _, p := range pollers {
+			summaries = append(summaries, s.summarize(p))
+		}
+
+		writeJSON(w, summaries)
+
+	case id == "" && r.Method == http.MethodPost:
+		var body struct {
+			Id string `json:"id"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+
+		poller, err := s.Channels.Add(r.Context(), strings.TrimSpace(body.Id))
+
+		switch {
+		case errors.Is(err, errChannelInvalid):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+		case errors.Is(err, errChannelExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+
+		case err != nil:
+			log.Err(err).Str("channel", body.Id).Msg("Unable to add channel")
+			http.Error(w, "unable to add channel", http.StatusBadGateway)
+
+		default:
+			w.Header().Set("content-type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(w, s.summarize(poller))
+		}
+
+	case id != "" && r.Method == http.MethodDelete:
+		if err := s.Channels.Remove(id); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	case id == "":
+		w.Header().Set("allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+	default:
+		w.Header().Set("allow", "DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+type RefreshResult struct {
+	ChannelId string `json:"channelId"`
+	Version   uint64 `json:"version"`
+	Error     string `json:"error,omitempty"`
+}
+
+// serveRefresh refreshes the channels of the channel query parameters, or
+// every channel, right away, answering with their new state versions.
+func (s *Server) serveRefresh(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var pollers []*onyt.Poller
+
+	if ids := r.URL.Query()["channel"]; len(ids) > 0 {
+		for _, id := range ids {
+			poller, ok := s.Poller(id)
+
+			if !ok {
+				http.Error(w, fmt.Sprintf("channel %s not monitored", id), http.StatusNotFound)
+				return
+			}
+
+			pollers = append(pollers, poller)
+		}
+	} else {
+		pollers = s.Pollers()
+	}
+
+	results := make([]*RefreshResult, len(pollers))
+	failed := 0
+
+	var wg sync.WaitGroup
+
+	for i, poller := range pollers {
+		wg.Add(1)
+
+		go func(i int, poller *onyt.Poller) {
+			defer wg.Done()
+
+			result := &RefreshResult{
+				ChannelId: poller.ChannelId,
+			}
+
+			if err := s.Scheduler.RefreshNow(r.Context(), poller.ChannelId); err != nil {
+				result.Error = err.Error()
+			}
+
+			_, result.Version, _ = poller.Store.Snapshot()
+
+			results[i] = result
+		}(i, poller)
+	}
+
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+
+	w.Header().Set("content-type", "application/json")
+
+	if failed > 0 && failed == len(results) {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	writeJSON(w, results)
+}
+
+// servePause pauses or resumes the polling, according to the path.
+func (s *Server) servePause(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	paused := r.URL.Path == "/admin/pause"
+
+	if paused != s.Pause.Paused() {
+		s.Pause.Set(paused)
+
+		if paused {
+			log.Info().Msg("Polling paused")
+		} else {
+			log.Info().Msg("Polling resumed")
+		}
+	}
+
+	writeJSON(w, map[string]any{
+		"paused":      paused,
+		"pausedSince": s.Pause.Since(),
+	})
+}
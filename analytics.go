@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+func queryLocation(r *http.Request) (*time.Location, error) {
+	if tz := r.URL.Query().Get("tz"); tz != "" {
+		return time.LoadLocation(tz)
+	}
+
+	return time.UTC, nil
+}
+
+func (s *Server) servePrediction(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	if s.Database == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+
+	loc, err := queryLocation(r)
+
+	if err != nil {
+		http.Error(w, "invalid timezone", http.StatusBadRequest)
+		return
+	}
+
+	days, err := strconv.Atoi(query.Get("days"))
+
+	if err != nil || days <= 0 {
+		days = 90
+	}
+
+	count, err := strconv.Atoi(query.Get("count"))
+
+	if err != nil || count <= 0 {
+		count = 3
+	}
+
+	now := time.Now()
+
+	starts, err := s.Database.SessionStarts(poller.ChannelId, now.AddDate(0, 0, -days))
+
+	if err != nil {
+		log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to load sessions")
+		http.Error(w, "unable to load sessions", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, onyt.PredictSchedule(starts, now, loc, count))
+}
+
+func (s *Server) serveUploadAnalytics(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	if s.Database == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	loc, err := queryLocation(r)
+
+	if err != nil {
+		http.Error(w, "invalid timezone", http.StatusBadRequest)
+		return
+	}
+
+	months, err := strconv.Atoi(r.URL.Query().Get("months"))
+
+	if err != nil || months <= 0 || months > 120 {
+		months = 12
+	}
+
+	times, err := s.Database.UploadTimes(poller.ChannelId)
+
+	if err != nil {
+		log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to load uploads")
+		http.Error(w, "unable to load uploads", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, onyt.AnalyzeUploads(times, time.Now(), loc, months))
+}
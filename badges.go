@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+var (
+	badgeColorRe = regexp.MustCompile(`^[0-9a-fA-F]{3}([0-9a-fA-F]{3})?$`)
+
+	badgeColors = map[string]string{
+		"brightgreen": "#4c1",
+		"green":       "#97ca00",
+		"yellow":      "#dfb317",
+		"orange":      "#fe7d37",
+		"red":         "#e05d44",
+		"blue":        "#007ec6",
+		"grey":        "#555",
+		"lightgrey":   "#9f9f9f",
+	}
+)
+
+type badge struct {
+	label      string
+	message    string
+	color      string
+	labelColor string
+	square     bool
+}
+
+func badgeColor(value, fallback string) string {
+	if color, ok := badgeColors[value]; ok {
+		return color
+	}
+
+	if badgeColorRe.MatchString(value) {
+		return "#" + value
+	}
+
+	return fallback
+}
+
+// textWidth approximates the width of the text rendered in 11px Verdana.
+func textWidth(text string) int {
+	width := 0
+
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("iljI.,:;!'|", r):
+			width += 4
+		case strings.ContainsRune("frt() ", r):
+			width += 5
+		case strings.ContainsRune("mwMW", r):
+			width += 11
+		case r >= 'A' && r <= 'Z':
+			width += 8
+		default:
+			width += 7
+		}
+	}
+
+	return width
+}
+
+func (b *badge) svg() string {
+	labelWidth := textWidth(b.label) + 10
+	messageWidth := textWidth(b.message) + 10
+	width := labelWidth + messageWidth
+
+	radius := 3
+
+	if b.square {
+		radius = 0
+	}
+
+	label := html.EscapeString(b.label)
+	message := html.EscapeString(b.message)
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s"><title>%[4]s: %[5]s</title><linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient><clipPath id="r"><rect width="%[1]d" height="20" rx="%[8]d" fill="#fff"/></clipPath><g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="%[6]s"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[7]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g><g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11"><text x="%[9]d" y="15" fill="#010101" fill-opacity=".3">%[4]s</text><text x="%[9]d" y="14">%[4]s</text><text x="%[10]d" y="15" fill="#010101" fill-opacity=".3">%[5]s</text><text x="%[10]d" y="14">%[5]s</text></g></svg>`,
+		width, labelWidth, messageWidth, label, message, b.labelColor, b.color, radius, labelWidth/2, labelWidth+messageWidth/2)
+}
+
+func formatCount(n uint64) string {
+	switch {
+	case n >= 1e9:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1e9), ".0") + "B"
+	case n >= 1e6:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1e6), ".0") + "M"
+	case n >= 1e3:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1e3), ".0") + "K"
+	}
+
+	return fmt.Sprint(n)
+}
+
+func serveBadge(w http.ResponseWriter, r *http.Request, poller *onyt.Poller, name string) {
+	state := poller.Store.Get()
+
+	b := new(badge)
+
+	switch name {
+	case "subscribers.svg", "views.svg", "videos.svg":
+		b.label = strings.TrimSuffix(name, ".svg")
+		b.message = "unknown"
+		b.color = badgeColors["lightgrey"]
+
+		if value, ok := onyt.ChannelMetrics(state.Channel)[b.label]; ok {
+			b.message = formatCount(value)
+			b.color = badgeColors["red"]
+		}
+
+	case "live.svg":
+		b.label = "youtube"
+		b.message = "offline"
+		b.color = badgeColors["lightgrey"]
+
+		if state.LiveVideo != nil {
+			b.message = "live"
+			b.color = badgeColors["red"]
+
+			if d := state.LiveVideo.LiveStreamingDetails; d != nil && d.ConcurrentViewers > 0 {
+				b.message = fmt.Sprintf("live | %s watching", formatCount(d.ConcurrentViewers))
+			}
+		}
+
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+
+	if query.Has("label") {
+		b.label = query.Get("label")
+	}
+
+	b.color = badgeColor(query.Get("color"), b.color)
+	b.labelColor = badgeColor(query.Get("labelColor"), "#555")
+	b.square = query.Get("style") == "flat-square"
+
+	w.Header().Set("content-type", "image/svg+xml")
+	w.Header().Set("cache-control", "max-age=60")
+
+	fmt.Fprint(w, b.svg())
+}
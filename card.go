@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	cardWidth      = 600
+	cardHeight     = 200
+	cardPadding    = 30
+	cardAvatarSize = cardHeight - 2*cardPadding
+	cardAvatarTTL  = time.Hour
+)
+
+type cardTheme struct {
+	background color.Color
+	foreground color.Color
+	muted      color.Color
+}
+
+var cardThemes = map[string]*cardTheme{
+	"dark": {
+		background: color.RGBA{0x18, 0x18, 0x18, 0xff},
+		foreground: color.White,
+		muted:      color.RGBA{0xaa, 0xaa, 0xaa, 0xff},
+	},
+	"light": {
+		background: color.White,
+		foreground: color.RGBA{0x0f, 0x0f, 0x0f, 0xff},
+		muted:      color.RGBA{0x60, 0x60, 0x60, 0xff},
+	},
+}
+
+var (
+	cardLiveColor = color.RGBA{0xcc, 0x00, 0x00, 0xff}
+
+	cardFontsOnce sync.Once
+	cardFonts     struct {
+		title, body, badge font.Face
+	}
+
+	cardAvatars = onyt.NewTTLCache[image.Image]()
+)
+
+func loadCardFonts() {
+	bold, _ := opentype.Parse(gobold.TTF)
+	regular, _ := opentype.Parse(goregular.TTF)
+
+	cardFonts.title, _ = opentype.NewFace(bold, &opentype.FaceOptions{Size: 30, DPI: 72, Hinting: font.HintingFull})
+	cardFonts.body, _ = opentype.NewFace(regular, &opentype.FaceOptions{Size: 20, DPI: 72, Hinting: font.HintingFull})
+	cardFonts.badge, _ = opentype.NewFace(bold, &opentype.FaceOptions{Size: 16, DPI: 72, Hinting: font.HintingFull})
+}
+
+func fetchAvatar(r *http.Request, url string) image.Image {
+	if img, ok := cardAvatars.Get(url); ok {
+		return img
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, url, nil)
+
+	if err != nil {
+		return nil
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to fetch avatar")
+		return nil
+	}
+
+	defer resp.Body.Close()
+
+	img, _, err := image.Decode(resp.Body)
+
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to decode avatar")
+		return nil
+	}
+
+	cardAvatars.Set(url, img, cardAvatarTTL)
+
+	return img
+}
+
+// circleMask masks everything outside the circle inscribed in its bounds.
+type circleMask struct {
+	size int
+}
+
+func (m circleMask) ColorModel() color.Model {
+	return color.AlphaModel
+}
+
+func (m circleMask) Bounds() image.Rectangle {
+	return image.Rect(0, 0, m.size, m.size)
+}
+
+func (m circleMask) At(x, y int) color.Color {
+	r := float64(m.size) / 2
+	dx, dy := float64(x)+0.5-r, float64(y)+0.5-r
+
+	if dx*dx+dy*dy <= r*r {
+		return color.Alpha{0xff}
+	}
+
+	return color.Alpha{0}
+}
+
+func drawText(dst draw.Image, face font.Face, c color.Color, x, y int, text string, maxWidth int) int {
+	d := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+
+	runes := []rune(text)
+
+	for len(runes) > 0 && d.MeasureString(string(runes)).Ceil() > maxWidth {
+		runes = runes[:len(runes)-1]
+		text = string(runes) + "…"
+	}
+
+	d.DrawString(text)
+
+	return d.Dot.X.Ceil()
+}
+
+func renderCard(r *http.Request, state *onyt.State, theme *cardTheme) *image.RGBA {
+	cardFontsOnce.Do(loadCardFonts)
+
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(theme.background), image.Point{}, draw.Src)
+
+	var name, avatarURL, subscribers string
+
+	if c := state.Channel; c != nil {
+		if c.Snippet != nil {
+			name = c.Snippet.Title
+			avatarURL = onyt.BestThumbnail(c.Snippet.Thumbnails)
+		}
+
+		if value, ok := onyt.ChannelMetrics(c)["subscribers"]; ok {
+			subscribers = fmt.Sprintf("%s subscribers", formatCount(value))
+		}
+	}
+
+	avatarRect := image.Rect(cardPadding, cardPadding, cardPadding+cardAvatarSize, cardPadding+cardAvatarSize)
+
+	var avatar image.Image
+
+	if avatarURL != "" {
+		avatar = fetchAvatar(r, avatarURL)
+	}
+
+	if avatar != nil {
+		scaled := image.NewRGBA(image.Rect(0, 0, cardAvatarSize, cardAvatarSize))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), avatar, avatar.Bounds(), draw.Src, nil)
+
+		draw.DrawMask(img, avatarRect, scaled, image.Point{}, circleMask{cardAvatarSize}, image.Point{}, draw.Over)
+	} else {
+		draw.DrawMask(img, avatarRect, image.NewUniform(theme.muted), image.Point{}, circleMask{cardAvatarSize}, image.Point{}, draw.Over)
+	}
+
+	x := cardPadding*2 + cardAvatarSize
+	maxWidth := cardWidth - x - cardPadding
+
+	drawText(img, cardFonts.title, theme.foreground, x, 70, name, maxWidth)
+	drawText(img, cardFonts.body, theme.muted, x, 102, subscribers, maxWidth)
+
+	if v := state.LiveVideo; v != nil {
+		badge := image.Rect(x, 122, x+58, 148)
+		draw.Draw(img, badge, image.NewUniform(cardLiveColor), image.Point{}, draw.Src)
+		drawText(img, cardFonts.badge, color.White, x+10, 141, "LIVE", 48)
+
+		if v.Snippet != nil {
+			drawText(img, cardFonts.body, theme.foreground, x+70, 142, v.Snippet.Title, maxWidth-70)
+		}
+	} else {
+		drawText(img, cardFonts.body, theme.muted, x, 142, "Offline", maxWidth)
+	}
+
+	return img
+}
+
+func serveCard(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	theme, ok := cardThemes[r.URL.Query().Get("theme")]
+
+	if !ok {
+		theme = cardThemes["dark"]
+	}
+
+	var buf bytes.Buffer
+
+	if err := png.Encode(&buf, renderCard(r, poller.Store.Get(), theme)); err != nil {
+		http.Error(w, "unable to render card", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "image/png")
+	w.Header().Set("cache-control", "max-age=60")
+
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+// Chat bots such as Nightbot reject responses longer than 400 characters.
+const chatMaxLength = 400
+
+var defaultChatTemplates = map[string]string{
+	"uptime": `{{if .Live}}{{.Channel}} has been live for {{.Uptime}}{{else}}{{.Channel}} is not live right now{{end}}`,
+	"latest": `{{with .Latest}}Latest video: {{.Title}} ({{.Age}} ago) {{.URL}}{{else}}{{.Channel}} has no videos yet{{end}}`,
+	"subs":   `{{if .Subscribers}}{{.Channel}} has {{.Subscribers}} subscribers{{else}}{{.Channel}} hides its subscriber count{{end}}`,
+}
+
+type ChatVideo struct {
+	Id    string
+	Title string
+	URL   string
+	Age   string
+}
+
+type ChatData struct {
+	Channel     string
+	Live        bool
+	Uptime      string
+	Stream      *ChatVideo
+	Latest      *ChatVideo
+	Subscribers string
+}
+
+// ChatResponder renders the single-line responses of the chat bot commands.
+type ChatResponder struct {
+	templates map[string]*template.Template
+}
+
+func NewChatResponder(overrides map[string]string) (*ChatResponder, error) {
+	c := &ChatResponder{
+		templates: make(map[string]*template.Template, len(defaultChatTemplates)),
+	}
+
+	for name, text := range defaultChatTemplates {
+		if override, ok := overrides[name]; ok {
+			text = override
+		}
+
+		tmpl, err := parseTemplate("chat-"+name, text)
+
+		if err != nil {
+			return nil, fmt.Errorf("chat %s: %w", name, err)
+		}
+
+		c.templates[name] = tmpl
+	}
+
+	for name := range overrides {
+		if _, ok := defaultChatTemplates[name]; !ok {
+			return nil, fmt.Errorf("unknown chat command: %s", name)
+		}
+	}
+
+	return c, nil
+}
+
+// formatChatDuration formats the duration with its two most significant units.
+func formatChatDuration(d time.Duration) string {
+	var parts []string
+
+	for _, unit := range []struct {
+		suffix string
+		value  time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	} {
+		if n := d / unit.value; n > 0 || (len(parts) == 0 && unit.value == time.Second) {
+			parts = append(parts, fmt.Sprintf("%d%s", n, unit.suffix))
+			d -= n * unit.value
+		}
+
+		if len(parts) == 2 {
+			break
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func chatVideo(v *onyt.Video) *ChatVideo {
+	video := &ChatVideo{
+		Id:  v.Id,
+		URL: fmt.Sprintf("https://youtu.be/%s", v.Id),
+	}
+
+	if fields := v.Fields(); fields.PublishedAtUnix > 0 {
+		video.Age = formatChatDuration(time.Since(time.Unix(fields.PublishedAtUnix, 0)))
+	}
+
+	if v.Snippet != nil {
+		video.Title = v.Snippet.Title
+	}
+
+	return video
+}
+
+func chatData(poller *onyt.Poller) *ChatData {
+	state := poller.Store.Get()
+
+	data := &ChatData{
+		Channel: poller.ChannelId,
+		Live:    state.LiveVideo != nil,
+	}
+
+	if c := state.Channel; c != nil && c.Snippet != nil {
+		data.Channel = c.Snippet.Title
+	}
+
+	if value, ok := onyt.ChannelMetrics(state.Channel)["subscribers"]; ok {
+		data.Subscribers = formatCount(value)
+	}
+
+	if v := state.LiveVideo; v != nil {
+		data.Stream = chatVideo(v)
+		data.Uptime = formatChatDuration(time.Duration(v.Fields().UptimeSeconds) * time.Second)
+	}
+
+	if len(state.Videos) > 0 {
+		data.Latest = chatVideo(state.Videos[0])
+	}
+
+	return data
+}
+
+// truncateChat keeps the response on a single line within the length limit.
+func truncateChat(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	if runes := []rune(text); len(runes) > chatMaxLength {
+		text = string(runes[:chatMaxLength-1]) + "…"
+	}
+
+	return text
+}
+
+func (c *ChatResponder) serve(w http.ResponseWriter, r *http.Request, poller *onyt.Poller, name string) {
+	tmpl, ok := c.templates[name]
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var message strings.Builder
+
+	if err := tmpl.Execute(&message, chatData(poller)); err != nil {
+		http.Error(w, "unable to render response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "text/plain; charset=utf-8")
+	w.Header().Set("cache-control", "no-cache")
+
+	fmt.Fprint(w, truncateChat(message.String()))
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the settings too structured to be provided with flags.
+type Config struct {
+	Milestones map[string]onyt.MilestoneRule `yaml:"milestones"`
+	Keywords   []onyt.KeywordRule            `yaml:"keywords"`
+	Notifiers  []*NotifierConfig             `yaml:"notifiers"`
+	Chat       map[string]string             `yaml:"chat"`
+	Digests    []*DigestConfig               `yaml:"digests"`
+	Channels   map[string]*ChannelConfig     `yaml:"channels"`
+}
+
+// ChannelConfig overrides the settings of a channel, those left empty
+// defaulting to the flags.
+type ChannelConfig struct {
+	Interval      time.Duration `yaml:"interval"`
+	LiveInterval  time.Duration `yaml:"liveInterval"`
+	LiveDetection string        `yaml:"liveDetection"`
+	MaxVideos     int           `yaml:"maxVideos"`
+
+	// Notifiers are the names of the notifiers receiving the events of the
+	// channel, every matching one when empty.
+	Notifiers []string `yaml:"notifiers"`
+
+	// Groups are the names of the groups the channel is tagged into.
+	Groups []string `yaml:"groups"`
+
+	liveDetection []string
+}
+
+// Channel returns the overrides of the channel, empty when it has none.
+func (c *Config) Channel(channelId string) *ChannelConfig {
+	if channel, ok := c.Channels[channelId]; ok {
+		return channel
+	}
+
+	return new(ChannelConfig)
+}
+
+// Groups returns the channels tagged into each group.
+func (c *Config) Groups() map[string][]string {
+	channelIds := make([]string, 0, len(c.Channels))
+
+	for channelId := range c.Channels {
+		channelIds = append(channelIds, channelId)
+	}
+
+	sort.Strings(channelIds)
+
+	groups := make(map[string][]string)
+
+	for _, channelId := range channelIds {
+		for _, name := range c.Channels[channelId].Groups {
+			if !contains(groups[name], channelId) {
+				groups[name] = append(groups[name], channelId)
+			}
+		}
+	}
+
+	return groups
+}
+
+func LoadConfig(path string) (*Config, error) {
+	config := new(Config)
+
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+
+	for channelId, channel := range config.Channels {
+		if channel == nil {
+			config.Channels[channelId] = new(ChannelConfig)
+			continue
+		}
+
+		if channel.LiveDetection == "" {
+			continue
+		}
+
+		if channel.liveDetection, err = onyt.ParseLiveDetection(channel.LiveDetection); err != nil {
+			return nil, fmt.Errorf("channel %s: %w", channelId, err)
+		}
+	}
+
+	return config, nil
+}
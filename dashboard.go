@@ -0,0 +1,72 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+	"sync"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+// dashboardEvents is the number of recent events listed by the dashboard.
+const dashboardEvents = 50
+
+//go:embed dashboard.html
+var dashboardHTML []byte
+
+// Dashboard serves the embedded single-page ops view, keeping the recent
+// events it lists.
+type Dashboard struct {
+	mu     sync.RWMutex
+	events []onyt.Event
+}
+
+func NewDashboard(events *onyt.EventBus) *Dashboard {
+	d := new(Dashboard)
+
+	events.Subscribe(func(evt onyt.Event) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		if len(d.events) == dashboardEvents {
+			d.events = d.events[1:]
+		}
+
+		d.events = append(d.events, evt)
+	})
+
+	return d
+}
+
+// Events returns the recent events, the most recent first.
+func (d *Dashboard) Events() []onyt.Event {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	result := make([]onyt.Event, 0, len(d.events))
+
+	for i := len(d.events) - 1; i >= 0; i-- {
+		result = append(result, d.events[i])
+	}
+
+	return result
+}
+
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/ui":
+		// The redirect is relative so it survives the base path.
+		w.Header().Set("location", "ui/")
+		w.WriteHeader(http.StatusMovedPermanently)
+
+	case "/ui/":
+		w.Header().Set("content-type", "text/html; charset=utf-8")
+		w.Write(dashboardHTML)
+
+	case "/ui/events":
+		writeJSON(w, d.Events())
+
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,801 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+	"google.golang.org/api/youtube/v3"
+	_ "modernc.org/sqlite"
+)
+
+var migrations = []string{
+	`CREATE TABLE milestones (
+		channel_id TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		milestone INTEGER NOT NULL,
+		PRIMARY KEY (channel_id, metric)
+	)`,
+	`CREATE TABLE sessions (
+		video_id TEXT PRIMARY KEY,
+		channel_id TEXT NOT NULL,
+		title TEXT NOT NULL,
+		started_at INTEGER NOT NULL,
+		ended_at INTEGER NOT NULL,
+		peak_viewers INTEGER NOT NULL,
+		average_viewers REAL NOT NULL,
+		like_count INTEGER NOT NULL
+	);
+	CREATE INDEX sessions_channel_id ON sessions (channel_id, started_at)`,
+	`CREATE TABLE uploads (
+		video_id TEXT PRIMARY KEY,
+		channel_id TEXT NOT NULL,
+		title TEXT NOT NULL,
+		published_at INTEGER NOT NULL
+	);
+	CREATE INDEX uploads_channel_id ON uploads (channel_id, published_at)`,
+	`ALTER TABLE uploads ADD COLUMN description TEXT NOT NULL DEFAULT '';
+	CREATE VIRTUAL TABLE uploads_fts USING fts5(title, description, content='uploads', content_rowid='rowid');
+	CREATE TRIGGER uploads_ai AFTER INSERT ON uploads BEGIN
+		INSERT INTO uploads_fts (rowid, title, description) VALUES (new.rowid, new.title, new.description);
+	END;
+	CREATE TRIGGER uploads_ad AFTER DELETE ON uploads BEGIN
+		INSERT INTO uploads_fts (uploads_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+	END;
+	CREATE TRIGGER uploads_au AFTER UPDATE ON uploads BEGIN
+		INSERT INTO uploads_fts (uploads_fts, rowid, title, description) VALUES ('delete', old.rowid, old.title, old.description);
+		INSERT INTO uploads_fts (rowid, title, description) VALUES (new.rowid, new.title, new.description);
+	END;
+	INSERT INTO uploads_fts (uploads_fts) VALUES ('rebuild')`,
+	`CREATE TABLE channels (
+		channel_id TEXT PRIMARY KEY,
+		removed INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	)`,
+	`CREATE TABLE samples (
+		channel_id TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		time INTEGER NOT NULL,
+		value INTEGER NOT NULL
+	);
+	CREATE INDEX samples_channel_id ON samples (channel_id, metric, time)`,
+	`CREATE TABLE events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		time INTEGER NOT NULL,
+		data TEXT NOT NULL
+	)`,
+	`CREATE TABLE deliveries (
+		id INTEGER PRIMARY KEY,
+		notifier TEXT NOT NULL,
+		type TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		time INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		message TEXT NOT NULL,
+		attempts INTEGER NOT NULL,
+		last_error TEXT NOT NULL,
+		next_attempt_at INTEGER NOT NULL,
+		dead INTEGER NOT NULL
+	)`,
+	`ALTER TABLE uploads ADD COLUMN status TEXT NOT NULL DEFAULT 'public';
+	ALTER TABLE uploads ADD COLUMN checked_at INTEGER NOT NULL DEFAULT 0`,
+}
+
+// Database persists the data which must survive restarts in SQLite.
+type Database struct {
+	db *sql.DB
+}
+
+func OpenDatabase(path string) (*Database, error) {
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path))
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+
+	d := &Database{
+		db: db,
+	}
+
+	if err := d.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *Database) migrate() error {
+	var version int
+
+	if err := d.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := d.db.Begin()
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+func (d *Database) LastMilestone(channelId, metric string) (uint64, bool, error) {
+	var milestone uint64
+
+	err := d.db.QueryRow("SELECT milestone FROM milestones WHERE channel_id = ? AND metric = ?", channelId, metric).
+		Scan(&milestone)
+
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	return milestone, true, nil
+}
+
+func (d *Database) SetMilestone(channelId, metric string, milestone uint64) error {
+	_, err := d.db.Exec("INSERT INTO milestones (channel_id, metric, milestone) VALUES (?, ?, ?) ON CONFLICT (channel_id, metric) DO UPDATE SET milestone = excluded.milestone", channelId, metric, milestone)
+
+	return err
+}
+
+// SetChannel records a channel added or removed at runtime, overriding the
+// configured channels on the next start.
+func (d *Database) SetChannel(channelId string, removed bool) error {
+	_, err := d.db.Exec("INSERT INTO channels (channel_id, removed, updated_at) VALUES (?, ?, ?) ON CONFLICT (channel_id) DO UPDATE SET removed = excluded.removed, updated_at = excluded.updated_at", channelId, removed, time.Now().Unix())
+
+	return err
+}
+
+// Channels returns the channels added and removed at runtime, in the order
+// they were.
+func (d *Database) Channels() ([]string, []string, error) {
+	rows, err := d.db.Query("SELECT channel_id, removed FROM channels ORDER BY updated_at, rowid")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	var added, removed []string
+
+	for rows.Next() {
+		var (
+			channelId string
+			isRemoved bool
+		)
+
+		if err := rows.Scan(&channelId, &isRemoved); err != nil {
+			return nil, nil, err
+		}
+
+		if isRemoved {
+			removed = append(removed, channelId)
+		} else {
+			added = append(added, channelId)
+		}
+	}
+
+	return added, removed, rows.Err()
+}
+
+// WriteSamples stores the samples as the history of the channels, the
+// viewers and likes of the live videos of a channel being summed.
+func (d *Database) WriteSamples(ctx context.Context, samples []*Sample) error {
+	type key struct {
+		channelId string
+		metric    string
+		time      int64
+	}
+
+	values := make(map[key]uint64)
+	keys := make([]key, 0)
+
+	for _, sample := range samples {
+		for field, value := range sample.Fields {
+			k := key{sample.Tags["channel"], field, sample.Time.Unix()}
+
+			if sample.Measurement != "channel" {
+				k.metric = sample.Measurement + "_" + field
+			}
+
+			if _, ok := values[k]; !ok {
+				keys = append(keys, k)
+			}
+
+			values[k] += value
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if _, err := tx.Exec("INSERT INTO samples (channel_id, metric, time, value) VALUES (?, ?, ?, ?)", k.channelId, k.metric, k.time, values[k]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AppendEvent stores the event, keeping the latest events up to the limit,
+// and returns its ID.
+func (d *Database) AppendEvent(evt onyt.Event, limit int) (int64, error) {
+	data, err := json.Marshal(evt.Data)
+
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := d.db.Exec("INSERT INTO events (type, channel_id, time, data) VALUES (?, ?, ?, ?)", evt.Type, evt.ChannelId, evt.Time.UnixMilli(), string(data))
+
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := d.db.Exec("DELETE FROM events WHERE id <= ?", id-int64(limit)); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// EventsSince returns the stored events of the channels and types following
+// the ID, the oldest first.
+func (d *Database) EventsSince(since int64, limit int, channels, types []string) ([]*LoggedEvent, error) {
+	query := "SELECT id, type, channel_id, time, data FROM events WHERE id > ?"
+	args := []any{since}
+
+	for column, values := range map[string][]string{"channel_id": channels, "type": types} {
+		if len(values) == 0 {
+			continue
+		}
+
+		query += " AND " + column + " IN (?" + strings.Repeat(", ?", len(values)-1) + ")"
+
+		for _, value := range values {
+			args = append(args, value)
+		}
+	}
+
+	rows, err := d.db.Query(query+" ORDER BY id LIMIT ?", append(args, limit)...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	events := make([]*LoggedEvent, 0)
+
+	for rows.Next() {
+		var (
+			evt  = new(LoggedEvent)
+			unix int64
+			data string
+		)
+
+		if err := rows.Scan(&evt.Id, &evt.Type, &evt.ChannelId, &unix, &data); err != nil {
+			return nil, err
+		}
+
+		evt.Time = time.UnixMilli(unix)
+		evt.Data = json.RawMessage(data)
+
+		events = append(events, evt)
+	}
+
+	return events, rows.Err()
+}
+
+// LastEventId returns the ID of the latest stored event, 0 when none.
+func (d *Database) LastEventId() (int64, error) {
+	var id sql.NullInt64
+
+	err := d.db.QueryRow("SELECT MAX(id) FROM events").Scan(&id)
+
+	return id.Int64, err
+}
+
+// SaveDelivery stores the queued delivery, replacing it when already stored.
+func (d *Database) SaveDelivery(delivery *Delivery) error {
+	data, err := json.Marshal(delivery.Event.Data)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec("INSERT OR REPLACE INTO deliveries (id, notifier, type, channel_id, time, data, message, attempts, last_error, next_attempt_at, dead) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", delivery.Id, delivery.Notifier, delivery.Event.Type, delivery.Event.ChannelId, delivery.Event.Time.UnixMilli(), string(data), delivery.Message, delivery.Attempts, delivery.LastError, delivery.NextAttemptAt.UnixMilli(), delivery.Dead)
+
+	return err
+}
+
+func (d *Database) DeleteDelivery(id int64) error {
+	_, err := d.db.Exec("DELETE FROM deliveries WHERE id = ?", id)
+
+	return err
+}
+
+// Deliveries returns the queued deliveries, dead letters included.
+func (d *Database) Deliveries() ([]*Delivery, error) {
+	rows, err := d.db.Query("SELECT id, notifier, type, channel_id, time, data, message, attempts, last_error, next_attempt_at, dead FROM deliveries ORDER BY id")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	deliveries := make([]*Delivery, 0)
+
+	for rows.Next() {
+		var (
+			delivery       = new(Delivery)
+			unix, nextUnix int64
+			data           string
+		)
+
+		if err := rows.Scan(&delivery.Id, &delivery.Notifier, &delivery.Event.Type, &delivery.Event.ChannelId, &unix, &data, &delivery.Message, &delivery.Attempts, &delivery.LastError, &nextUnix, &delivery.Dead); err != nil {
+			return nil, err
+		}
+
+		delivery.Event.Time = time.UnixMilli(unix)
+		delivery.NextAttemptAt = time.UnixMilli(nextUnix)
+
+		if delivery.Event.Data, err = decodeEventData(delivery.Event.Type, []byte(data)); err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// HistoryPoint is the value of a metric of the history at a time.
+type HistoryPoint struct {
+	Time  time.Time
+	Value uint64
+}
+
+// History returns the values of the metric of the channel between the times,
+// the highest of each step when non-zero.
+func (d *Database) History(channelId, metric string, from, to time.Time, step time.Duration) ([]*HistoryPoint, error) {
+	seconds := int64(step / time.Second)
+
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	rows, err := d.db.Query("SELECT time / ? * ? AS bucket, MAX(value) FROM samples WHERE channel_id = ? AND metric = ? AND time BETWEEN ? AND ? GROUP BY bucket ORDER BY bucket", seconds, seconds, channelId, metric, from.Unix(), to.Unix())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	points := make([]*HistoryPoint, 0)
+
+	for rows.Next() {
+		var (
+			unix  int64
+			point = new(HistoryPoint)
+		)
+
+		if err := rows.Scan(&unix, &point.Value); err != nil {
+			return nil, err
+		}
+
+		point.Time = time.Unix(unix, 0)
+		points = append(points, point)
+	}
+
+	return points, rows.Err()
+}
+
+// SessionsBetween returns the archived live sessions of the channel which
+// overlap the times, the oldest first.
+func (d *Database) SessionsBetween(channelId string, from, to time.Time) ([]*ArchivedSession, error) {
+	rows, err := d.db.Query("SELECT video_id, channel_id, title, started_at, ended_at, peak_viewers, average_viewers, like_count FROM sessions WHERE channel_id = ? AND started_at <= ? AND ended_at >= ? ORDER BY started_at", channelId, to.Unix(), from.Unix())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sessions := make([]*ArchivedSession, 0)
+
+	for rows.Next() {
+		s := new(ArchivedSession)
+
+		if err := rows.Scan(&s.VideoId, &s.ChannelId, &s.Title, &s.StartedAt, &s.EndedAt, &s.PeakViewers, &s.AverageViewers, &s.LikeCount); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+// ArchivedUploads returns every archived upload, the oldest first.
+func (d *Database) ArchivedUploads() ([]*ArchivedUpload, error) {
+	rows, err := d.db.Query("SELECT video_id, channel_id, title, description, published_at FROM uploads ORDER BY published_at")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	uploads := make([]*ArchivedUpload, 0)
+
+	for rows.Next() {
+		u := new(ArchivedUpload)
+
+		if err := rows.Scan(&u.VideoId, &u.ChannelId, &u.Title, &u.Description, &u.PublishedAt); err != nil {
+			return nil, err
+		}
+
+		uploads = append(uploads, u)
+	}
+
+	return uploads, rows.Err()
+}
+
+// ArchivedSessions returns every archived live session, the oldest first.
+func (d *Database) ArchivedSessions() ([]*ArchivedSession, error) {
+	rows, err := d.db.Query("SELECT video_id, channel_id, title, started_at, ended_at, peak_viewers, average_viewers, like_count FROM sessions ORDER BY started_at")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	sessions := make([]*ArchivedSession, 0)
+
+	for rows.Next() {
+		s := new(ArchivedSession)
+
+		if err := rows.Scan(&s.VideoId, &s.ChannelId, &s.Title, &s.StartedAt, &s.EndedAt, &s.PeakViewers, &s.AverageViewers, &s.LikeCount); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+// RecordSession stores the ended live session. Sessions recovered from past
+// live videos don't replace the ones tracked while live.
+func (d *Database) RecordSession(channelId string, session *onyt.Session, tracked bool) error {
+	query := "INSERT INTO sessions (video_id, channel_id, title, started_at, ended_at, peak_viewers, average_viewers, like_count) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+
+	if tracked {
+		query += " ON CONFLICT (video_id) DO UPDATE SET title = excluded.title, started_at = excluded.started_at, ended_at = excluded.ended_at, peak_viewers = excluded.peak_viewers, average_viewers = excluded.average_viewers, like_count = excluded.like_count"
+	} else {
+		query += " ON CONFLICT (video_id) DO NOTHING"
+	}
+
+	_, err := d.db.Exec(query, session.VideoId, channelId, session.Title, session.StartedAt, session.EndedAt, session.PeakViewers, session.AverageViewers, session.LikeCount)
+
+	return err
+}
+
+func (d *Database) SessionStarts(channelId string, since time.Time) ([]time.Time, error) {
+	rows, err := d.db.Query("SELECT started_at FROM sessions WHERE channel_id = ? AND started_at >= ? ORDER BY started_at", channelId, since.Unix())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	starts := make([]time.Time, 0)
+
+	for rows.Next() {
+		var startedAt int64
+
+		if err := rows.Scan(&startedAt); err != nil {
+			return nil, err
+		}
+
+		starts = append(starts, time.Unix(startedAt, 0))
+	}
+
+	return starts, rows.Err()
+}
+
+// pastSessions returns the sessions of the live videos which already ended.
+func pastSessions(state *onyt.State) []*onyt.Session {
+	sessions := make([]*onyt.Session, 0)
+
+	for _, v := range state.Videos {
+		d := v.LiveStreamingDetails
+
+		if d == nil || d.ActualStartTime == "" || d.ActualEndTime == "" {
+			continue
+		}
+
+		startedAt, err := time.Parse(time.RFC3339, d.ActualStartTime)
+
+		if err != nil {
+			continue
+		}
+
+		endedAt, err := time.Parse(time.RFC3339, d.ActualEndTime)
+
+		if err != nil {
+			continue
+		}
+
+		session := &onyt.Session{
+			VideoId:         v.Id,
+			StartedAt:       startedAt.Unix(),
+			EndedAt:         endedAt.Unix(),
+			DurationSeconds: int64(endedAt.Sub(startedAt).Seconds()),
+		}
+
+		if v.Snippet != nil {
+			session.Title = v.Snippet.Title
+		}
+
+		if v.Statistics != nil {
+			session.LikeCount = v.Statistics.LikeCount
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions
+}
+
+func (d *Database) RecordUpload(channelId string, video *onyt.Video) error {
+	fields := video.Fields()
+
+	if video.Snippet == nil || fields.PublishedAtUnix == 0 {
+		return nil
+	}
+
+	_, err := d.db.Exec("INSERT INTO uploads (video_id, channel_id, title, description, published_at) VALUES (?, ?, ?, ?, ?) ON CONFLICT (video_id) DO UPDATE SET title = excluded.title, description = excluded.description WHERE title != excluded.title OR description != excluded.description", video.Id, channelId, video.Snippet.Title, video.Snippet.Description, fields.PublishedAtUnix)
+
+	return err
+}
+
+func (d *Database) UploadTimes(channelId string) ([]time.Time, error) {
+	rows, err := d.db.Query("SELECT published_at FROM uploads WHERE channel_id = ? ORDER BY published_at", channelId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	times := make([]time.Time, 0)
+
+	for rows.Next() {
+		var publishedAt int64
+
+		if err := rows.Scan(&publishedAt); err != nil {
+			return nil, err
+		}
+
+		times = append(times, time.Unix(publishedAt, 0))
+	}
+
+	return times, rows.Err()
+}
+
+type SearchResult struct {
+	VideoId     string `json:"videoId"`
+	ChannelId   string `json:"channelId"`
+	Title       string `json:"title"`
+	Snippet     string `json:"snippet"`
+	PublishedAt int64  `json:"publishedAt"`
+}
+
+// SearchUploads matches the FTS5 query against the titles and descriptions of
+// the archived uploads, the best matches first.
+func (d *Database) SearchUploads(channelId, query string, limit, offset int) ([]*SearchResult, error) {
+	rows, err := d.db.Query(`SELECT u.video_id, u.channel_id, u.title, snippet(uploads_fts, 1, '', '', '…', 24), u.published_at
+		FROM uploads_fts JOIN uploads u ON u.rowid = uploads_fts.rowid
+		WHERE uploads_fts MATCH ? AND (? = '' OR u.channel_id = ?)
+		ORDER BY bm25(uploads_fts, 5.0, 1.0) LIMIT ? OFFSET ?`, query, channelId, channelId, limit, offset)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]*SearchResult, 0)
+
+	for rows.Next() {
+		result := new(SearchResult)
+
+		if err := rows.Scan(&result.VideoId, &result.ChannelId, &result.Title, &result.Snippet, &result.PublishedAt); err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
+
+func (d *Database) RandomUpload(channelId string) (*VideoLink, error) {
+	var (
+		id, title   string
+		publishedAt int64
+	)
+
+	err := d.db.QueryRow("SELECT video_id, title, published_at FROM uploads WHERE channel_id = ? ORDER BY random() LIMIT 1", channelId).
+		Scan(&id, &title, &publishedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newVideoLink(id, title, publishedAt), nil
+}
+
+// UploadStatus is the availability of an archived upload, as of its last
+// check.
+type UploadStatus struct {
+	VideoId   string
+	ChannelId string
+	Title     string
+	Status    string
+}
+
+// UploadStatuses returns the availability of every archived upload, the least
+// recently checked first.
+func (d *Database) UploadStatuses() ([]*UploadStatus, error) {
+	rows, err := d.db.Query("SELECT video_id, channel_id, title, status FROM uploads ORDER BY checked_at, published_at")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	statuses := make([]*UploadStatus, 0)
+
+	for rows.Next() {
+		s := new(UploadStatus)
+
+		if err := rows.Scan(&s.VideoId, &s.ChannelId, &s.Title, &s.Status); err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, s)
+	}
+
+	return statuses, rows.Err()
+}
+
+// CheckUpload stores the availability and the title of an archived upload.
+func (d *Database) CheckUpload(videoId, title, status string, checkedAt time.Time) error {
+	_, err := d.db.Exec("UPDATE uploads SET title = ?, status = ?, checked_at = ? WHERE video_id = ?", title, status, checkedAt.Unix(), videoId)
+
+	return err
+}
+
+// Backfill archives all the uploads of the channel.
+func (d *Database) Backfill(ctx context.Context, src *youtube.Service, quota *onyt.QuotaMeter, channelId string) error {
+	count := 0
+
+	err := onyt.FetchUploads(ctx, src, quota, channelId, func(videos []*onyt.Video) error {
+		for _, video := range videos {
+			if err := d.RecordUpload(channelId, video); err != nil {
+				return err
+			}
+		}
+
+		count += len(videos)
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("channel", channelId).Int("count", count).Msg("Uploads backfilled")
+
+	return nil
+}
+
+// Watch stores the uploads of the channel until the context is done,
+// including the past sessions found in its latest videos.
+func (d *Database) Watch(ctx context.Context, poller *onyt.Poller) {
+	poller.Store.Watch(ctx, func(state *onyt.State, version uint64) {
+		for _, video := range state.Videos {
+			if err := d.RecordUpload(poller.ChannelId, video); err != nil {
+				log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to record upload")
+			}
+		}
+
+		for _, session := range pastSessions(state) {
+			if err := d.RecordSession(poller.ChannelId, session, false); err != nil {
+				log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to record session")
+			}
+		}
+	})
+}
+
+// Record stores the live sessions of the channels as they end, until the
+// context is done.
+func (d *Database) Record(ctx context.Context, events *onyt.EventBus) {
+	unsubscribe := events.Subscribe(func(evt onyt.Event) {
+		session, ok := evt.Data.(*onyt.Session)
+
+		if !ok || evt.Type != "live_ended" {
+			return
+		}
+
+		if err := d.RecordSession(evt.ChannelId, session, true); err != nil {
+			log.Err(err).Str("channel", evt.ChannelId).Msg("Unable to record session")
+		}
+	})
+
+	go func() {
+		<-ctx.Done()
+
+		unsubscribe()
+	}()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+func init() {
+	expvar.Publish("throttled", expvar.Func(func() any {
+		return onyt.DefaultThrottle.Hosts()
+	}))
+
+	expvar.Publish("pipeline", expvar.Func(func() any {
+		return onyt.DefaultMetrics.Stats()
+	}))
+}
+
+func startDebugServer(addr string) error {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	mux.HandleFunc("/debug/goroutines", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().
+			Set("content-type", "text/plain; charset=utf-8")
+
+		runtimepprof.Lookup("goroutine").
+			WriteTo(w, 2)
+	})
+
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+const (
+	// deliveryBackoff is the delay before the first retry of a delivery,
+	// doubled on every attempt up to deliveryMaxBackoff.
+	deliveryBackoff    = 10 * time.Second
+	deliveryMaxBackoff = time.Hour
+)
+
+var errDeliveryUnknown = errors.New("delivery not found")
+
+// eventDataTypes are the types of the data of the events, decoded back when
+// a delivery is loaded from the database.
+var eventDataTypes = map[string]func() any{
+	"video_uploaded":      func() any { return new(onyt.Video) },
+	"live_started":        func() any { return new(onyt.Video) },
+	"live_updated":        func() any { return new(onyt.LiveUpdate) },
+	"live_ended":          func() any { return new(onyt.Session) },
+	"video_removed":       func() any { return new(onyt.Tombstone) },
+	"keyword_match":       func() any { return new(onyt.KeywordMatch) },
+	"community_post":      func() any { return new(onyt.CommunityPost) },
+	"milestone_reached":   func() any { return new(onyt.Milestone) },
+	"playlist_item_added": func() any { return new(onyt.PlaylistItem) },
+	"category_changed":    func() any { return new(onyt.CategoryChange) },
+	"digest":              func() any { return new(Digest) },
+}
+
+// decodeEventData decodes the data of an event of the type, as a generic
+// value when unknown.
+func decodeEventData(eventType string, data []byte) (any, error) {
+	factory, ok := eventDataTypes[eventType]
+
+	if !ok {
+		var v any
+
+		err := json.Unmarshal(data, &v)
+
+		return v, err
+	}
+
+	v := factory()
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Delivery is a notification queued for a notifier.
+type Delivery struct {
+	Id            int64      `json:"id"`
+	Notifier      string     `json:"notifier"`
+	Event         onyt.Event `json:"event"`
+	Message       string     `json:"message,omitempty"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"lastError,omitempty"`
+	NextAttemptAt time.Time  `json:"nextAttemptAt"`
+	Dead          bool       `json:"dead"`
+
+	sending bool
+}
+
+// DeliveryQueue delivers the notifications at least once, retrying them with
+// backoff until the attempts are exhausted, when they are moved to the dead
+// letters. The queue is persisted to the database when any.
+type DeliveryQueue struct {
+	database    *Database
+	maxAttempts int
+
+	// Timeout bounds each attempt, failing it once exceeded, unbounded when
+	// zero.
+	Timeout time.Duration
+
+	mu         sync.Mutex
+	nextId     int64
+	deliveries map[int64]*Delivery
+	wake       chan struct{}
+}
+
+func NewDeliveryQueue(database *Database, maxAttempts int) (*DeliveryQueue, error) {
+	q := &DeliveryQueue{
+		database:    database,
+		maxAttempts: maxAttempts,
+		deliveries:  make(map[int64]*Delivery),
+		wake:        make(chan struct{}, 1),
+	}
+
+	if database == nil {
+		return q, nil
+	}
+
+	deliveries, err := database.Deliveries()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range deliveries {
+		if d.Id > q.nextId {
+			q.nextId = d.Id
+		}
+
+		q.deliveries[d.Id] = d
+	}
+
+	return q, nil
+}
+
+// save persists the delivery, with the lock held.
+func (q *DeliveryQueue) save(d *Delivery) {
+	if q.database == nil {
+		return
+	}
+
+	if err := q.database.SaveDelivery(d); err != nil {
+		log.Err(err).Str("notifier", d.Notifier).Msg("Unable to persist delivery")
+	}
+}
+
+// delete forgets the delivery, with the lock held.
+func (q *DeliveryQueue) delete(d *Delivery) {
+	delete(q.deliveries, d.Id)
+
+	if q.database == nil {
+		return
+	}
+
+	if err := q.database.DeleteDelivery(d.Id); err != nil {
+		log.Err(err).Str("notifier", d.Notifier).Msg("Unable to delete delivery")
+	}
+}
+
+func (q *DeliveryQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue queues the notification for the notifier.
+func (q *DeliveryQueue) Enqueue(notifier string, n *Notification) {
+	q.mu.Lock()
+
+	q.nextId++
+
+	d := &Delivery{
+		Id:            q.nextId,
+		Notifier:      notifier,
+		Event:         n.Event,
+		Message:       n.Message,
+		NextAttemptAt: time.Now(),
+	}
+
+	q.deliveries[d.Id] = d
+	q.save(d)
+
+	q.mu.Unlock()
+
+	q.notify()
+}
+
+// Run sends the due deliveries with the function until the context is done.
+func (q *DeliveryQueue) Run(ctx context.Context, send func(ctx context.Context, d *Delivery) error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		case <-q.wake:
+		}
+
+		now := time.Now()
+
+		q.mu.Lock()
+
+		for _, d := range q.deliveries {
+			if d.Dead || d.sending || d.NextAttemptAt.After(now) {
+				continue
+			}
+
+			d.sending = true
+
+			go q.attempt(ctx, d, send)
+		}
+
+		q.mu.Unlock()
+	}
+}
+
+func (q *DeliveryQueue) attempt(ctx context.Context, d *Delivery, send func(ctx context.Context, d *Delivery) error) {
+	sendCtx := ctx
+
+	if q.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		sendCtx, cancel = context.WithTimeout(ctx, q.Timeout)
+		defer cancel()
+	}
+
+	err := send(sendCtx, d)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	d.sending = false
+
+	if err == nil {
+		q.delete(d)
+		return
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	d.Attempts++
+	d.LastError = err.Error()
+
+	if d.Attempts >= q.maxAttempts {
+		d.Dead = true
+
+		log.Err(err).Str("notifier", d.Notifier).Str("type", d.Event.Type).Int("attempts", d.Attempts).Msg("Moving notification to the dead letters")
+	} else {
+		backoff := deliveryBackoff << (d.Attempts - 1)
+
+		if backoff > deliveryMaxBackoff || backoff <= 0 {
+			backoff = deliveryMaxBackoff
+		}
+
+		d.NextAttemptAt = time.Now().Add(backoff)
+
+		log.Err(err).Str("notifier", d.Notifier).Str("type", d.Event.Type).Dur("backoff", backoff).Msg("Unable to send notification, retrying")
+	}
+
+	q.save(d)
+}
+
+// DeadLetters returns the deliveries whose attempts are exhausted, the
+// oldest first.
+func (q *DeliveryQueue) DeadLetters() []*Delivery {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := make([]*Delivery, 0)
+
+	for _, d := range q.deliveries {
+		if d.Dead {
+			copied := *d
+			result = append(result, &copied)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+
+	return result
+}
+
+// Retry queues the dead letter again, with its attempts reset.
+func (q *DeliveryQueue) Retry(id int64) error {
+	q.mu.Lock()
+
+	d, ok := q.deliveries[id]
+
+	if !ok || !d.Dead {
+		q.mu.Unlock()
+		return errDeliveryUnknown
+	}
+
+	d.Dead = false
+	d.Attempts = 0
+	d.NextAttemptAt = time.Now()
+
+	q.save(d)
+
+	q.mu.Unlock()
+
+	q.notify()
+
+	return nil
+}
+
+// Discard forgets the dead letter.
+func (q *DeliveryQueue) Discard(id int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	d, ok := q.deliveries[id]
+
+	if !ok || !d.Dead {
+		return errDeliveryUnknown
+	}
+
+	q.delete(d)
+
+	return nil
+}
+
+// serveDeadLetters lists the dead letters, retries them with POST
+// /admin/deadletter/{id}/retry and discards them with DELETE
+// /admin/deadletter/{id}.
+func (s *Server) serveDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/deadletter"), "/")
+
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			w.Header().Set("allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		writeJSON(w, s.Deliveries.DeadLetters())
+		return
+	}
+
+	value, action, _ := strings.Cut(rest, "/")
+	id, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case action == "retry" && r.Method == http.MethodPost:
+		err = s.Deliveries.Retry(id)
+
+	case action == "" && r.Method == http.MethodDelete:
+		err = s.Deliveries.Discard(id)
+
+	case action == "retry":
+		w.Header().Set("allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+
+	case action == "":
+		w.Header().Set("allow", "DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if action == "retry" {
+		w.WriteHeader(http.StatusAccepted)
+	} else {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+// serveDiff answers the difference between the retained state versions given
+// by the from and to query parameters, the latter defaulting to the current
+// version.
+func (s *Server) serveDiff(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	query := r.URL.Query()
+
+	oldest, latest := poller.Store.Versions()
+
+	from, err := strconv.ParseUint(query.Get("from"), 10, 64)
+
+	if err != nil {
+		http.Error(w, "invalid from parameter", http.StatusBadRequest)
+		return
+	}
+
+	to := latest
+
+	if value := query.Get("to"); value != "" {
+		if to, err = strconv.ParseUint(value, 10, 64); err != nil {
+			http.Error(w, "invalid to parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	previous, ok := poller.Store.Version(from)
+	next, found := poller.Store.Version(to)
+
+	if !ok || !found {
+		w.Header().Set("x-oldest-version", strconv.FormatUint(oldest, 10))
+		w.Header().Set("x-state-version", strconv.FormatUint(latest, 10))
+
+		http.Error(w, "version not retained", http.StatusNotFound)
+		return
+	}
+
+	trim := s.trimmed(r)
+
+	diff := onyt.DiffStates(onyt.TrimState(previous, trim), onyt.TrimState(next, trim))
+	diff.From = from
+	diff.To = to
+
+	writeJSON(w, diff)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+type DigestConfig struct {
+	Name     string   `yaml:"name"`
+	Schedule string   `yaml:"schedule"`
+	Channels []string `yaml:"channels"`
+}
+
+type DigestVideo struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	PublishedAt int64  `json:"publishedAt"`
+}
+
+type DigestMetric struct {
+	Value uint64 `json:"value"`
+	Delta int64  `json:"delta"`
+}
+
+// Digest summarizes the activity of a channel over a period.
+type Digest struct {
+	Name     string                   `json:"name"`
+	Channel  string                   `json:"channel"`
+	From     time.Time                `json:"from"`
+	To       time.Time                `json:"to"`
+	Metrics  map[string]*DigestMetric `json:"metrics"`
+	Videos   []*DigestVideo           `json:"videos"`
+	Sessions []*onyt.Session          `json:"sessions"`
+	LiveTime int64                    `json:"liveTimeSeconds"`
+}
+
+// Summary formats the digest as plain text.
+func (d *Digest) Summary() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Digest for %s (%s – %s)\n", d.Channel, d.From.Format("Jan 2 15:04"), d.To.Format("Jan 2 15:04"))
+
+	for _, metric := range []struct{ name, label string }{
+		{"subscribers", "Subscribers"},
+		{"views", "Views"},
+		{"videos", "Videos"},
+	} {
+		if m, ok := d.Metrics[metric.name]; ok {
+			fmt.Fprintf(&b, "%s: %s (%+d)\n", metric.label, formatCount(m.Value), m.Delta)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nNew videos: %d\n", len(d.Videos))
+
+	for _, v := range d.Videos {
+		fmt.Fprintf(&b, "- %s https://www.youtube.com/watch?v=%s\n", v.Title, v.Id)
+	}
+
+	fmt.Fprintf(&b, "\nLive sessions: %d (%s)\n", len(d.Sessions), formatChatDuration(time.Duration(d.LiveTime)*time.Second))
+
+	for _, s := range d.Sessions {
+		fmt.Fprintf(&b, "- %s (%s, peak %s viewers)\n", s.Title, formatChatDuration(time.Duration(s.DurationSeconds)*time.Second), formatCount(s.PeakViewers))
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+type digestBaseline struct {
+	time    time.Time
+	metrics map[string]uint64
+}
+
+// DigestScheduler periodically emits a digest event per channel, delivered
+// by the notifiers routing the "digest" event.
+type DigestScheduler struct {
+	configs []*DigestConfig
+	pollers func() []*onyt.Poller
+	events  *onyt.EventBus
+
+	mu        sync.Mutex
+	baselines map[string]*digestBaseline
+}
+
+func NewDigestScheduler(configs []*DigestConfig, pollers func() []*onyt.Poller, events *onyt.EventBus) (*DigestScheduler, error) {
+	for i, config := range configs {
+		if config.Name == "" {
+			config.Name = fmt.Sprintf("digest-%d", i+1)
+		}
+
+		if _, err := cron.ParseStandard(config.Schedule); err != nil {
+			return nil, fmt.Errorf("digest %s: %w", config.Name, err)
+		}
+	}
+
+	return &DigestScheduler{
+		configs:   configs,
+		pollers:   pollers,
+		events:    events,
+		baselines: make(map[string]*digestBaseline),
+	}, nil
+}
+
+// record stores the metrics as the baseline of the next digest, returning the
+// previous one. Existing baselines are kept unless replace is set.
+func (s *DigestScheduler) record(config *DigestConfig, poller *onyt.Poller, metrics map[string]uint64, now time.Time, replace bool) (*digestBaseline, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := config.Name + "/" + poller.ChannelId
+	baseline, ok := s.baselines[key]
+
+	if !ok || replace {
+		s.baselines[key] = &digestBaseline{now, metrics}
+	}
+
+	return baseline, ok
+}
+
+func (s *DigestScheduler) compile(config *DigestConfig, poller *onyt.Poller, now time.Time) *Digest {
+	state := poller.Store.Get()
+
+	if state.Channel == nil {
+		return nil
+	}
+
+	metrics := onyt.ChannelMetrics(state.Channel)
+
+	baseline, ok := s.record(config, poller, metrics, now, true)
+
+	// The first digest of a channel only records its baseline.
+	if !ok {
+		return nil
+	}
+
+	digest := &Digest{
+		Name:     config.Name,
+		Channel:  poller.ChannelId,
+		From:     baseline.time,
+		To:       now,
+		Metrics:  make(map[string]*DigestMetric, len(metrics)),
+		Videos:   make([]*DigestVideo, 0),
+		Sessions: make([]*onyt.Session, 0),
+	}
+
+	if state.Channel.Snippet != nil {
+		digest.Channel = state.Channel.Snippet.Title
+	}
+
+	for name, value := range metrics {
+		digest.Metrics[name] = &DigestMetric{
+			Value: value,
+			Delta: int64(value) - int64(baseline.metrics[name]),
+		}
+	}
+
+	for _, v := range state.Videos {
+		fields := v.Fields()
+
+		if fields.PublishedAtUnix <= digest.From.Unix() || v.Snippet == nil {
+			continue
+		}
+
+		digest.Videos = append(digest.Videos, &DigestVideo{
+			Id:          v.Id,
+			Title:       v.Snippet.Title,
+			PublishedAt: fields.PublishedAtUnix,
+		})
+	}
+
+	for _, session := range poller.Sessions.History() {
+		if session.EndedAt > digest.From.Unix() && session.EndedAt <= now.Unix() {
+			digest.Sessions = append(digest.Sessions, session)
+			digest.LiveTime += session.DurationSeconds
+		}
+	}
+
+	return digest
+}
+
+func (s *DigestScheduler) run(config *DigestConfig) {
+	now := time.Now()
+
+	for _, poller := range s.pollers() {
+		if len(config.Channels) > 0 && !contains(config.Channels, poller.ChannelId) {
+			continue
+		}
+
+		digest := s.compile(config, poller, now)
+
+		if digest == nil {
+			log.Info().Str("digest", config.Name).Str("channel", poller.ChannelId).Msg("Digest baseline recorded")
+			continue
+		}
+
+		s.events.Emit(onyt.Event{
+			Type:      "digest",
+			ChannelId: poller.ChannelId,
+			Time:      now,
+			Data:      digest,
+		})
+	}
+}
+
+// forget drops the baselines of the channel.
+func (s *DigestScheduler) forget(poller *onyt.Poller) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, config := range s.configs {
+		delete(s.baselines, config.Name+"/"+poller.ChannelId)
+	}
+}
+
+// Watch records the baseline of the channel once it is first fetched, which
+// is dropped when the context is done.
+func (s *DigestScheduler) Watch(ctx context.Context, poller *onyt.Poller) {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	poller.Store.Watch(watchCtx, func(state *onyt.State, _ uint64) {
+		if state.Channel == nil {
+			return
+		}
+
+		for _, config := range s.configs {
+			if len(config.Channels) == 0 || contains(config.Channels, poller.ChannelId) {
+				s.record(config, poller, onyt.ChannelMetrics(state.Channel), time.Now(), false)
+			}
+		}
+
+		cancel()
+	})
+
+	<-ctx.Done()
+
+	s.forget(poller)
+}
+
+// Start schedules the digests until the context is done.
+func (s *DigestScheduler) Start(ctx context.Context) {
+	scheduler := cron.New()
+
+	for _, config := range s.configs {
+		config := config
+
+		scheduler.AddFunc(config.Schedule, func() {
+			s.run(config)
+		})
+	}
+
+	scheduler.Start()
+
+	go func() {
+		<-ctx.Done()
+
+		scheduler.Stop()
+	}()
+}
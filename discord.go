@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+type discordNotifier struct {
+	URL       string `yaml:"url"`
+	Username  string `yaml:"username"`
+	AvatarURL string `yaml:"avatarUrl"`
+}
+
+func newDiscordNotifier(config *NotifierConfig) (Notifier, error) {
+	n := new(discordNotifier)
+
+	if err := config.Decode(n); err != nil {
+		return nil, err
+	}
+
+	if n.URL == "" {
+		return nil, errors.New("missing webhook url")
+	}
+
+	return n, nil
+}
+
+type discordMessage struct {
+	Content   string `json:"content"`
+	Username  string `json:"username,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+func (d *discordNotifier) Notify(ctx context.Context, n *Notification) error {
+	return postJSON(ctx, d.URL, &discordMessage{
+		Content:   defaultMessage(n),
+		Username:  d.Username,
+		AvatarURL: d.AvatarURL,
+	})
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"text/template"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+const (
+	defaultDiscordPresence = `{{if .Live}}LIVE: {{.Stream.Title}}{{else}}{{.Channel}}{{end}}`
+	defaultDiscordTopic    = `{{if .Live}}🔴 LIVE: {{.Stream.Title}} {{.Stream.URL}}{{else}}{{.Channel}} is not live right now{{end}}`
+
+	maxDiscordActivityLength = 128
+	maxDiscordTopicLength    = 1024
+)
+
+// DiscordBot logs in as a Discord bot, reflecting the live status of the
+// channels in its presence and in the topic of a text channel.
+type DiscordBot struct {
+	session  *discordgo.Session
+	channel  string
+	presence *template.Template
+	topic    *template.Template
+	changed  chan struct{}
+
+	lastPresence string
+	lastTopic    string
+}
+
+func NewDiscordBot(token, channel, presence, topic string) (*DiscordBot, error) {
+	session, err := discordgo.New("Bot " + token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	session.Identify.Intents = discordgo.IntentsGuilds
+
+	b := &DiscordBot{
+		session: session,
+		channel: channel,
+		changed: make(chan struct{}, 1),
+	}
+
+	if b.presence, err = parseTemplate("discord-presence", presence); err != nil {
+		return nil, err
+	}
+
+	if b.topic, err = parseTemplate("discord-topic", topic); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func truncateRunes(text string, length int) string {
+	if runes := []rune(text); len(runes) > length {
+		return string(runes[:length-1]) + "…"
+	}
+
+	return text
+}
+
+func renderLine(tmpl *template.Template, data any, length int) (string, error) {
+	var text strings.Builder
+
+	if err := tmpl.Execute(&text, data); err != nil {
+		return "", err
+	}
+
+	return truncateRunes(strings.Join(strings.Fields(text.String()), " "), length), nil
+}
+
+// featured returns the first live channel, or the first channel when none is.
+func featured(pollers []*onyt.Poller) *onyt.Poller {
+	for _, poller := range pollers {
+		if poller.Store.Get().LiveVideo != nil {
+			return poller
+		}
+	}
+
+	return pollers[0]
+}
+
+// notify schedules an update of the status.
+func (b *DiscordBot) notify() {
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Watch updates the status whenever the state of the channel changes, and
+// once the context is done.
+func (b *DiscordBot) Watch(ctx context.Context, poller *onyt.Poller) {
+	poller.Store.Watch(ctx, func(*onyt.State, uint64) {
+		b.notify()
+	})
+
+	b.notify()
+}
+
+func (b *DiscordBot) update(pollers []*onyt.Poller) {
+	if len(pollers) == 0 {
+		return
+	}
+
+	data := chatData(featured(pollers))
+
+	if b.presence != nil {
+		name, err := renderLine(b.presence, data, maxDiscordActivityLength)
+
+		if err != nil {
+			log.Err(err).Msg("Unable to render Discord presence")
+		} else if name != b.lastPresence {
+			activity := &discordgo.Activity{
+				Name: name,
+				Type: discordgo.ActivityTypeWatching,
+			}
+
+			if data.Stream != nil {
+				activity.Type = discordgo.ActivityTypeStreaming
+				activity.URL = "https://www.youtube.com/watch?v=" + data.Stream.Id
+			}
+
+			err := b.session.UpdateStatusComplex(discordgo.UpdateStatusData{
+				Activities: []*discordgo.Activity{activity},
+				Status:     string(discordgo.StatusOnline),
+			})
+
+			if err != nil {
+				log.Err(err).Msg("Unable to update Discord presence")
+			} else {
+				b.lastPresence = name
+			}
+		}
+	}
+
+	if b.topic != nil && b.channel != "" {
+		topic, err := renderLine(b.topic, data, maxDiscordTopicLength)
+
+		if err != nil {
+			log.Err(err).Msg("Unable to render Discord topic")
+		} else if topic != b.lastTopic {
+			// Topic changes are heavily rate limited, hence only sent when the
+			// rendered topic differs.
+			_, err := b.session.ChannelEdit(b.channel, &discordgo.ChannelEdit{
+				Topic: topic,
+			})
+
+			if err != nil {
+				log.Err(err).Str("channel", b.channel).Msg("Unable to update Discord channel topic")
+			} else {
+				b.lastTopic = topic
+			}
+		}
+	}
+}
+
+// Start connects the bot and keeps its status updated until the context is
+// done.
+func (b *DiscordBot) Start(ctx context.Context, pollers func() []*onyt.Poller) error {
+	if len(pollers()) == 0 {
+		return errors.New("no YouTube channel configured")
+	}
+
+	// The presence is reset whenever the bot reconnects to the gateway.
+	ready := make(chan struct{}, 1)
+
+	b.session.AddHandler(func(_ *discordgo.Session, _ *discordgo.Ready) {
+		select {
+		case ready <- struct{}{}:
+		default:
+		}
+	})
+
+	if err := b.session.Open(); err != nil {
+		return err
+	}
+
+	go func() {
+		defer b.session.Close()
+
+		b.notify()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ready:
+				b.lastPresence = ""
+				b.update(pollers())
+
+			case <-b.changed:
+				b.update(pollers())
+			}
+		}
+	}()
+
+	return nil
+}
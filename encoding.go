@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// stateEncodings maps the media types accepted on the state endpoints to
+// their encoding, JSON being the fallback.
+var stateEncodings = map[string]string{
+	"application/msgpack":    "msgpack",
+	"application/x-msgpack":  "msgpack",
+	"application/protobuf":   "protobuf",
+	"application/x-protobuf": "protobuf",
+}
+
+// negotiateEncoding returns the first encoding of the Accept header which is
+// supported, JSON otherwise.
+func negotiateEncoding(r *http.Request) string {
+	for _, value := range strings.Split(r.Header.Get("accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(value))
+
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+
+		if encoding, ok := stateEncodings[mediaType]; ok {
+			return encoding
+		}
+
+		if mediaType == "application/json" || mediaType == "*/*" {
+			break
+		}
+	}
+
+	return "json"
+}
+
+// encodeMsgpack encodes the JSON document of the value, so the fields
+// computed when marshaling to JSON are kept, the integral numbers being
+// encoded as integers.
+func encodeMsgpack(v any) ([]byte, error) {
+	doc, err := toJSONValue(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	enc := msgpack.NewEncoder(&buf)
+	enc.UseCompactInts(true)
+	enc.UseCompactFloats(true)
+
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeState writes the state in the encoding negotiated with the client,
+// protobuf leaving out the freshness, without the trimmed fields. The
+// schemaVersion=2 query parameter maps it to the schema v2 first, protobuf
+// excepted.
+func writeState(w http.ResponseWriter, r *http.Request, poller *onyt.Poller, freshness Freshness, trim map[string]bool) {
+	w.Header().Add("vary", "accept")
+
+	state, version, _ := poller.Store.Snapshot()
+	state = onyt.TrimState(state, trim)
+
+	w.Header().Set("x-state-version", strconv.FormatUint(version, 10))
+
+	var response any = &StateResponse{state, freshness}
+
+	switch r.URL.Query().Get("schemaVersion") {
+	case "", "1":
+	case "2":
+		response = toStateV2(state, freshness)
+
+	default:
+		http.Error(w, "unsupported schema version", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		contentType string
+		body        []byte
+		err         error
+	)
+
+	switch negotiateEncoding(r) {
+	case "msgpack":
+		contentType = "application/msgpack"
+		body, err = encodeMsgpack(response)
+
+	case "protobuf":
+		contentType = "application/protobuf"
+		body, err = proto.Marshal(toProtoState(state, version))
+
+	default:
+		writeJSON(w, response)
+		return
+	}
+
+	if err != nil {
+		log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to encode state")
+		http.Error(w, "unable to encode state", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", contentType)
+	w.Write(body)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+// LoggedEvent is an event of the log, along with its ID.
+type LoggedEvent struct {
+	Id int64 `json:"id"`
+
+	onyt.Event
+}
+
+// EventLog keeps the latest emitted events, in the database when any, so the
+// consumers which were offline can catch up.
+type EventLog struct {
+	database *Database
+	capacity int
+
+	mu     sync.RWMutex
+	lastId int64
+	events []*LoggedEvent
+}
+
+func NewEventLog(events *onyt.EventBus, database *Database, capacity int) (*EventLog, error) {
+	l := &EventLog{
+		database: database,
+		capacity: capacity,
+	}
+
+	if database != nil {
+		var err error
+
+		if l.lastId, err = database.LastEventId(); err != nil {
+			return nil, err
+		}
+	}
+
+	events.Subscribe(l.append)
+
+	return l, nil
+}
+
+func (l *EventLog) append(evt onyt.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.database != nil {
+		id, err := l.database.AppendEvent(evt, l.capacity)
+
+		if err != nil {
+			log.Err(err).Str("channel", evt.ChannelId).Msg("Unable to log event")
+			return
+		}
+
+		l.lastId = id
+
+		return
+	}
+
+	l.lastId++
+
+	if len(l.events) == l.capacity {
+		l.events = l.events[1:]
+	}
+
+	l.events = append(l.events, &LoggedEvent{l.lastId, evt})
+}
+
+// Since returns the events of the channels and types following the ID, up to
+// the limit, the oldest first. Every event is returned when the ID is past
+// the latest one, as the log was reset.
+func (l *EventLog) Since(since int64, limit int, channels, types []string) ([]*LoggedEvent, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if since > l.lastId {
+		since = 0
+	}
+
+	if l.database != nil {
+		return l.database.EventsSince(since, limit, channels, types)
+	}
+
+	result := make([]*LoggedEvent, 0)
+
+	for _, evt := range l.events {
+		if len(result) == limit {
+			break
+		}
+
+		if evt.Id > since && (len(channels) == 0 || contains(channels, evt.ChannelId)) && (len(types) == 0 || contains(types, evt.Type)) {
+			result = append(result, evt)
+		}
+	}
+
+	return result, nil
+}
+
+// serveEventHistory answers the logged events following the since query
+// parameter, filtered by the channel and type ones.
+func (s *Server) serveEventHistory(w http.ResponseWriter, r *http.Request) {
+	s.writeEventHistory(w, r, r.URL.Query()["channel"])
+}
+
+// writeEventHistory answers the logged events of the channels, of every
+// channel when empty.
+func (s *Server) writeEventHistory(w http.ResponseWriter, r *http.Request, channels []string) {
+	query := r.URL.Query()
+
+	since, _ := strconv.ParseInt(query.Get("since"), 10, 64)
+	limit, err := strconv.Atoi(query.Get("limit"))
+
+	if err != nil || limit <= 0 || limit > s.Events.capacity {
+		limit = s.Events.capacity
+	}
+
+	events, err := s.Events.Since(since, limit, channels, query["type"])
+
+	if err != nil {
+		log.Err(err).Msg("Unable to list events")
+		http.Error(w, "unable to list events", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, events)
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+	"github.com/urfave/cli/v2"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// ExportVideo is a video of the exported state, with its key statistics.
+type ExportVideo struct {
+	Id              string `xml:"id"`
+	Title           string `xml:"title"`
+	PublishedAt     string `xml:"publishedAt"`
+	DurationSeconds int64  `xml:"durationSeconds"`
+	ViewCount       uint64 `xml:"viewCount"`
+	LikeCount       uint64 `xml:"likeCount"`
+	CommentCount    uint64 `xml:"commentCount"`
+	Live            bool   `xml:"live"`
+	URL             string `xml:"url"`
+}
+
+var exportVideoHeader = []string{"id", "title", "published_at", "duration_seconds", "view_count", "like_count", "comment_count", "live", "url"}
+
+func (v *ExportVideo) record() []string {
+	return []string{
+		v.Id,
+		v.Title,
+		v.PublishedAt,
+		strconv.FormatInt(v.DurationSeconds, 10),
+		strconv.FormatUint(v.ViewCount, 10),
+		strconv.FormatUint(v.LikeCount, 10),
+		strconv.FormatUint(v.CommentCount, 10),
+		strconv.FormatBool(v.Live),
+		v.URL,
+	}
+}
+
+type ExportState struct {
+	XMLName   xml.Name       `xml:"channel"`
+	ChannelId string         `xml:"id,attr"`
+	Title     string         `xml:"title,attr,omitempty"`
+	Videos    []*ExportVideo `xml:"videos>video"`
+}
+
+// exportVideos returns the live videos followed by the latest uploads, which
+// leave the live ones out.
+func exportVideos(state *onyt.State) []*ExportVideo {
+	live := make(map[string]bool, len(state.LiveVideos))
+
+	for _, v := range state.LiveVideos {
+		live[v.Id] = true
+	}
+
+	all := append(append([]*onyt.Video{}, state.LiveVideos...), state.Videos...)
+
+	videos := make([]*ExportVideo, 0, len(all))
+	seen := make(map[string]bool, len(all))
+
+	for _, v := range all {
+		if seen[v.Id] {
+			continue
+		}
+
+		seen[v.Id] = true
+
+		fields := v.Fields()
+
+		video := &ExportVideo{
+			Id:              v.Id,
+			DurationSeconds: fields.DurationSeconds,
+			Live:            live[v.Id],
+			URL:             fmt.Sprintf("https://www.youtube.com/watch?v=%s", v.Id),
+		}
+
+		if v.Snippet != nil {
+			video.Title = v.Snippet.Title
+			video.PublishedAt = v.Snippet.PublishedAt
+		}
+
+		if v.Statistics != nil {
+			video.ViewCount = v.Statistics.ViewCount
+			video.LikeCount = v.Statistics.LikeCount
+			video.CommentCount = v.Statistics.CommentCount
+		}
+
+		videos = append(videos, video)
+	}
+
+	return videos
+}
+
+func writeCSV(w io.Writer, header []string, records [][]string) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
+
+func serveExportCSV(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	videos := exportVideos(poller.Store.Get())
+	records := make([][]string, 0, len(videos))
+
+	for _, v := range videos {
+		records = append(records, v.record())
+	}
+
+	w.Header().Set("content-type", "text/csv; charset=utf-8")
+	w.Header().Set("content-disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, poller.ChannelId))
+
+	writeCSV(w, exportVideoHeader, records)
+}
+
+func serveExportXML(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	state := poller.Store.Get()
+
+	export := &ExportState{
+		ChannelId: poller.ChannelId,
+		Videos:    exportVideos(state),
+	}
+
+	if state.Channel != nil && state.Channel.Snippet != nil {
+		export.Title = state.Channel.Snippet.Title
+	}
+
+	w.Header().Set("content-type", "application/xml; charset=utf-8")
+
+	io.WriteString(w, xml.Header)
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(export)
+}
+
+// ArchivedUpload is an upload of the archive, as exported.
+type ArchivedUpload struct {
+	VideoId     string `parquet:"name=video_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ChannelId   string `parquet:"name=channel_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Title       string `parquet:"name=title, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Description string `parquet:"name=description, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PublishedAt int64  `parquet:"name=published_at, type=INT64"`
+}
+
+// ArchivedSession is a live session of the archive, as exported.
+type ArchivedSession struct {
+	VideoId        string  `parquet:"name=video_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	ChannelId      string  `parquet:"name=channel_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Title          string  `parquet:"name=title, type=BYTE_ARRAY, convertedtype=UTF8"`
+	StartedAt      int64   `parquet:"name=started_at, type=INT64"`
+	EndedAt        int64   `parquet:"name=ended_at, type=INT64"`
+	PeakViewers    int64   `parquet:"name=peak_viewers, type=INT64"`
+	AverageViewers float64 `parquet:"name=average_viewers, type=DOUBLE"`
+	LikeCount      int64   `parquet:"name=like_count, type=INT64"`
+}
+
+func formatUnix(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(time.RFC3339)
+}
+
+// writeParquet writes the rows as a Snappy compressed Parquet file, the
+// timestamps being Unix seconds.
+func writeParquet[T any](w io.Writer, rows []*T) error {
+	pw, err := writer.NewParquetWriterFromWriter(w, new(T), 1)
+
+	if err != nil {
+		return err
+	}
+
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+	for _, row := range rows {
+		if err := pw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return pw.WriteStop()
+}
+
+func exportArchive(database *Database, table, format string, w io.Writer) error {
+	switch table {
+	case "uploads":
+		uploads, err := database.ArchivedUploads()
+
+		if err != nil {
+			return err
+		}
+
+		if format == "parquet" {
+			return writeParquet(w, uploads)
+		}
+
+		records := make([][]string, 0, len(uploads))
+
+		for _, u := range uploads {
+			records = append(records, []string{u.VideoId, u.ChannelId, u.Title, u.Description, formatUnix(u.PublishedAt)})
+		}
+
+		return writeCSV(w, []string{"video_id", "channel_id", "title", "description", "published_at"}, records)
+
+	case "sessions":
+		sessions, err := database.ArchivedSessions()
+
+		if err != nil {
+			return err
+		}
+
+		if format == "parquet" {
+			return writeParquet(w, sessions)
+		}
+
+		records := make([][]string, 0, len(sessions))
+
+		for _, s := range sessions {
+			records = append(records, []string{s.VideoId, s.ChannelId, s.Title, formatUnix(s.StartedAt), formatUnix(s.EndedAt), strconv.FormatInt(s.PeakViewers, 10), strconv.FormatFloat(s.AverageViewers, 'f', 2, 64), strconv.FormatInt(s.LikeCount, 10)})
+		}
+
+		return writeCSV(w, []string{"video_id", "channel_id", "title", "started_at", "ended_at", "peak_viewers", "average_viewers", "like_count"}, records)
+	}
+
+	return fmt.Errorf("unknown table: %s", table)
+}
+
+var exportCommand = &cli.Command{
+	Name:  "export",
+	Usage: "Write the uploads or the live sessions archived in the database to CSV or Parquet",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "db",
+			EnvVars:  []string{"DATABASE_PATH"},
+			Usage:    "The SQLite database to export",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "table",
+			Usage: "The archive to export (uploads, sessions)",
+			Value: "uploads",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "The export format (csv, parquet)",
+			Value: "csv",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "The file to write, the standard output when -",
+			Value:   "-",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		format := ctx.String("format")
+
+		if format != "csv" && format != "parquet" {
+			return fmt.Errorf("unknown format: %s", format)
+		}
+
+		database, err := OpenDatabase(ctx.String("db"))
+
+		if err != nil {
+			return err
+		}
+
+		defer database.Close()
+
+		if ctx.String("output") == "-" {
+			return exportArchive(database, ctx.String("table"), format, os.Stdout)
+		}
+
+		file, err := os.Create(ctx.String("output"))
+
+		if err != nil {
+			return err
+		}
+
+		if err := exportArchive(database, ctx.String("table"), format, file); err != nil {
+			return errors.Join(err, file.Close())
+		}
+
+		return file.Close()
+	},
+}
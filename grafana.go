@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// grafanaMetrics are the metrics of the history, per channel.
+var grafanaMetrics = []string{"subscribers", "views", "videos", "live_viewers", "live_likes"}
+
+type GrafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type GrafanaTarget struct {
+	Target string `json:"target"`
+	RefId  string `json:"refId"`
+	Type   string `json:"type"`
+}
+
+type GrafanaQuery struct {
+	Range         GrafanaRange     `json:"range"`
+	IntervalMs    int64            `json:"intervalMs"`
+	MaxDataPoints int64            `json:"maxDataPoints"`
+	Targets       []*GrafanaTarget `json:"targets"`
+}
+
+type GrafanaSeries struct {
+	Target     string      `json:"target"`
+	Datapoints [][2]uint64 `json:"datapoints"`
+}
+
+type GrafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+type GrafanaTable struct {
+	Type    string           `json:"type"`
+	Columns []*GrafanaColumn `json:"columns"`
+	Rows    [][2]uint64      `json:"rows"`
+}
+
+type GrafanaAnnotation struct {
+	Annotation json.RawMessage `json:"annotation"`
+	Time       int64           `json:"time"`
+	TimeEnd    int64           `json:"timeEnd"`
+	Title      string          `json:"title"`
+	Text       string          `json:"text"`
+	Tags       []string        `json:"tags"`
+}
+
+// parseGrafanaTarget splits a target into its channel and metric, such as
+// UC…/subscribers.
+func parseGrafanaTarget(target string) (string, string, bool) {
+	channelId, metric, ok := strings.Cut(target, "/")
+
+	return channelId, metric, ok && validChannel(channelId) && contains(grafanaMetrics, metric)
+}
+
+// parseGrafanaTime parses a time given in milliseconds, as interpolated by
+// Grafana, or in RFC 3339.
+func parseGrafanaTime(value string, fallback time.Time) time.Time {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms)
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+
+	return fallback
+}
+
+// grafanaStep returns the step of the points of a query, the interval
+// widened so the points don't exceed the maximum.
+func grafanaStep(query *GrafanaQuery) time.Duration {
+	step := time.Duration(query.IntervalMs) * time.Millisecond
+
+	if query.MaxDataPoints > 0 {
+		if minimum := query.Range.To.Sub(query.Range.From) / time.Duration(query.MaxDataPoints); minimum > step {
+			step = minimum
+		}
+	}
+
+	return step
+}
+
+func (s *Server) grafanaHistory(target string, query *GrafanaQuery) ([][2]uint64, error) {
+	channelId, metric, _ := parseGrafanaTarget(target)
+
+	points, err := s.Database.History(channelId, metric, query.Range.From, query.Range.To, grafanaStep(query))
+
+	if err != nil {
+		return nil, err
+	}
+
+	datapoints := make([][2]uint64, 0, len(points))
+
+	for _, point := range points {
+		datapoints = append(datapoints, [2]uint64{point.Value, uint64(point.Time.UnixMilli())})
+	}
+
+	return datapoints, nil
+}
+
+// serveGrafana serves the endpoints of the Grafana SimpleJSON datasource
+// over the stored history, the query endpoint also answering GET requests
+// with rows for the Infinity datasource.
+func (s *Server) serveGrafana(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimPrefix(r.URL.Path, "/grafana") {
+	case "", "/":
+		io.WriteString(w, "OK")
+
+	case "/search":
+		var body struct {
+			Target string `json:"target"`
+		}
+
+		json.NewDecoder(r.Body).Decode(&body)
+
+		targets := make([]string, 0)
+
+		for _, poller := range s.Pollers() {
+			for _, metric := range grafanaMetrics {
+				if target := poller.ChannelId + "/" + metric; strings.Contains(target, body.Target) {
+					targets = append(targets, target)
+				}
+			}
+		}
+
+		writeJSON(w, targets)
+
+	case "/query":
+		if r.Method == http.MethodGet {
+			s.serveGrafanaRows(w, r)
+			return
+		}
+
+		query := new(GrafanaQuery)
+
+		if err := json.NewDecoder(r.Body).Decode(query); err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+
+		results := make([]any, 0, len(query.Targets))
+
+		for _, target := range query.Targets {
+			if _, _, ok := parseGrafanaTarget(target.Target); !ok {
+				http.Error(w, "invalid target: "+target.Target, http.StatusBadRequest)
+				return
+			}
+
+			datapoints, err := s.grafanaHistory(target.Target, query)
+
+			if err != nil {
+				log.Err(err).Str("target", target.Target).Msg("Unable to query history")
+				http.Error(w, "unable to query history", http.StatusInternalServerError)
+				return
+			}
+
+			if target.Type == "table" {
+				// The rows of a table start with the time.
+				for i, datapoint := range datapoints {
+					datapoints[i] = [2]uint64{datapoint[1], datapoint[0]}
+				}
+
+				results = append(results, &GrafanaTable{
+					Type: "table",
+					Columns: []*GrafanaColumn{
+						{Text: "Time", Type: "time"},
+						{Text: target.Target, Type: "number"},
+					},
+					Rows: datapoints,
+				})
+
+				continue
+			}
+
+			results = append(results, &GrafanaSeries{
+				Target:     target.Target,
+				Datapoints: datapoints,
+			})
+		}
+
+		writeJSON(w, results)
+
+	case "/annotations":
+		var body struct {
+			Range      GrafanaRange    `json:"range"`
+			Annotation json.RawMessage `json:"annotation"`
+		}
+
+		var annotation struct {
+			Query string `json:"query"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid body", http.StatusBadRequest)
+			return
+		}
+
+		// The annotation is echoed back as sent.
+		json.Unmarshal(body.Annotation, &annotation)
+
+		channelIds := []string{annotation.Query}
+
+		if annotation.Query == "" {
+			channelIds = channelIds[:0]
+
+			for _, poller := range s.Pollers() {
+				channelIds = append(channelIds, poller.ChannelId)
+			}
+		}
+
+		annotations := make([]*GrafanaAnnotation, 0)
+
+		for _, channelId := range channelIds {
+			sessions, err := s.Database.SessionsBetween(channelId, body.Range.From, body.Range.To)
+
+			if err != nil {
+				log.Err(err).Str("channel", channelId).Msg("Unable to query sessions")
+				http.Error(w, "unable to query sessions", http.StatusInternalServerError)
+				return
+			}
+
+			for _, session := range sessions {
+				annotations = append(annotations, &GrafanaAnnotation{
+					Annotation: body.Annotation,
+					Time:       session.StartedAt * 1000,
+					TimeEnd:    session.EndedAt * 1000,
+					Title:      session.Title,
+					Text:       "Peak of " + strconv.FormatInt(session.PeakViewers, 10) + " viewers",
+					Tags:       []string{"live", session.ChannelId},
+				})
+			}
+		}
+
+		writeJSON(w, annotations)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveGrafanaRows answers the targets of the query parameters as rows, the
+// range and interval being given by from, to and interval.
+func (s *Server) serveGrafanaRows(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	values := r.URL.Query()
+
+	query := &GrafanaQuery{
+		Range: GrafanaRange{
+			From: parseGrafanaTime(values.Get("from"), now.Add(-24*time.Hour)),
+			To:   parseGrafanaTime(values.Get("to"), now),
+		},
+	}
+
+	query.IntervalMs, _ = strconv.ParseInt(values.Get("interval"), 10, 64)
+	query.MaxDataPoints, _ = strconv.ParseInt(values.Get("maxDataPoints"), 10, 64)
+
+	type row struct {
+		Target string `json:"target"`
+		Time   int64  `json:"time"`
+		Value  uint64 `json:"value"`
+	}
+
+	rows := make([]*row, 0)
+
+	for _, target := range values["target"] {
+		if _, _, ok := parseGrafanaTarget(target); !ok {
+			http.Error(w, "invalid target: "+target, http.StatusBadRequest)
+			return
+		}
+
+		datapoints, err := s.grafanaHistory(target, query)
+
+		if err != nil {
+			log.Err(err).Str("target", target).Msg("Unable to query history")
+			http.Error(w, "unable to query history", http.StatusInternalServerError)
+			return
+		}
+
+		for _, datapoint := range datapoints {
+			rows = append(rows, &row{target, int64(datapoint[1]), datapoint[0]})
+		}
+	}
+
+	writeJSON(w, rows)
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/handler"
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+func videoField(typ graphql.Output, resolve func(v *onyt.Video, fields onyt.VideoFields) any) *graphql.Field {
+	return &graphql.Field{
+		Type: typ,
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			v := p.Source.(*onyt.Video)
+
+			return resolve(v, v.Fields()), nil
+		},
+	}
+}
+
+func (s *Server) graphqlSchema() (graphql.Schema, error) {
+	chapterType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Chapter",
+		Fields: graphql.Fields{
+			"title":        &graphql.Field{Type: graphql.String},
+			"startSeconds": &graphql.Field{Type: graphql.Int},
+			"endSeconds":   &graphql.Field{Type: graphql.Int},
+		},
+	})
+
+	categoryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Category",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.String},
+			"name": &graphql.Field{Type: graphql.String},
+			"game": &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	segmentType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Segment",
+		Fields: graphql.Fields{
+			"category":     &graphql.Field{Type: graphql.String},
+			"actionType":   &graphql.Field{Type: graphql.String},
+			"startSeconds": &graphql.Field{Type: graphql.Float},
+			"endSeconds":   &graphql.Field{Type: graphql.Float},
+			"uuid":         &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	endScreenElementType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "EndScreenElement",
+		Fields: graphql.Fields{
+			"type":         &graphql.Field{Type: graphql.String},
+			"title":        &graphql.Field{Type: graphql.String},
+			"id":           &graphql.Field{Type: graphql.String},
+			"url":          &graphql.Field{Type: graphql.String},
+			"startSeconds": &graphql.Field{Type: graphql.Float},
+			"endSeconds":   &graphql.Field{Type: graphql.Float},
+		},
+	})
+
+	extrasType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "VideoExtras",
+		Fields: graphql.Fields{
+			"watchingNow": &graphql.Field{
+				Type: graphql.Float,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					extras := p.Source.(*onyt.VideoExtras)
+
+					if extras.WatchingNow == nil {
+						return nil, nil
+					}
+
+					return float64(*extras.WatchingNow), nil
+				},
+			},
+			"membersOnly": &graphql.Field{Type: graphql.Boolean},
+			"endScreen":   &graphql.Field{Type: graphql.NewList(endScreenElementType)},
+		},
+	})
+
+	videoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Video",
+		Fields: graphql.Fields{
+			"id": videoField(graphql.NewNonNull(graphql.String), func(v *onyt.Video, _ onyt.VideoFields) any {
+				return v.Id
+			}),
+			"url": videoField(graphql.NewNonNull(graphql.String), func(v *onyt.Video, _ onyt.VideoFields) any {
+				return fmt.Sprintf("https://www.youtube.com/watch?v=%s", v.Id)
+			}),
+			"title": videoField(graphql.String, func(v *onyt.Video, _ onyt.VideoFields) any {
+				if v.Snippet == nil {
+					return nil
+				}
+
+				return v.Snippet.Title
+			}),
+			"description": videoField(graphql.String, func(v *onyt.Video, _ onyt.VideoFields) any {
+				if v.Snippet == nil {
+					return nil
+				}
+
+				return v.Snippet.Description
+			}),
+			"publishedAt": videoField(graphql.Int, func(v *onyt.Video, fields onyt.VideoFields) any {
+				return fields.PublishedAtUnix
+			}),
+			"durationSeconds": videoField(graphql.Int, func(v *onyt.Video, fields onyt.VideoFields) any {
+				return fields.DurationSeconds
+			}),
+			"thumbnailUrl": videoField(graphql.String, func(v *onyt.Video, fields onyt.VideoFields) any {
+				return fields.ThumbnailURL
+			}),
+			"engagementRatio": videoField(graphql.Float, func(v *onyt.Video, fields onyt.VideoFields) any {
+				return fields.EngagementRatio
+			}),
+			"uptimeSeconds": videoField(graphql.Int, func(v *onyt.Video, fields onyt.VideoFields) any {
+				return fields.UptimeSeconds
+			}),
+			"chapters": videoField(graphql.NewList(chapterType), func(v *onyt.Video, fields onyt.VideoFields) any {
+				return fields.Chapters
+			}),
+			"category": videoField(categoryType, func(v *onyt.Video, fields onyt.VideoFields) any {
+				return fields.Category
+			}),
+			"topics": videoField(graphql.NewList(graphql.String), func(v *onyt.Video, fields onyt.VideoFields) any {
+				return fields.Topics
+			}),
+			"segments": videoField(graphql.NewList(segmentType), func(v *onyt.Video, fields onyt.VideoFields) any {
+				return fields.Segments
+			}),
+			"extras": videoField(extrasType, func(v *onyt.Video, fields onyt.VideoFields) any {
+				return fields.Extras
+			}),
+			"viewCount": videoField(graphql.Float, func(v *onyt.Video, _ onyt.VideoFields) any {
+				if v.Statistics == nil {
+					return nil
+				}
+
+				return float64(v.Statistics.ViewCount)
+			}),
+			"likeCount": videoField(graphql.Float, func(v *onyt.Video, _ onyt.VideoFields) any {
+				if v.Statistics == nil {
+					return nil
+				}
+
+				return float64(v.Statistics.LikeCount)
+			}),
+			"commentCount": videoField(graphql.Float, func(v *onyt.Video, _ onyt.VideoFields) any {
+				if v.Statistics == nil {
+					return nil
+				}
+
+				return float64(v.Statistics.CommentCount)
+			}),
+			"estimatedDislikeCount": videoField(graphql.Float, func(v *onyt.Video, fields onyt.VideoFields) any {
+				if fields.Dislikes == nil {
+					return nil
+				}
+
+				return float64(fields.Dislikes.Count)
+			}),
+			"concurrentViewers": videoField(graphql.Float, func(v *onyt.Video, _ onyt.VideoFields) any {
+				if v.LiveStreamingDetails == nil {
+					return nil
+				}
+
+				return float64(v.LiveStreamingDetails.ConcurrentViewers)
+			}),
+		},
+	})
+
+	sessionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Session",
+		Fields: graphql.Fields{
+			"videoId":         &graphql.Field{Type: graphql.String},
+			"title":           &graphql.Field{Type: graphql.String},
+			"startedAt":       &graphql.Field{Type: graphql.Int},
+			"endedAt":         &graphql.Field{Type: graphql.Int},
+			"durationSeconds": &graphql.Field{Type: graphql.Int},
+			"peakViewers":     &graphql.Field{Type: graphql.Float},
+			"averageViewers":  &graphql.Field{Type: graphql.Float},
+			"likeCount":       &graphql.Field{Type: graphql.Float},
+		},
+	})
+
+	viewerSampleType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ViewerSample",
+		Fields: graphql.Fields{
+			"timestamp": &graphql.Field{Type: graphql.Int},
+			"viewers":   &graphql.Field{Type: graphql.Float},
+		},
+	})
+
+	tombstoneType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RemovedVideo",
+		Fields: graphql.Fields{
+			"videoId":     &graphql.Field{Type: graphql.String},
+			"title":       &graphql.Field{Type: graphql.String},
+			"publishedAt": &graphql.Field{Type: graphql.String},
+			"removedAt":   &graphql.Field{Type: graphql.Int},
+		},
+	})
+
+	channelField := func(typ graphql.Output, resolve func(p *onyt.Poller, state *onyt.State, args map[string]any) any) *graphql.Field {
+		return &graphql.Field{
+			Type: typ,
+			Resolve: func(params graphql.ResolveParams) (any, error) {
+				p := params.Source.(*onyt.Poller)
+
+				return resolve(p, p.Store.Get(), params.Args), nil
+			},
+		}
+	}
+
+	channelType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Channel",
+		Fields: graphql.Fields{
+			"id": channelField(graphql.NewNonNull(graphql.String), func(p *onyt.Poller, _ *onyt.State, _ map[string]any) any {
+				return p.ChannelId
+			}),
+			"title": channelField(graphql.String, func(_ *onyt.Poller, state *onyt.State, _ map[string]any) any {
+				if state.Channel == nil || state.Channel.Snippet == nil {
+					return nil
+				}
+
+				return state.Channel.Snippet.Title
+			}),
+			"description": channelField(graphql.String, func(_ *onyt.Poller, state *onyt.State, _ map[string]any) any {
+				if state.Channel == nil || state.Channel.Snippet == nil {
+					return nil
+				}
+
+				return state.Channel.Snippet.Description
+			}),
+			"thumbnailUrl": channelField(graphql.String, func(_ *onyt.Poller, state *onyt.State, _ map[string]any) any {
+				if state.Channel == nil || state.Channel.Snippet == nil {
+					return nil
+				}
+
+				return onyt.BestThumbnail(state.Channel.Snippet.Thumbnails)
+			}),
+			"subscriberCount": channelField(graphql.Float, func(_ *onyt.Poller, state *onyt.State, _ map[string]any) any {
+				if state.Channel == nil || state.Channel.Statistics == nil {
+					return nil
+				}
+
+				return float64(state.Channel.Statistics.SubscriberCount)
+			}),
+			"viewCount": channelField(graphql.Float, func(_ *onyt.Poller, state *onyt.State, _ map[string]any) any {
+				if state.Channel == nil || state.Channel.Statistics == nil {
+					return nil
+				}
+
+				return float64(state.Channel.Statistics.ViewCount)
+			}),
+			"videoCount": channelField(graphql.Float, func(_ *onyt.Poller, state *onyt.State, _ map[string]any) any {
+				if state.Channel == nil || state.Channel.Statistics == nil {
+					return nil
+				}
+
+				return float64(state.Channel.Statistics.VideoCount)
+			}),
+			"live": channelField(graphql.NewNonNull(graphql.Boolean), func(_ *onyt.Poller, state *onyt.State, _ map[string]any) any {
+				return state.LiveVideo != nil
+			}),
+			"liveVideo": channelField(videoType, func(_ *onyt.Poller, state *onyt.State, _ map[string]any) any {
+				if state.LiveVideo == nil {
+					return nil
+				}
+
+				return state.LiveVideo
+			}),
+			"liveVideos": channelField(graphql.NewList(videoType), func(_ *onyt.Poller, state *onyt.State, _ map[string]any) any {
+				return state.LiveVideos
+			}),
+			"upcomingVideos": channelField(graphql.NewList(videoType), func(_ *onyt.Poller, state *onyt.State, _ map[string]any) any {
+				return state.UpcomingVideos
+			}),
+			"sessions": channelField(graphql.NewList(sessionType), func(p *onyt.Poller, _ *onyt.State, _ map[string]any) any {
+				return p.Sessions.History()
+			}),
+			"viewerHistory": channelField(graphql.NewList(viewerSampleType), func(p *onyt.Poller, _ *onyt.State, _ map[string]any) any {
+				_, samples := p.Viewers.Samples()
+
+				return samples
+			}),
+			"removedVideos": channelField(graphql.NewList(tombstoneType), func(p *onyt.Poller, _ *onyt.State, _ map[string]any) any {
+				return p.Tombstones.List()
+			}),
+		},
+	})
+
+	channelType.AddFieldConfig("videos", &graphql.Field{
+		Type: graphql.NewList(videoType),
+		Args: graphql.FieldConfigArgument{
+			"limit": &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
+		},
+		Resolve: func(params graphql.ResolveParams) (any, error) {
+			videos := params.Source.(*onyt.Poller).Store.Get().Videos
+
+			if limit, ok := params.Args["limit"].(int); ok && limit >= 0 && limit < len(videos) {
+				videos = videos[:limit]
+			}
+
+			return videos, nil
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"channels": &graphql.Field{
+				Type: graphql.NewList(channelType),
+				Resolve: func(params graphql.ResolveParams) (any, error) {
+					return s.Pollers(), nil
+				},
+			},
+			"channel": &graphql.Field{
+				Type: channelType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
+				},
+				Resolve: func(params graphql.ResolveParams) (any, error) {
+					if id, ok := params.Args["id"].(string); ok {
+						if p, ok := s.Poller(id); ok {
+							return p, nil
+						}
+
+						return nil, nil
+					}
+
+					if p, ok := s.primary(); ok {
+						return p, nil
+					}
+
+					return nil, nil
+				},
+			},
+			"live": &graphql.Field{
+				Type: graphql.NewList(channelType),
+				Resolve: func(params graphql.ResolveParams) (any, error) {
+					live := make([]*onyt.Poller, 0)
+
+					for _, p := range s.Pollers() {
+						if p.Store.Get().LiveVideo != nil {
+							live = append(live, p)
+						}
+					}
+
+					return live, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query: queryType,
+	})
+}
+
+func (s *Server) graphqlHandler() (*handler.Handler, error) {
+	schema, err := s.graphqlSchema()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.New(&handler.Config{
+		Schema:   &schema,
+		Pretty:   true,
+		GraphiQL: true,
+	}), nil
+}
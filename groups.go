@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+// GroupSummary is a group of channels, live when any of them is.
+type GroupSummary struct {
+	Name     string `json:"name"`
+	Live     bool   `json:"live"`
+	Channels int    `json:"channels"`
+}
+
+// GroupStatus aggregates the channels of a group, along with the live
+// streams of those live.
+type GroupStatus struct {
+	Name         string            `json:"name"`
+	Live         bool              `json:"live"`
+	LiveChannels []*LiveStatus     `json:"liveChannels"`
+	Channels     []*ChannelSummary `json:"channels"`
+}
+
+// groupPollers returns the pollers of the monitored channels of the group,
+// in order.
+func (s *Server) groupPollers(name string) ([]*onyt.Poller, bool) {
+	channelIds, ok := s.Groups[name]
+
+	if !ok {
+		return nil, false
+	}
+
+	pollers := make([]*onyt.Poller, 0, len(channelIds))
+
+	for _, p := range s.Pollers() {
+		if contains(channelIds, p.ChannelId) {
+			pollers = append(pollers, p)
+		}
+	}
+
+	return pollers, true
+}
+
+func (s *Server) serveGroups(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(s.Groups))
+
+	for name := range s.Groups {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	groups := make([]*GroupSummary, 0, len(names))
+
+	for _, name := range names {
+		pollers, _ := s.groupPollers(name)
+
+		group := &GroupSummary{
+			Name:     name,
+			Channels: len(pollers),
+		}
+
+		for _, p := range pollers {
+			if s.summarize(p).Live {
+				group.Live = true
+			}
+		}
+
+		groups = append(groups, group)
+	}
+
+	writeJSON(w, groups)
+}
+
+// serveGroup answers the aggregate status of a group, or the history of the
+// events of its channels under /events.
+func (s *Server) serveGroup(w http.ResponseWriter, r *http.Request) {
+	name, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/groups/"), "/")
+
+	pollers, ok := s.groupPollers(name)
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch rest {
+	case "":
+		trim := s.trimmed(r)
+
+		status := &GroupStatus{
+			Name:         name,
+			LiveChannels: make([]*LiveStatus, 0),
+			Channels:     make([]*ChannelSummary, 0, len(pollers)),
+		}
+
+		for _, p := range pollers {
+			if live := s.liveStatus(p, trim); live.Live {
+				status.LiveChannels = append(status.LiveChannels, live)
+			}
+
+			status.Channels = append(status.Channels, s.summarize(p))
+		}
+
+		status.Live = len(status.LiveChannels) > 0
+
+		writeJSON(w, status)
+
+	case "events":
+		if s.Events == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		s.writeEventHistory(w, r, s.Groups[name])
+
+	default:
+		http.NotFound(w, r)
+	}
+}
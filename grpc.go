@@ -0,0 +1,283 @@
+package main
+
+//go:generate protoc --go_out=. --go_opt=module=github.com/seldszar/onyt --go-grpc_out=. --go-grpc_opt=module=github.com/seldszar/onyt -I proto onyt/v1/onyt.proto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+	"github.com/seldszar/onyt/pkg/onytpb"
+	"google.golang.org/api/youtube/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type grpcServer struct {
+	onytpb.UnimplementedOnytServiceServer
+
+	server *Server
+	events *onyt.EventBus
+}
+
+func toProtoChannel(c *youtube.Channel) *onytpb.Channel {
+	if c == nil {
+		return nil
+	}
+
+	result := &onytpb.Channel{
+		Id: c.Id,
+	}
+
+	if c.Snippet != nil {
+		result.Title = c.Snippet.Title
+		result.Description = c.Snippet.Description
+		result.ThumbnailUrl = onyt.BestThumbnail(c.Snippet.Thumbnails)
+	}
+
+	if c.Statistics != nil {
+		result.SubscriberCount = c.Statistics.SubscriberCount
+		result.ViewCount = c.Statistics.ViewCount
+		result.VideoCount = c.Statistics.VideoCount
+	}
+
+	return result
+}
+
+func toProtoVideo(v *onyt.Video) *onytpb.Video {
+	if v == nil {
+		return nil
+	}
+
+	fields := v.Fields()
+
+	result := &onytpb.Video{
+		Id:              v.Id,
+		Url:             fmt.Sprintf("https://www.youtube.com/watch?v=%s", v.Id),
+		ThumbnailUrl:    fields.ThumbnailURL,
+		PublishedAt:     fields.PublishedAtUnix,
+		DurationSeconds: fields.DurationSeconds,
+		UptimeSeconds:   fields.UptimeSeconds,
+		EngagementRatio: fields.EngagementRatio,
+	}
+
+	if v.Snippet != nil {
+		result.Title = v.Snippet.Title
+		result.Description = v.Snippet.Description
+		result.LiveBroadcastContent = v.Snippet.LiveBroadcastContent
+	}
+
+	if v.Statistics != nil {
+		result.ViewCount = v.Statistics.ViewCount
+		result.LikeCount = v.Statistics.LikeCount
+		result.CommentCount = v.Statistics.CommentCount
+	}
+
+	if d := v.LiveStreamingDetails; d != nil {
+		result.ConcurrentViewers = d.ConcurrentViewers
+		result.ScheduledStartTime = parseUnix(d.ScheduledStartTime)
+	}
+
+	return result
+}
+
+func parseUnix(value string) int64 {
+	t, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return 0
+	}
+
+	return t.Unix()
+}
+
+func toProtoVideos(videos []*onyt.Video) []*onytpb.Video {
+	result := make([]*onytpb.Video, 0, len(videos))
+
+	for _, v := range videos {
+		result = append(result, toProtoVideo(v))
+	}
+
+	return result
+}
+
+func toProtoState(state *onyt.State, version uint64) *onytpb.State {
+	return &onytpb.State{
+		Channel:        toProtoChannel(state.Channel),
+		LiveVideo:      toProtoVideo(state.LiveVideo),
+		LiveVideos:     toProtoVideos(state.LiveVideos),
+		Videos:         toProtoVideos(state.Videos),
+		UpcomingVideos: toProtoVideos(state.UpcomingVideos),
+		Version:        version,
+	}
+}
+
+func toProtoEvent(evt onyt.Event) (*onytpb.Event, error) {
+	data, err := json.Marshal(evt.Data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	pv, err := structpb.NewValue(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &onytpb.Event{
+		Type:      evt.Type,
+		ChannelId: evt.ChannelId,
+		Time:      evt.Time.Unix(),
+		Data:      pv,
+	}, nil
+}
+
+func (g *grpcServer) poller(channelId string) (*onyt.Poller, error) {
+	if channelId == "" {
+		if p, ok := g.server.primary(); ok {
+			return p, nil
+		}
+	}
+
+	if p, ok := g.server.Poller(channelId); ok {
+		return p, nil
+	}
+
+	return nil, status.Errorf(codes.NotFound, "channel %q is not monitored", channelId)
+}
+
+func (g *grpcServer) GetState(ctx context.Context, req *onytpb.GetStateRequest) (*onytpb.State, error) {
+	p, err := g.poller(req.ChannelId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	state, version, _ := p.Store.Snapshot()
+
+	return toProtoState(state, version), nil
+}
+
+func (g *grpcServer) GetChannel(ctx context.Context, req *onytpb.GetChannelRequest) (*onytpb.Channel, error) {
+	p, err := g.poller(req.ChannelId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	channel := toProtoChannel(p.Store.Get().Channel)
+
+	if channel == nil {
+		return nil, status.Errorf(codes.Unavailable, "channel %q has not been fetched yet", p.ChannelId)
+	}
+
+	return channel, nil
+}
+
+func (g *grpcServer) ListChannels(ctx context.Context, req *onytpb.ListChannelsRequest) (*onytpb.ListChannelsResponse, error) {
+	pollers := g.server.Pollers()
+
+	resp := &onytpb.ListChannelsResponse{
+		Channels: make([]*onytpb.Channel, 0, len(pollers)),
+	}
+
+	for _, p := range pollers {
+		if channel := toProtoChannel(p.Store.Get().Channel); channel != nil {
+			resp.Channels = append(resp.Channels, channel)
+		}
+	}
+
+	return resp, nil
+}
+
+func (g *grpcServer) WatchState(req *onytpb.WatchStateRequest, stream onytpb.OnytService_WatchStateServer) error {
+	p, err := g.poller(req.ChannelId)
+
+	if err != nil {
+		return err
+	}
+
+	for {
+		state, version, changed := p.Store.Snapshot()
+
+		if err := stream.Send(toProtoState(state, version)); err != nil {
+			return err
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-changed:
+		}
+	}
+}
+
+func (g *grpcServer) WatchEvents(req *onytpb.WatchEventsRequest, stream onytpb.OnytService_WatchEventsServer) error {
+	types := make(map[string]bool, len(req.Types))
+
+	for _, t := range req.Types {
+		types[t] = true
+	}
+
+	events := make(chan onyt.Event, 16)
+
+	unsubscribe := g.events.Subscribe(func(evt onyt.Event) {
+		if len(types) > 0 && !types[evt.Type] {
+			return
+		}
+
+		select {
+		case events <- evt:
+		default:
+		}
+	})
+
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+
+		case evt := <-events:
+			pe, err := toProtoEvent(evt)
+
+			if err != nil {
+				return err
+			}
+
+			if err := stream.Send(pe); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func startGRPCServer(port int, server *Server, events *onyt.EventBus) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+
+	if err != nil {
+		return err
+	}
+
+	s := grpc.NewServer()
+
+	onytpb.RegisterOnytServiceServer(s, &grpcServer{
+		server: server,
+		events: events,
+	})
+
+	return s.Serve(lis)
+}
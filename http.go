@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// httpNotifier issues arbitrary HTTP requests, covering the services without
+// a dedicated notifier.
+type httpNotifier struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+
+	url     *template.Template
+	headers map[string]*template.Template
+	body    *template.Template
+}
+
+func newHTTPNotifier(config *NotifierConfig) (Notifier, error) {
+	n := &httpNotifier{
+		Method: http.MethodPost,
+	}
+
+	if err := config.Decode(n); err != nil {
+		return nil, err
+	}
+
+	if n.URL == "" {
+		return nil, errors.New("missing url")
+	}
+
+	var err error
+
+	if n.url, err = parseTemplate("url", n.URL); err != nil {
+		return nil, err
+	}
+
+	if n.body, err = parseTemplate("body", n.Body); err != nil {
+		return nil, err
+	}
+
+	n.headers = make(map[string]*template.Template, len(n.Headers))
+
+	for name, value := range n.Headers {
+		if n.headers[name], err = parseTemplate(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	n.Method = strings.ToUpper(n.Method)
+
+	return n, nil
+}
+
+func renderTemplate(tmpl *template.Template, data any) (string, error) {
+	if tmpl == nil {
+		return "", nil
+	}
+
+	var text strings.Builder
+
+	if err := tmpl.Execute(&text, data); err != nil {
+		return "", err
+	}
+
+	return text.String(), nil
+}
+
+func (h *httpNotifier) Notify(ctx context.Context, n *Notification) error {
+	url, err := renderTemplate(h.url, n)
+
+	if err != nil {
+		return err
+	}
+
+	var (
+		body        io.Reader
+		contentType = "text/plain; charset=utf-8"
+	)
+
+	if h.body != nil {
+		text, err := renderTemplate(h.body, n)
+
+		if err != nil {
+			return err
+		}
+
+		body = strings.NewReader(text)
+	} else if h.Method != http.MethodGet && h.Method != http.MethodHead {
+		if n.Message != "" {
+			body = strings.NewReader(n.Message)
+		} else {
+			data, err := json.Marshal(n.Event)
+
+			if err != nil {
+				return err
+			}
+
+			body = strings.NewReader(string(data))
+			contentType = "application/json"
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, h.Method, url, body)
+
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("content-type", contentType)
+	}
+
+	for name, tmpl := range h.headers {
+		value, err := renderTemplate(tmpl, n)
+
+		if err != nil {
+			return err
+		}
+
+		req.Header.Set(name, value)
+	}
+
+	resp, err := notifierClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type PatchOperation struct {
+	Op    string
+	Path  string
+	Value any
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func (o *PatchOperation) MarshalJSON() ([]byte, error) {
+	if o.Op == "remove" {
+		return json.Marshal(map[string]any{"op": o.Op, "path": o.Path})
+	}
+
+	return json.Marshal(map[string]any{"op": o.Op, "path": o.Path, "value": o.Value})
+}
+
+func toJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result any
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func diffJSON(path string, from, to any) []*PatchOperation {
+	switch a := from.(type) {
+	case map[string]any:
+		if b, ok := to.(map[string]any); ok {
+			return diffObjects(path, a, b)
+		}
+
+	case []any:
+		if b, ok := to.([]any); ok {
+			return diffArrays(path, a, b)
+		}
+	}
+
+	if reflect.DeepEqual(from, to) {
+		return nil
+	}
+
+	return []*PatchOperation{{Op: "replace", Path: path, Value: to}}
+}
+
+func diffObjects(path string, from, to map[string]any) []*PatchOperation {
+	ops := make([]*PatchOperation, 0)
+
+	for key, a := range from {
+		p := path + "/" + pointerEscaper.Replace(key)
+
+		if b, ok := to[key]; ok {
+			ops = append(ops, diffJSON(p, a, b)...)
+		} else {
+			ops = append(ops, &PatchOperation{Op: "remove", Path: p})
+		}
+	}
+
+	for key, b := range to {
+		if _, ok := from[key]; !ok {
+			ops = append(ops, &PatchOperation{Op: "add", Path: path + "/" + pointerEscaper.Replace(key), Value: b})
+		}
+	}
+
+	return ops
+}
+
+func diffArrays(path string, from, to []any) []*PatchOperation {
+	ops := make([]*PatchOperation, 0)
+
+	n := len(from)
+
+	if len(to) < n {
+		n = len(to)
+	}
+
+	for i := 0; i < n; i++ {
+		ops = append(ops, diffJSON(fmt.Sprintf("%s/%d", path, i), from[i], to[i])...)
+	}
+
+	for i := len(from) - 1; i >= n; i-- {
+		ops = append(ops, &PatchOperation{Op: "remove", Path: fmt.Sprintf("%s/%d", path, i)})
+	}
+
+	for i := n; i < len(to); i++ {
+		ops = append(ops, &PatchOperation{Op: "add", Path: path + "/-", Value: to[i]})
+	}
+
+	return ops
+}
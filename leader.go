@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+var errLeadershipLost = errors.New("leadership lost")
+
+// minLeaderLease is the shortest lease, long enough for the renewals to reach
+// the lock.
+const minLeaderLease = time.Second
+
+// LeaderLock is a lock held by a single instance at once, for a lease.
+type LeaderLock interface {
+	// Acquire takes or renews the lock for the lease, reporting whether it
+	// is held.
+	Acquire(ctx context.Context, lease time.Duration) (bool, error)
+
+	// Release frees the lock when held.
+	Release(ctx context.Context) error
+}
+
+// instanceId returns an identifier unique to the running instance.
+func instanceId() string {
+	hostname, _ := os.Hostname()
+
+	b := make([]byte, 4)
+	rand.Read(b)
+
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(b))
+}
+
+var (
+	redisAcquireScript = redis.NewScript(`
+		if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+			return 1
+		end
+
+		if redis.call("GET", KEYS[1]) == ARGV[1] then
+			redis.call("PEXPIRE", KEYS[1], ARGV[2])
+			return 1
+		end
+
+		return 0
+	`)
+
+	redisReleaseScript = redis.NewScript(`
+		if redis.call("GET", KEYS[1]) == ARGV[1] then
+			return redis.call("DEL", KEYS[1])
+		end
+
+		return 0
+	`)
+)
+
+// RedisLock is a lock stored under "<prefix>:leader", holding the ID of the
+// leader until its lease expires.
+type RedisLock struct {
+	client *redis.Client
+	key    string
+	id     string
+}
+
+func (s *RedisSync) Lock() *RedisLock {
+	return &RedisLock{
+		client: s.client,
+		key:    s.prefix + ":leader",
+		id:     instanceId(),
+	}
+}
+
+func (l *RedisLock) Acquire(ctx context.Context, lease time.Duration) (bool, error) {
+	held, err := redisAcquireScript.Run(ctx, l.client, []string{l.key}, l.id, lease.Milliseconds()).Int()
+
+	return held == 1, err
+}
+
+func (l *RedisLock) Release(ctx context.Context) error {
+	return redisReleaseScript.Run(ctx, l.client, []string{l.key}, l.id).Err()
+}
+
+// FileLock is a lease file on shared storage, holding the ID of the leader
+// and the expiry of its lease.
+type FileLock struct {
+	path string
+	id   string
+}
+
+func NewFileLock(path string) *FileLock {
+	return &FileLock{
+		path: path,
+		id:   instanceId(),
+	}
+}
+
+// read returns the holder of the lease and its expiry, none when missing.
+func (l *FileLock) read() (string, time.Time, error) {
+	data, err := os.ReadFile(l.path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return "", time.Time{}, nil
+	}
+
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	id, expiry, _ := strings.Cut(strings.TrimSpace(string(data)), " ")
+	ms, _ := strconv.ParseInt(expiry, 10, 64)
+
+	return id, time.UnixMilli(ms), nil
+}
+
+func (l *FileLock) Acquire(ctx context.Context, lease time.Duration) (bool, error) {
+	id, expiry, err := l.read()
+
+	if err != nil {
+		return false, err
+	}
+
+	if id != l.id && id != "" && time.Now().Before(expiry) {
+		return false, nil
+	}
+
+	// The lease is written aside then renamed, so the file is never read
+	// partially written.
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*")
+
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Fprintf(tmp, "%s %d\n", l.id, time.Now().Add(lease).UnixMilli())
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		os.Remove(tmp.Name())
+		return false, err
+	}
+
+	// Another instance may have taken the expired lease at once, the last
+	// write winning.
+	if id, _, err = l.read(); err != nil {
+		return false, err
+	}
+
+	return id == l.id, nil
+}
+
+func (l *FileLock) Release(ctx context.Context) error {
+	id, _, err := l.read()
+
+	if err != nil || id != l.id {
+		return err
+	}
+
+	return os.Remove(l.path)
+}
+
+// LeaderElection elects the instance polling YouTube among the ones sharing
+// the lock, renewing its lease until the leadership is lost.
+type LeaderElection struct {
+	Lock  LeaderLock
+	Lease time.Duration
+
+	leader atomic.Bool
+}
+
+// Leader tells whether the instance holds the lock.
+func (e *LeaderElection) Leader() bool {
+	return e != nil && e.leader.Load()
+}
+
+// Await blocks until the lock is acquired or the context is done.
+func (e *LeaderElection) Await(ctx context.Context) error {
+	ticker := time.NewTicker(e.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		held, err := e.Lock.Acquire(ctx, e.Lease)
+
+		if err != nil {
+			log.Err(err).Msg("Unable to acquire leader lock")
+		} else if held {
+			e.leader.Store(true)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Keep renews the lease until the context is done, releasing the lock then,
+// and calls lost when the lock can't be renewed before the lease expires.
+func (e *LeaderElection) Keep(ctx context.Context, lost func()) {
+	ticker := time.NewTicker(e.Lease / 3)
+	defer ticker.Stop()
+
+	renewedAt := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			e.leader.Store(false)
+
+			if err := e.Lock.Release(context.Background()); err != nil {
+				log.Err(err).Msg("Unable to release leader lock")
+			}
+
+			return
+
+		case <-ticker.C:
+		}
+
+		held, err := e.Lock.Acquire(ctx, e.Lease)
+
+		switch {
+		case err == nil && held:
+			renewedAt = time.Now()
+			continue
+
+		// The leadership is given up before the lease expires, so another
+		// instance never polls at once.
+		case err != nil && time.Since(renewedAt) < e.Lease*2/3:
+			log.Err(err).Msg("Unable to renew leader lock")
+			continue
+		}
+
+		e.leader.Store(false)
+		lost()
+
+		return
+	}
+}
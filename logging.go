@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func setupLogging(ctx *cli.Context) error {
+	level, err := zerolog.ParseLevel(ctx.String("log-level"))
+
+	if err != nil {
+		return err
+	}
+
+	zerolog.SetGlobalLevel(level)
+
+	var out io.Writer = os.Stdout
+
+	if path := ctx.String("log-file"); path != "" {
+		out = &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    ctx.Int("log-max-size"),
+			MaxAge:     ctx.Int("log-max-age"),
+			MaxBackups: ctx.Int("log-max-backups"),
+			Compress:   ctx.Bool("log-compress"),
+		}
+	}
+
+	switch format := ctx.String("log-format"); format {
+	case "json":
+		log.Logger = zerolog.New(out).With().Timestamp().Logger()
+
+	case "console":
+		log.Logger = log.Output(zerolog.ConsoleWriter{
+			Out:     out,
+			NoColor: out != os.Stdout,
+		})
+
+	default:
+		return fmt.Errorf("unknown log format: %s", format)
+	}
+
+	return nil
+}
@@ -2,244 +2,1584 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
-	"regexp"
+	"strings"
 	"time"
 
-	"github.com/andybalholm/cascadia"
+	"github.com/getsentry/sentry-go"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/rs/zerolog/pkgerrors"
+	"github.com/seldszar/onyt/pkg/onyt"
 	"github.com/urfave/cli/v2"
-	"golang.org/x/net/html"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi/transport"
 	"google.golang.org/api/option"
 	"google.golang.org/api/youtube/v3"
 )
 
-type State struct {
-	Channel   *youtube.Channel `json:"channel"`
-	LiveVideo *youtube.Video   `json:"liveVideo"`
-	Videos    []*youtube.Video `json:"videos"`
+func refreshSource(ctx context.Context, source onyt.Source, sources *onyt.SourceStore, reporter *ErrorReporter) error {
+	tags := map[string]string{
+		"platform": source.Platform(),
+	}
+
+	defer reporter.Recover(tags)
+
+	result, err := source.Refresh(ctx)
+
+	if err != nil {
+		log.Err(err).Str("platform", source.Platform()).Msgf("Unable to refresh state")
+		reporter.Report(err, tags)
+
+		return err
+	}
+
+	sources.Set(source, result)
+
+	return nil
 }
 
-var (
-	re    = regexp.MustCompile(`(?i)https://www\.youtube\.com/watch\?v=(.+)`)
-	state = new(State)
-)
+func main() {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+
+	log.Logger = log.Output(zerolog.ConsoleWriter{
+		Out: os.Stdout,
+	})
+
+	app := &cli.App{
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "key",
+				Aliases: []string{"k"},
+				EnvVars: []string{"API_KEY"},
+				Usage:   "The YouTube API key",
+			},
+			&cli.StringSliceFlag{
+				Name:    "channel",
+				Aliases: []string{"c"},
+				EnvVars: []string{"CHANNEL_ID"},
+				Usage:   "The YouTube channel IDs, or @handles, to monitor",
+			},
+			&cli.IntFlag{
+				Name:    "port",
+				Aliases: []string{"p"},
+				EnvVars: []string{"PORT"},
+				Usage:   "The server port to use",
+				Value:   3000,
+			},
+			&cli.DurationFlag{
+				Name:    "http-read-timeout",
+				EnvVars: []string{"HTTP_READ_TIMEOUT"},
+				Usage:   "The maximum duration for reading a request, headers included",
+				Value:   10 * time.Second,
+			},
+			&cli.DurationFlag{
+				Name:    "http-write-timeout",
+				EnvVars: []string{"HTTP_WRITE_TIMEOUT"},
+				Usage:   "The maximum duration for writing a response, streams excepted",
+				Value:   30 * time.Second,
+			},
+			&cli.DurationFlag{
+				Name:    "http-idle-timeout",
+				EnvVars: []string{"HTTP_IDLE_TIMEOUT"},
+				Usage:   "The maximum duration a keep-alive connection stays idle",
+				Value:   2 * time.Minute,
+			},
+			&cli.IntFlag{
+				Name:    "http-max-header-bytes",
+				EnvVars: []string{"HTTP_MAX_HEADER_BYTES"},
+				Usage:   "The maximum size of the request headers",
+				Value:   64 << 10,
+			},
+			&cli.IntFlag{
+				Name:    "http-max-conns",
+				EnvVars: []string{"HTTP_MAX_CONNS"},
+				Usage:   "The maximum number of simultaneous connections, unlimited when zero",
+			},
+			&cli.StringFlag{
+				Name:    "tls-cert",
+				EnvVars: []string{"TLS_CERT"},
+				Usage:   "The certificate file serving HTTPS and HTTP/2, along with --tls-key",
+			},
+			&cli.StringFlag{
+				Name:    "tls-key",
+				EnvVars: []string{"TLS_KEY"},
+				Usage:   "The private key file of the certificate",
+			},
+			&cli.BoolFlag{
+				Name:    "h2c",
+				EnvVars: []string{"H2C"},
+				Usage:   "Serve HTTP/2 without TLS to the reverse proxies supporting it",
+			},
+			&cli.Float64Flag{
+				Name:    "rate-limit",
+				EnvVars: []string{"RATE_LIMIT"},
+				Usage:   "The requests per second allowed for each client IP, disabled when zero",
+			},
+			&cli.IntFlag{
+				Name:    "rate-limit-burst",
+				EnvVars: []string{"RATE_LIMIT_BURST"},
+				Usage:   "The requests a client IP can send at once before being rate limited",
+				Value:   20,
+			},
+			&cli.IntFlag{
+				Name:    "max-concurrent",
+				EnvVars: []string{"MAX_CONCURRENT"},
+				Usage:   "The maximum number of requests served at once, streams excepted, unlimited when zero",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				EnvVars: []string{"CONFIG_FILE"},
+				Usage:   "The YAML configuration file to use",
+			},
+			&cli.StringFlag{
+				Name:    "db",
+				EnvVars: []string{"DATABASE_PATH"},
+				Usage:   "The SQLite database persisting data across restarts, kept in memory when empty",
+			},
+			&cli.IntFlag{
+				Name:    "delivery-attempts",
+				EnvVars: []string{"DELIVERY_ATTEMPTS"},
+				Usage:   "The number of attempts to send a notification, with backoff, before moving it to the dead letters",
+				Value:   8,
+			},
+			&cli.DurationFlag{
+				Name:    "delivery-timeout",
+				EnvVars: []string{"DELIVERY_TIMEOUT"},
+				Usage:   "The duration after which an attempt to send a notification fails, unbounded when zero",
+				Value:   30 * time.Second,
+			},
+			&cli.IntFlag{
+				Name:    "event-history",
+				EnvVars: []string{"EVENT_HISTORY"},
+				Usage:   "The number of emitted events kept for the consumers catching up, none when 0",
+				Value:   1000,
+			},
+			&cli.StringFlag{
+				Name:    "log-level",
+				EnvVars: []string{"LOG_LEVEL"},
+				Usage:   "The minimum log level (trace, debug, info, warn, error)",
+				Value:   "info",
+			},
+			&cli.StringFlag{
+				Name:    "log-format",
+				EnvVars: []string{"LOG_FORMAT"},
+				Usage:   "The log format (console, json)",
+				Value:   "console",
+			},
+			&cli.StringFlag{
+				Name:    "log-file",
+				EnvVars: []string{"LOG_FILE"},
+				Usage:   "The file to write logs to instead of stdout",
+			},
+			&cli.IntFlag{
+				Name:    "log-max-size",
+				EnvVars: []string{"LOG_MAX_SIZE"},
+				Usage:   "The maximum size in megabytes of the log file before rotation",
+				Value:   100,
+			},
+			&cli.IntFlag{
+				Name:    "log-max-age",
+				EnvVars: []string{"LOG_MAX_AGE"},
+				Usage:   "The maximum number of days to retain rotated log files, unlimited when zero",
+				Value:   28,
+			},
+			&cli.IntFlag{
+				Name:    "log-max-backups",
+				EnvVars: []string{"LOG_MAX_BACKUPS"},
+				Usage:   "The maximum number of rotated log files to retain, unlimited when zero",
+				Value:   7,
+			},
+			&cli.BoolFlag{
+				Name:    "log-compress",
+				EnvVars: []string{"LOG_COMPRESS"},
+				Usage:   "Compress rotated log files with gzip",
+			},
+			&cli.StringFlag{
+				Name:    "debug-listen",
+				EnvVars: []string{"DEBUG_LISTEN"},
+				Usage:   "The address serving pprof, expvar and goroutine dumps, disabled when empty",
+			},
+			&cli.BoolFlag{
+				Name:    "tracing",
+				EnvVars: []string{"TRACING"},
+				Usage:   "Export refresh traces over OTLP, configured with the standard OTEL_EXPORTER_OTLP_* variables",
+			},
+			&cli.StringFlag{
+				Name:    "sentry-dsn",
+				EnvVars: []string{"SENTRY_DSN"},
+				Usage:   "The Sentry DSN used to report refresh failures and panics",
+			},
+			&cli.StringFlag{
+				Name:    "error-webhook",
+				EnvVars: []string{"ERROR_WEBHOOK_URL"},
+				Usage:   "The URL receiving refresh failures and panics as JSON",
+			},
+			&cli.DurationFlag{
+				Name:    "error-dedupe-window",
+				EnvVars: []string{"ERROR_DEDUPE_WINDOW"},
+				Usage:   "The duration during which identical errors are reported once",
+				Value:   time.Hour,
+			},
+			&cli.IntFlag{
+				Name:    "error-rate-limit",
+				EnvVars: []string{"ERROR_RATE_LIMIT"},
+				Usage:   "The maximum number of error reports sent per hour",
+				Value:   20,
+			},
+			&cli.StringFlag{
+				Name:    "role",
+				EnvVars: []string{"ROLE"},
+				Usage:   "The instance role (poller, frontend, both), frontends mirror the state published to Redis by pollers",
+				Value:   "both",
+			},
+			&cli.StringFlag{
+				Name:    "redis-url",
+				EnvVars: []string{"REDIS_URL"},
+				Usage:   "The Redis URL used to share states and events between instances",
+			},
+			&cli.StringFlag{
+				Name:    "leader-lock",
+				EnvVars: []string{"LEADER_LOCK"},
+				Usage:   "Elect the instance polling YouTube with a lock, redis or the path of a lease file on shared storage, the others mirroring its state",
+			},
+			&cli.DurationFlag{
+				Name:    "leader-lease",
+				EnvVars: []string{"LEADER_LEASE"},
+				Usage:   "The duration of the leader lease, renewed every third, at least 1s",
+				Value:   15 * time.Second,
+			},
+			&cli.StringFlag{
+				Name:    "redis-prefix",
+				EnvVars: []string{"REDIS_PREFIX"},
+				Usage:   "The prefix of the Redis keys and channels",
+				Value:   "onyt",
+			},
+			&cli.StringFlag{
+				Name:    "nats-url",
+				EnvVars: []string{"NATS_URL"},
+				Usage:   "The NATS server URL events are published to",
+			},
+			&cli.StringFlag{
+				Name:    "nats-subject",
+				EnvVars: []string{"NATS_SUBJECT"},
+				Usage:   "The prefix of the NATS subjects, events are published on <prefix>.events.<type> and states on <prefix>.state.<channel>",
+				Value:   "onyt",
+			},
+			&cli.StringSliceFlag{
+				Name:    "kafka-brokers",
+				EnvVars: []string{"KAFKA_BROKERS"},
+				Usage:   "The Kafka broker addresses events are published to",
+			},
+			&cli.StringFlag{
+				Name:    "kafka-topic",
+				EnvVars: []string{"KAFKA_TOPIC"},
+				Usage:   "The Kafka topic events are published to",
+				Value:   "onyt.events",
+			},
+			&cli.StringFlag{
+				Name:    "kafka-state-topic",
+				EnvVars: []string{"KAFKA_STATE_TOPIC"},
+				Usage:   "The Kafka topic states are published to",
+				Value:   "onyt.state",
+			},
+			&cli.StringFlag{
+				Name:    "sink-format",
+				EnvVars: []string{"SINK_FORMAT"},
+				Usage:   "The serialization of the published messages (json, protobuf)",
+				Value:   "json",
+			},
+			&cli.BoolFlag{
+				Name:    "sink-snapshots",
+				EnvVars: []string{"SINK_SNAPSHOTS"},
+				Usage:   "Publish the full state after each change in addition to events",
+			},
+			&cli.StringFlag{
+				Name:    "upload-bucket",
+				EnvVars: []string{"UPLOAD_BUCKET"},
+				Usage:   "The bucket URL (s3://<bucket>, gs://<bucket>) states and session summaries are uploaded to",
+			},
+			&cli.StringFlag{
+				Name:    "upload-endpoint",
+				EnvVars: []string{"UPLOAD_ENDPOINT"},
+				Usage:   "The S3-compatible endpoint to use instead of the default one of the bucket scheme",
+			},
+			&cli.StringFlag{
+				Name:    "upload-access-key",
+				EnvVars: []string{"UPLOAD_ACCESS_KEY"},
+				Usage:   "The access key of the bucket, read from the environment or instance metadata when empty",
+			},
+			&cli.StringFlag{
+				Name:    "upload-secret-key",
+				EnvVars: []string{"UPLOAD_SECRET_KEY"},
+				Usage:   "The secret key of the bucket",
+			},
+			&cli.StringFlag{
+				Name:    "upload-state-key",
+				EnvVars: []string{"UPLOAD_STATE_KEY"},
+				Usage:   "The object key template of the uploaded states",
+				Value:   "{{.ChannelId}}/state.json",
+			},
+			&cli.StringFlag{
+				Name:    "upload-session-key",
+				EnvVars: []string{"UPLOAD_SESSION_KEY"},
+				Usage:   "The object key template of the uploaded session summaries",
+				Value:   "{{.ChannelId}}/sessions/{{.VideoId}}.json",
+			},
+			&cli.BoolFlag{
+				Name:    "trust-proxy",
+				EnvVars: []string{"TRUST_PROXY"},
+				Usage:   "Trust the X-Forwarded-For header to resolve client IPs, from its rightmost entry appended by the proxy",
+			},
+			&cli.StringFlag{
+				Name:    "influx-url",
+				EnvVars: []string{"INFLUX_URL"},
+				Usage:   "The InfluxDB write endpoint the statistics are written to every refresh, such as http://localhost:8086/api/v2/write?org=onyt&bucket=onyt",
+			},
+			&cli.StringFlag{
+				Name:    "influx-token",
+				EnvVars: []string{"INFLUX_TOKEN"},
+				Usage:   "The InfluxDB API token",
+			},
+			&cli.StringFlag{
+				Name:    "graphite-addr",
+				EnvVars: []string{"GRAPHITE_ADDR"},
+				Usage:   "The Carbon plaintext address the statistics are written to every refresh, such as localhost:2003",
+			},
+			&cli.StringFlag{
+				Name:    "timeseries-prefix",
+				EnvVars: []string{"TIMESERIES_PREFIX"},
+				Usage:   "The prefix of the exported measurements",
+				Value:   "onyt.",
+			},
+			&cli.StringFlag{
+				Name:    "admin-token",
+				EnvVars: []string{"ADMIN_TOKEN"},
+				Usage:   "The bearer token of the admin endpoints adding and removing channels at runtime, disabled when empty",
+			},
+			&cli.BoolFlag{
+				Name:    "paused",
+				EnvVars: []string{"PAUSED"},
+				Usage:   "Start with the polling paused, until resumed through the admin endpoints",
+			},
+			&cli.StringFlag{
+				Name:    "base-path",
+				EnvVars: []string{"BASE_PATH"},
+				Usage:   "The path prefix the routes are served under, such as /onyt, when mounted behind a reverse proxy",
+			},
+			&cli.StringFlag{
+				Name:    "cache-control",
+				EnvVars: []string{"CACHE_CONTROL"},
+				Usage:   "The default Cache-Control header of the responses, such as max-age=10",
+			},
+			&cli.StringSliceFlag{
+				Name:    "trim",
+				EnvVars: []string{"TRIM_FIELDS"},
+				Usage:   "The heavy fields left out of the served states unless expanded (description, thumbnails, tags, localizations)",
+			},
+			&cli.IntFlag{
+				Name:    "grpc-port",
+				EnvVars: []string{"GRPC_PORT"},
+				Usage:   "The gRPC server port to use, disabled when zero",
+			},
+			&cli.StringFlag{
+				Name:    "live-detection",
+				EnvVars: []string{"LIVE_DETECTION"},
+				Usage:   "The comma-separated live detection methods (canonical, innertube, search), tried in order, or off to rely on the uploads playlist",
+				Value:   "canonical",
+			},
+			&cli.BoolFlag{
+				Name:    "search-fallback",
+				EnvVars: []string{"SEARCH_FALLBACK"},
+				Usage:   "Use the search API (100 quota units per call) when live scraping fails",
+			},
+			&cli.DurationFlag{
+				Name:    "search-fallback-interval",
+				EnvVars: []string{"SEARCH_FALLBACK_INTERVAL"},
+				Usage:   "The minimum interval between search API calls of each channel",
+				Value:   15 * time.Minute,
+			},
+			&cli.StringFlag{
+				Name:    "invidious-url",
+				EnvVars: []string{"INVIDIOUS_URL"},
+				Usage:   "The Invidious instance URL to use with the invidious backend",
+			},
+			&cli.StringFlag{
+				Name:    "piped-url",
+				EnvVars: []string{"PIPED_URL"},
+				Usage:   "The Piped API URL to use with the piped backend",
+			},
+			&cli.StringFlag{
+				Name:    "holodex-key",
+				EnvVars: []string{"HOLODEX_API_KEY"},
+				Usage:   "The Holodex API key used to enrich the state with Holodex data",
+			},
+			&cli.StringFlag{
+				Name:    "twitch-client-id",
+				EnvVars: []string{"TWITCH_CLIENT_ID"},
+				Usage:   "The Twitch application client ID",
+			},
+			&cli.StringFlag{
+				Name:    "twitch-client-secret",
+				EnvVars: []string{"TWITCH_CLIENT_SECRET"},
+				Usage:   "The Twitch application client secret",
+			},
+			&cli.StringFlag{
+				Name:    "twitch-channel",
+				EnvVars: []string{"TWITCH_CHANNEL"},
+				Usage:   "The Twitch channel login",
+			},
+			&cli.StringFlag{
+				Name:    "kick-channel",
+				EnvVars: []string{"KICK_CHANNEL"},
+				Usage:   "The Kick channel slug",
+			},
+			&cli.StringFlag{
+				Name:    "rumble-channel",
+				EnvVars: []string{"RUMBLE_CHANNEL"},
+				Usage:   "The Rumble channel name",
+			},
+			&cli.Int64Flag{
+				Name:    "fetch-comments",
+				EnvVars: []string{"FETCH_COMMENTS"},
+				Usage:   "The number of top comment threads to fetch for the latest upload and live video (1 quota unit per video and refresh), disabled when zero",
+			},
+			&cli.BoolFlag{
+				Name:    "backfill-uploads",
+				EnvVars: []string{"BACKFILL_UPLOADS"},
+				Usage:   "Archive all the uploads of the channels in the database on startup (1 quota unit per 50 videos)",
+			},
+			&cli.Int64Flag{
+				Name:    "quota-limit",
+				EnvVars: []string{"QUOTA_LIMIT"},
+				Usage:   "The daily quota units optional fetches, such as comments, stop at",
+				Value:   10000,
+			},
+			&cli.DurationFlag{
+				Name:    "community-interval",
+				EnvVars: []string{"COMMUNITY_INTERVAL"},
+				Usage:   "The interval between Community tab scrapes, disabled when zero",
+			},
+			&cli.DurationFlag{
+				Name:    "playlists-interval",
+				EnvVars: []string{"PLAYLISTS_INTERVAL"},
+				Usage:   "The interval between playlist listings, disabled when zero",
+			},
+			&cli.StringSliceFlag{
+				Name:    "expand-playlist",
+				EnvVars: []string{"EXPAND_PLAYLIST"},
+				Usage:   "The playlist IDs to expand into their videos",
+			},
+			&cli.DurationFlag{
+				Name:    "live-game-interval",
+				EnvVars: []string{"LIVE_GAME_INTERVAL"},
+				Usage:   "The interval between scrapes of the game played on each live stream, disabled when zero",
+			},
+			&cli.BoolFlag{
+				Name:    "sponsorblock",
+				EnvVars: []string{"SPONSORBLOCK"},
+				Usage:   "Whether to attach the SponsorBlock segments to the latest uploads",
+			},
+			&cli.StringFlag{
+				Name:    "sponsorblock-url",
+				EnvVars: []string{"SPONSORBLOCK_URL"},
+				Usage:   "The URL of the SponsorBlock API",
+				Value:   "https://sponsor.ajay.app",
+			},
+			&cli.StringSliceFlag{
+				Name:    "sponsorblock-categories",
+				EnvVars: []string{"SPONSORBLOCK_CATEGORIES"},
+				Usage:   "The SponsorBlock segment categories to attach",
+				Value:   cli.NewStringSlice("sponsor", "intro", "outro"),
+			},
+			&cli.IntFlag{
+				Name:    "sponsorblock-videos",
+				EnvVars: []string{"SPONSORBLOCK_VIDEOS"},
+				Usage:   "The number of latest uploads to attach the SponsorBlock segments to",
+				Value:   5,
+			},
+			&cli.BoolFlag{
+				Name:    "dislikes",
+				EnvVars: []string{"DISLIKES"},
+				Usage:   "Whether to attach the dislikes estimated by Return YouTube Dislike to the videos",
+			},
+			&cli.StringFlag{
+				Name:    "dislikes-url",
+				EnvVars: []string{"DISLIKES_URL"},
+				Usage:   "The URL of the Return YouTube Dislike API",
+				Value:   "https://returnyoutubedislikeapi.com",
+			},
+			&cli.DurationFlag{
+				Name:    "watch-page-interval",
+				EnvVars: []string{"WATCH_PAGE_INTERVAL"},
+				Usage:   "The interval between scrapes of the watch page of each video for the extras the API lacks, disabled when zero",
+			},
+			&cli.IntFlag{
+				Name:    "watch-page-videos",
+				EnvVars: []string{"WATCH_PAGE_VIDEOS"},
+				Usage:   "The number of latest uploads to attach the watch page extras to, besides the live and upcoming videos",
+				Value:   5,
+			},
+			&cli.DurationFlag{
+				Name:    "sections-interval",
+				EnvVars: []string{"SECTIONS_INTERVAL"},
+				Usage:   "The interval between channel section listings, disabled when zero",
+			},
+			&cli.BoolFlag{
+				Name:    "sections-live",
+				EnvVars: []string{"SECTIONS_LIVE"},
+				Usage:   "Whether to check the featured channels for live streams on every refresh, by scraping their live page",
+			},
+			&cli.StringSliceFlag{
+				Name:    "monitor-playlist",
+				EnvVars: []string{"MONITOR_PLAYLIST"},
+				Usage:   "The playlist IDs to monitor for added videos, beyond the uploads",
+			},
+			&cli.DurationFlag{
+				Name:    "monitor-playlist-interval",
+				EnvVars: []string{"MONITOR_PLAYLIST_INTERVAL"},
+				Usage:   "The interval between refreshes of the monitored playlists",
+				Value:   10 * time.Minute,
+			},
+			&cli.StringFlag{
+				Name:    "oauth-client-id",
+				EnvVars: []string{"OAUTH_CLIENT_ID"},
+				Usage:   "The OAuth client ID used to read the live chat of the monitored channel",
+			},
+			&cli.StringFlag{
+				Name:    "oauth-client-secret",
+				EnvVars: []string{"OAUTH_CLIENT_SECRET"},
+				Usage:   "The OAuth client secret",
+			},
+			&cli.StringFlag{
+				Name:    "oauth-refresh-token",
+				EnvVars: []string{"OAUTH_REFRESH_TOKEN"},
+				Usage:   "The OAuth refresh token of the channel owner, granted the youtube.readonly scope",
+			},
+			&cli.BoolFlag{
+				Name:    "subscriptions",
+				EnvVars: []string{"SUBSCRIPTIONS"},
+				Usage:   "Whether to monitor every channel the OAuth account subscribes to",
+			},
+			&cli.StringSliceFlag{
+				Name:    "subscriptions-include",
+				EnvVars: []string{"SUBSCRIPTIONS_INCLUDE"},
+				Usage:   "The patterns, such as *gaming*, the subscribed channel IDs or titles must match to be monitored",
+			},
+			&cli.StringSliceFlag{
+				Name:    "subscriptions-exclude",
+				EnvVars: []string{"SUBSCRIPTIONS_EXCLUDE"},
+				Usage:   "The patterns the subscribed channel IDs or titles must not match to be monitored",
+			},
+			&cli.DurationFlag{
+				Name:    "subscriptions-interval",
+				EnvVars: []string{"SUBSCRIPTIONS_INTERVAL"},
+				Usage:   "The interval between syncs of the subscriptions",
+				Value:   time.Hour,
+			},
+			&cli.DurationFlag{
+				Name:    "live-chat-interval",
+				EnvVars: []string{"LIVE_CHAT_INTERVAL"},
+				Usage:   "The minimum interval between live chat polls (about 5 quota units per poll)",
+				Value:   10 * time.Second,
+			},
+			&cli.StringFlag{
+				Name:    "hl",
+				EnvVars: []string{"HL"},
+				Usage:   "The language of the returned titles and descriptions, such as \"ja\", when localized by the channel",
+			},
+			&cli.StringFlag{
+				Name:    "region",
+				EnvVars: []string{"REGION"},
+				Usage:   "The ISO 3166-1 region code used by the search API and scrapers",
+			},
+			&cli.StringFlag{
+				Name:    "cookies",
+				EnvVars: []string{"COOKIES_FILE"},
+				Usage:   "The Netscape cookies.txt file whose cookies are sent by the scrapers, for age-restricted or region-gated pages",
+			},
+			&cli.StringSliceFlag{
+				Name:    "scrape-proxies",
+				EnvVars: []string{"SCRAPE_PROXIES"},
+				Usage:   "The HTTP or SOCKS proxies the scrapes are sent through, such as socks5://127.0.0.1:1080",
+			},
+			&cli.StringFlag{
+				Name:    "scrape-proxy-mode",
+				EnvVars: []string{"SCRAPE_PROXY_MODE"},
+				Usage:   "How the scrape proxies are used (round-robin, failover)",
+				Value:   onyt.ProxyRoundRobin,
+			},
+			&cli.StringSliceFlag{
+				Name:    "scrape-user-agents",
+				EnvVars: []string{"SCRAPE_USER_AGENTS"},
+				Usage:   "The User-Agents rotated across the scrapes",
+			},
+			&cli.StringFlag{
+				Name:    "scrape-accept-language",
+				EnvVars: []string{"SCRAPE_ACCEPT_LANGUAGE"},
+				Usage:   "The Accept-Language header sent by the scrapers instead of the languages of the locale",
+			},
+			&cli.DurationFlag{
+				Name:    "scrape-pacing",
+				EnvVars: []string{"SCRAPE_PACING"},
+				Usage:   "The average randomized delay between two scrapes of the same host, disabled when zero",
+			},
+			&cli.DurationFlag{
+				Name:    "scrape-cooldown",
+				EnvVars: []string{"SCRAPE_COOLDOWN"},
+				Usage:   "How long the scrapes of a host are held back after it blocked one, disabled when zero",
+			},
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				EnvVars: []string{"DRY_RUN"},
+				Usage:   "Log the notifications instead of sending them, and keep the Discord bot offline",
+			},
+			&cli.StringFlag{
+				Name:    "replay",
+				EnvVars: []string{"REPLAY_DIR"},
+				Usage:   "The directory of recorded responses answering the API calls and scrapes instead of YouTube",
+			},
+			&cli.StringFlag{
+				Name:    "debug-dump",
+				EnvVars: []string{"DEBUG_DUMP_DIR"},
+				Usage:   "The directory where the raw responses of the API calls and scrapes are written, for troubleshooting",
+			},
+			&cli.StringFlag{
+				Name:    "youtube-url",
+				EnvVars: []string{"YOUTUBE_URL"},
+				Usage:   "The server answering the API calls and scrapes instead of YouTube, such as the mock server",
+			},
+			&cli.DurationFlag{
+				Name:    "refresh-interval",
+				EnvVars: []string{"REFRESH_INTERVAL"},
+				Usage:   "The interval between refreshes of each channel, spread across the channels",
+				Value:   time.Minute,
+			},
+			&cli.IntFlag{
+				Name:    "max-videos",
+				EnvVars: []string{"MAX_VIDEOS"},
+				Usage:   "The maximum number of latest uploads listed for each channel, all those fetched when zero",
+			},
+			&cli.DurationFlag{
+				Name:    "live-interval",
+				EnvVars: []string{"LIVE_INTERVAL"},
+				Usage:   "The interval between refreshes of the live videos statistics while live (1 quota unit each), disabled when zero",
+			},
+			&cli.IntFlag{
+				Name:    "workers",
+				EnvVars: []string{"WORKERS"},
+				Usage:   "The maximum number of channels refreshed at once",
+				Value:   4,
+			},
+			&cli.DurationFlag{
+				Name:    "channel-ttl",
+				EnvVars: []string{"CHANNEL_TTL"},
+				Usage:   "How long the channel metadata is cached, fetched on each refresh when zero",
+				Value:   time.Hour,
+			},
+			&cli.DurationFlag{
+				Name:    "channel-statistics-ttl",
+				EnvVars: []string{"CHANNEL_STATISTICS_TTL"},
+				Usage:   "How long the channel statistics are cached while its metadata is, fetched on each refresh when zero",
+				Value:   5 * time.Minute,
+			},
+			&cli.DurationFlag{
+				Name:    "skip-unchanged",
+				EnvVars: []string{"SKIP_UNCHANGED"},
+				Usage:   "How long the uploads are reused instead of being fetched again while the uploads playlist is unchanged, disabled when zero",
+			},
+			&cli.DurationFlag{
+				Name:    "batch-window",
+				EnvVars: []string{"BATCH_WINDOW"},
+				Usage:   "The window during which the videos.list calls of the channels are coalesced into batches of 50 IDs, disabled when zero",
+			},
+			&cli.DurationFlag{
+				Name:    "stale-after",
+				EnvVars: []string{"STALE_AFTER"},
+				Usage:   "The age past which a channel state is flagged as stale",
+				Value:   5 * time.Minute,
+			},
+			&cli.BoolFlag{
+				Name:    "stale-unavailable",
+				EnvVars: []string{"STALE_UNAVAILABLE"},
+				Usage:   "Respond with a 503 to the channel requests once their state is stale",
+			},
+			&cli.StringFlag{
+				Name:    "thumb-cache-dir",
+				EnvVars: []string{"THUMB_CACHE_DIR"},
+				Usage:   "The directory caching the thumbnails served by /thumb, disabled when empty",
+			},
+			&cli.Int64Flag{
+				Name:    "thumb-cache-size",
+				EnvVars: []string{"THUMB_CACHE_SIZE"},
+				Usage:   "The maximum size in megabytes of the thumbnail cache",
+				Value:   100,
+			},
+			&cli.DurationFlag{
+				Name:    "thumb-cache-ttl",
+				EnvVars: []string{"THUMB_CACHE_TTL"},
+				Usage:   "The duration thumbnails are cached for",
+				Value:   24 * time.Hour,
+			},
+			&cli.StringFlag{
+				Name:    "discord-bot-token",
+				EnvVars: []string{"DISCORD_BOT_TOKEN"},
+				Usage:   "The token of the Discord bot reflecting the live status in its presence",
+			},
+			&cli.StringFlag{
+				Name:    "discord-bot-presence",
+				EnvVars: []string{"DISCORD_BOT_PRESENCE"},
+				Usage:   "The template of the Discord bot activity, disabled when empty",
+				Value:   defaultDiscordPresence,
+			},
+			&cli.StringFlag{
+				Name:    "discord-topic-channel",
+				EnvVars: []string{"DISCORD_TOPIC_CHANNEL"},
+				Usage:   "The Discord channel whose topic reflects the live status",
+			},
+			&cli.StringFlag{
+				Name:    "discord-topic",
+				EnvVars: []string{"DISCORD_TOPIC"},
+				Usage:   "The template of the Discord channel topic",
+				Value:   defaultDiscordTopic,
+			},
+			&cli.StringSliceFlag{
+				Name:    "channel-backends",
+				EnvVars: []string{"CHANNEL_BACKENDS"},
+				Usage:   "The backends used to fetch the channel, in failover order",
+				Value:   cli.NewStringSlice("youtube"),
+			},
+			&cli.StringSliceFlag{
+				Name:    "video-backends",
+				EnvVars: []string{"VIDEO_BACKENDS"},
+				Usage:   "The backends used to fetch videos, in failover order",
+				Value:   cli.NewStringSlice("youtube"),
+			},
+			&cli.StringSliceFlag{
+				Name:    "live-backends",
+				EnvVars: []string{"LIVE_BACKENDS"},
+				Usage:   "The backends used to detect live streams, in failover order",
+				Value:   cli.NewStringSlice("youtube"),
+			},
+		},
+		Commands: []*cli.Command{
+			mockServerCommand,
+			serviceCommand,
+			exportCommand,
+			verifyCommand,
+		},
+		Action: func(ctx *cli.Context) error {
+			if err := setupLogging(ctx); err != nil {
+				return err
+			}
+
+			config, err := LoadConfig(ctx.String("config"))
+
+			if err != nil {
+				return err
+			}
+
+			var (
+				database       *Database
+				milestoneStore onyt.MilestoneStore
+			)
+
+			if path := ctx.String("db"); path != "" {
+				if database, err = OpenDatabase(path); err != nil {
+					return err
+				}
+
+				defer database.Close()
+
+				milestoneStore = database
+			}
+
+			key := ctx.String("key")
+			channels := ctx.StringSlice("channel")
+			port := ctx.Int("port")
+			role := ctx.String("role")
+
+			if role != "poller" && role != "frontend" && role != "both" {
+				return fmt.Errorf("unknown role: %s", role)
+			}
+
+			var redisSync *RedisSync
+
+			if url := ctx.String("redis-url"); url != "" {
+				shared, err := NewRedisSync(url, ctx.String("redis-prefix"))
+
+				if err != nil {
+					return err
+				}
+
+				redisSync = shared
+			} else if role == "frontend" {
+				return errors.New("the frontend role requires a Redis URL")
+			}
+
+			var election *LeaderElection
+
+			if lock := ctx.String("leader-lock"); lock != "" {
+				if redisSync == nil || role != "both" {
+					return errors.New("the leader election requires a Redis URL and the both role")
+				}
+
+				// The lease is renewed every third, hence bounded below.
+				if lease := ctx.Duration("leader-lease"); lease < minLeaderLease {
+					return fmt.Errorf("the leader lease must be at least %s", minLeaderLease)
+				}
+
+				election = &LeaderElection{
+					Lock:  NewFileLock(lock),
+					Lease: ctx.Duration("leader-lease"),
+				}
+
+				if lock == "redis" {
+					election.Lock = redisSync.Lock()
+				}
+			}
+
+			if ctx.Bool("tracing") {
+				shutdown, err := setupTracing(ctx.Context)
+
+				if err != nil {
+					return err
+				}
+
+				defer shutdown(context.Background())
+			}
+
+			sentryDsn := ctx.String("sentry-dsn")
+
+			if sentryDsn != "" {
+				err := sentry.Init(sentry.ClientOptions{
+					Dsn:              sentryDsn,
+					AttachStacktrace: true,
+				})
+
+				if err != nil {
+					return err
+				}
+
+				defer sentry.Flush(5 * time.Second)
+			}
+
+			reporter := NewErrorReporter(sentryDsn != "", ctx.String("error-webhook"), ctx.Duration("error-dedupe-window"), ctx.Int("error-rate-limit"))
+
+			var src *youtube.Service
+
+			if key != "" {
+				client := &http.Client{
+					Transport: &transport.APIKey{
+						Key:       key,
+						Transport: onyt.DefaultThrottle,
+					},
+				}
+
+				service, err := youtube.NewService(context.Background(), option.WithHTTPClient(client))
+
+				if err != nil {
+					log.Fatal().Err(err).Msg("Unable to initialize YouTube service")
+				}
+
+				src = service
+			}
+
+			quota := onyt.NewQuotaMeter(ctx.Int64("quota-limit"))
+
+			var oauthService *youtube.Service
+
+			if token := ctx.String("oauth-refresh-token"); token != "" {
+				config := &oauth2.Config{
+					ClientID:     ctx.String("oauth-client-id"),
+					ClientSecret: ctx.String("oauth-client-secret"),
+					Endpoint:     google.Endpoint,
+					Scopes:       []string{youtube.YoutubeReadonlyScope},
+				}
+
+				client := &http.Client{
+					Transport: &oauth2.Transport{
+						Source: config.TokenSource(context.Background(), &oauth2.Token{RefreshToken: token}),
+						Base:   onyt.DefaultThrottle,
+					},
+				}
+
+				service, err := youtube.NewService(context.Background(), option.WithHTTPClient(client))
+
+				if err != nil {
+					return err
+				}
+
+				oauthService = service
+			}
+
+			if path := ctx.String("cookies"); path != "" {
+				if err := onyt.LoadCookies(path); err != nil {
+					return err
+				}
+			}
+
+			if proxies := ctx.StringSlice("scrape-proxies"); len(proxies) > 0 {
+				pool, err := onyt.NewProxyPool(proxies, ctx.String("scrape-proxy-mode"), onyt.DefaultThrottle.Transport)
+
+				if err != nil {
+					return err
+				}
+
+				onyt.DefaultThrottle.Transport = pool
+			}
+
+			if dir := ctx.String("replay"); dir != "" {
+				replay, err := onyt.NewReplay(dir)
+
+				if err != nil {
+					return err
+				}
+
+				onyt.DefaultThrottle.Transport = replay
+			}
+
+			if base := ctx.String("youtube-url"); base != "" {
+				rewrite, err := onyt.NewRewrite(base, onyt.DefaultThrottle.Transport)
+
+				if err != nil {
+					return err
+				}
+
+				onyt.DefaultThrottle.Transport = rewrite
+			}
+
+			if dir := ctx.String("debug-dump"); dir != "" {
+				dump, err := onyt.NewDump(dir, onyt.DefaultThrottle.Transport)
+
+				if err != nil {
+					return err
+				}
+
+				onyt.DefaultThrottle.Transport = dump
+			}
+
+			if ctx.IsSet("scrape-user-agents") || ctx.IsSet("scrape-accept-language") || ctx.IsSet("scrape-pacing") || ctx.IsSet("scrape-cooldown") {
+				guard := onyt.NewScrapeGuard(onyt.DefaultThrottle.Transport)
+				guard.UserAgents = ctx.StringSlice("scrape-user-agents")
+				guard.AcceptLanguage = ctx.String("scrape-accept-language")
+				guard.Pacing = ctx.Duration("scrape-pacing")
+				guard.Cooldown = ctx.Duration("scrape-cooldown")
 
-func startWebServer(port int) error {
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().
-			Set("content-type", "application/json")
+				onyt.DefaultThrottle.Transport = guard
+			}
 
-		json.NewEncoder(w).
-			Encode(state)
-	})
+			youtubeBackend := onyt.NewYouTubeBackend(src)
+			youtubeBackend.Quota = quota
+			youtubeBackend.ChannelTTL = ctx.Duration("channel-ttl")
+			youtubeBackend.StatisticsTTL = ctx.Duration("channel-statistics-ttl")
 
-	return http.ListenAndServe(fmt.Sprintf(":%d", port), handler)
-}
+			locale := onyt.Locale{
+				Language: ctx.String("hl"),
+				Region:   ctx.String("region"),
+			}
 
-func fetchLiveVideoId(channelId string) (string, error) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://www.youtube.com/channel/%s/live", channelId), nil)
+			youtubeBackend.Locale = locale
 
-	if err != nil {
-		return "", err
-	}
+			if youtubeBackend.LiveDetection, err = onyt.ParseLiveDetection(ctx.String("live-detection")); err != nil {
+				return err
+			}
 
-	req.AddCookie(&http.Cookie{
-		Name:   "CONSENT",
-		Value:  "YES+42",
-		Secure: true,
-	})
+			if ctx.Bool("search-fallback") && len(youtubeBackend.LiveDetection) > 0 && !contains(youtubeBackend.LiveDetection, onyt.LiveDetectionSearch) {
+				youtubeBackend.LiveDetection = append(youtubeBackend.LiveDetection, onyt.LiveDetectionSearch)
+			}
 
-	resp, err := http.DefaultClient.Do(req)
+			youtubeBackend.SearchFallback = onyt.NewSearchFallback(ctx.Duration("search-fallback-interval"))
 
-	if err != nil {
-		return "", err
-	}
+			if window := ctx.Duration("batch-window"); window > 0 {
+				youtubeBackend.BatchVideos(window)
+			}
 
-	doc, err := html.Parse(resp.Body)
+			available := map[string]onyt.Backend{
+				"youtube": youtubeBackend,
+			}
 
-	if err != nil {
-		return "", err
-	}
+			if url := ctx.String("invidious-url"); url != "" {
+				available["invidious"] = onyt.NewInvidiousBackend(url)
+			}
 
-	sel, err := cascadia.Parse("link[rel='canonical']")
+			if url := ctx.String("piped-url"); url != "" {
+				available["piped"] = onyt.NewPipedBackend(url)
+			}
 
-	if err != nil {
-		return "", err
-	}
+			client := new(onyt.Client)
+
+			if client.Channel, err = onyt.ResolveBackends(available, ctx.StringSlice("channel-backends")); err != nil {
+				return err
+			}
+
+			if client.Videos, err = onyt.ResolveBackends(available, ctx.StringSlice("video-backends")); err != nil {
+				return err
+			}
+
+			if client.Live, err = onyt.ResolveBackends(available, ctx.StringSlice("live-backends")); err != nil {
+				return err
+			}
+
+			var sponsorBlock *onyt.SponsorBlockClient
+
+			if ctx.Bool("sponsorblock") {
+				sponsorBlock = onyt.NewSponsorBlockClient(ctx.String("sponsorblock-url"), ctx.StringSlice("sponsorblock-categories"), ctx.Int("sponsorblock-videos"))
+			}
+
+			var dislikes *onyt.DislikesClient
+
+			if ctx.Bool("dislikes") {
+				dislikes = onyt.NewDislikesClient(ctx.String("dislikes-url"))
+			}
+
+			var watchPage *onyt.WatchPageScraper
+
+			if interval := ctx.Duration("watch-page-interval"); interval > 0 {
+				watchPage = onyt.NewWatchPageScraper(interval, ctx.Int("watch-page-videos"))
+				watchPage.Locale = locale
+			}
+
+			var holodex *onyt.HolodexClient
+
+			if key := ctx.String("holodex-key"); key != "" {
+				holodex = onyt.NewHolodexClient(key)
+			}
+
+			var milestones *onyt.MilestoneTracker
 
-	if node := cascadia.Query(doc, sel); node != nil {
-		for _, v := range node.Attr {
-			if v.Key == "href" {
-				if sm := re.FindStringSubmatch(v.Val); len(sm) > 0 {
-					return sm[1], nil
+			if len(config.Milestones) > 0 {
+				milestones = onyt.NewMilestoneTracker(config.Milestones, milestoneStore)
+			}
+
+			var keywords *onyt.KeywordMatcher
+
+			if len(config.Keywords) > 0 {
+				if keywords, err = onyt.NewKeywordMatcher(config.Keywords); err != nil {
+					return err
 				}
 			}
-		}
-	}
 
-	return "", nil
-}
+			for i, channel := range channels {
+				if !strings.HasPrefix(channel, "@") {
+					continue
+				}
 
-func fetchChannel(src *youtube.Service, channelId string) (*youtube.Channel, error) {
-	resp, err := src.Channels.List([]string{"contentDetails", "snippet", "statistics"}).
-		Id(channelId).
-		Do()
+				if channels[i], err = onyt.ResolveHandle(ctx.Context, channel); err != nil {
+					return fmt.Errorf("unable to resolve %s: %w", channel, err)
+				}
 
-	if err != nil {
-		return nil, err
-	}
+				log.Info().Str("handle", channel).Str("channel", channels[i]).Msg("Handle resolved")
+			}
 
-	if len(resp.Items) > 0 {
-		return resp.Items[0], nil
-	}
+			if database != nil {
+				added, removed, err := database.Channels()
 
-	return nil, nil
-}
+				if err != nil {
+					return err
+				}
 
-func fetchPlaylistItems(src *youtube.Service, playlistId string) ([]*youtube.PlaylistItem, error) {
-	resp, err := src.PlaylistItems.List([]string{"contentDetails", "snippet"}).
-		PlaylistId(playlistId).
-		MaxResults(25).
-		Do()
+				channels = mergeChannels(channels, added, removed)
+			}
 
-	if err != nil {
-		return nil, err
-	}
+			var subscriptions *SubscriptionSync
 
-	return resp.Items, nil
-}
+			if ctx.Bool("subscriptions") {
+				if oauthService == nil {
+					return errors.New("subscriptions require an OAuth refresh token")
+				}
 
-func fetchVideos(src *youtube.Service, videoIds []string) ([]*youtube.Video, error) {
-	resp, err := src.Videos.List([]string{"snippet", "statistics", "liveStreamingDetails"}).
-		Id(videoIds...).
-		Do()
+				subscriptions = NewSubscriptionSync(oauthService, quota, ctx.StringSlice("subscriptions-include"), ctx.StringSlice("subscriptions-exclude"))
 
-	if err != nil {
-		return nil, err
-	}
+				if channels, err = subscriptions.Seed(ctx.Context, channels); err != nil {
+					return fmt.Errorf("unable to sync subscriptions: %w", err)
+				}
+			}
 
-	return resp.Items, nil
-}
+			pause := new(onyt.Pause)
+			pause.Set(ctx.Bool("paused"))
+
+			if pause.Paused() {
+				log.Info().Msg("Polling paused")
+			}
 
-func refresh(src *youtube.Service, channelId string) error {
-	channel, err := fetchChannel(src, channelId)
+			var (
+				events  = onyt.NewEventBus()
+				pollers []*onyt.Poller
+				list    []onyt.Source
+			)
 
-	if err != nil {
-		return err
-	}
+			newPoller := func(channel string) *onyt.Poller {
+				poller := onyt.NewPoller(channel, client, events)
+				poller.Holodex = holodex
+				poller.SponsorBlock = sponsorBlock
+				poller.Dislikes = dislikes
+				poller.WatchPage = watchPage
+				poller.Milestones = milestones
+				poller.Keywords = keywords
+				poller.ReuseUploads = ctx.Duration("skip-unchanged")
+				poller.Pause = pause
 
-	liveVideoId, err := fetchLiveVideoId(channel.Id)
+				overrides := config.Channel(channel)
+				poller.LiveDetection = overrides.liveDetection
+				poller.MaxVideos = ctx.Int("max-videos")
 
-	if err != nil {
-		return err
-	}
+				if overrides.MaxVideos > 0 {
+					poller.MaxVideos = overrides.MaxVideos
+				}
 
-	playlistItems, err := fetchPlaylistItems(src, channel.ContentDetails.RelatedPlaylists.Uploads)
+				if interval := ctx.Duration("community-interval"); interval > 0 {
+					poller.Community = onyt.NewCommunityTracker(interval)
+					poller.Community.Locale = locale
+				}
 
-	if err != nil {
-		return err
-	}
+				if interval := ctx.Duration("playlists-interval"); interval > 0 && src != nil {
+					poller.Playlists = onyt.NewPlaylistTracker(src, quota, interval, ctx.StringSlice("expand-playlist"))
+					poller.Playlists.Locale = locale
+				}
 
-	videoIds := make([]string, 0)
+				if interval := ctx.Duration("live-game-interval"); interval > 0 {
+					poller.Games = onyt.NewGameTracker(interval)
+					poller.Games.Locale = locale
+				}
 
-	if liveVideoId != "" {
-		videoIds = append(videoIds, liveVideoId)
-	}
+				if interval := ctx.Duration("sections-interval"); interval > 0 && src != nil {
+					poller.Sections = onyt.NewSectionTracker(src, quota, interval)
+					poller.Sections.Locale = locale
 
-	for _, v := range playlistItems {
-		videoIds = append(videoIds, v.ContentDetails.VideoId)
-	}
+					if ctx.Bool("sections-live") {
+						poller.Sections.Live = onyt.DetectCanonicalLive
+					}
+				}
 
-	state.Channel = channel
+				if oauthService != nil {
+					poller.LiveChat = onyt.NewLiveChatTracker(oauthService, quota, ctx.Duration("live-chat-interval"))
+					poller.LiveChat.Pause = pause
+				}
 
-	if len(videoIds) == 0 {
-		state.Videos = make([]*youtube.Video, 0)
-		state.LiveVideo = nil
+				if count := ctx.Int64("fetch-comments"); count > 0 && src != nil {
+					poller.Comments = onyt.NewCommentFetcher(src, count, quota)
+				}
 
-		return nil
-	}
+				return poller
+			}
 
-	videos, err := fetchVideos(src, videoIds)
+			for _, channel := range channels {
+				poller := newPoller(channel)
 
-	if err != nil {
-		return err
-	}
+				pollers = append(pollers, poller)
+				list = append(list, onyt.NewYouTubeSource(poller))
+			}
 
-	var liveVideo *youtube.Video
+			if login := ctx.String("twitch-channel"); login != "" {
+				list = append(list, onyt.NewTwitchSource(ctx.String("twitch-client-id"), ctx.String("twitch-client-secret"), login))
+			}
 
-	if len(videos) > 0 && videos[0].Id == liveVideoId {
-		liveVideo = videos[0]
-		videos = videos[1:]
-	}
+			if slug := ctx.String("kick-channel"); slug != "" {
+				list = append(list, onyt.NewKickSource(slug))
+			}
 
-	state.LiveVideo = liveVideo
-	state.Videos = videos
+			if name := ctx.String("rumble-channel"); name != "" {
+				list = append(list, onyt.NewRumbleSource(name))
+			}
 
-	return nil
-}
+			// Channels can be added at runtime through the admin endpoints, or the
+			// subscriptions.
+			if len(list) == 0 && ctx.String("admin-token") == "" && subscriptions == nil {
+				return errors.New("no channel configured")
+			}
 
-func main() {
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+			sources := onyt.NewSourceStore(list)
+			scheduler := onyt.NewScheduler(ctx.Duration("refresh-interval"), ctx.Int("workers"))
+			scheduler.Pause = pause
+			scheduler.Intervals = make(map[string]time.Duration)
 
-	log.Logger = log.Output(zerolog.ConsoleWriter{
-		Out: os.Stdout,
-	})
+			for channelId, channel := range config.Channels {
+				scheduler.Intervals[channelId] = channel.Interval
+			}
 
-	app := &cli.App{
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:     "key",
-				Aliases:  []string{"k"},
-				EnvVars:  []string{"API_KEY"},
-				Usage:    "The YouTube API key",
-				Required: true,
-			},
-			&cli.StringFlag{
-				Name:     "channel",
-				Aliases:  []string{"c"},
-				EnvVars:  []string{"CHANNEL_ID"},
-				Usage:    "The YouTube channel ID",
-				Required: true,
-			},
-			&cli.IntFlag{
-				Name:    "port",
-				Aliases: []string{"p"},
-				EnvVars: []string{"PORT"},
-				Usage:   "The server port to use",
-				Value:   3000,
-			},
-		},
-		Action: func(ctx *cli.Context) error {
-			key := ctx.String("key")
-			channel := ctx.String("channel")
-			port := ctx.Int("port")
+			// The channels refreshed at once share their batches.
+			if youtubeBackend.Batcher != nil {
+				scheduler.Group = scheduler.Workers
+			}
 
-			src, err := youtube.NewService(context.Background(), option.WithAPIKey(key))
+			expvar.Publish("schedule", expvar.Func(func() any {
+				return scheduler.List()
+			}))
 
-			if err != nil {
-				log.Fatal().Err(err).Msg("Unable to initialize YouTube service")
+			for _, field := range ctx.StringSlice("trim") {
+				if !contains(onyt.TrimFields, field) {
+					return fmt.Errorf("unknown trimmed field: %s", field)
+				}
+			}
+
+			server := NewServer(pollers, sources)
+			server.TrustProxy = ctx.Bool("trust-proxy")
+			server.BasePath = ctx.String("base-path")
+			server.CacheControl = ctx.String("cache-control")
+			server.Trim = ctx.StringSlice("trim")
+			server.Quota = quota
+			server.Scheduler = scheduler
+			server.Pause = pause
+			server.Election = election
+			server.Database = database
+			server.Dashboard = NewDashboard(events)
+			server.Groups = config.Groups()
+
+			if ids := ctx.StringSlice("monitor-playlist"); len(ids) > 0 && src != nil {
+				server.Playlists = onyt.NewPlaylistMonitor(src, quota, events, ids)
+				server.Playlists.Pause = pause
+				server.Playlists.Locale = locale
+			}
+
+			if len(config.Notifiers) > 0 {
+				if ctx.Int("delivery-attempts") < 1 {
+					return errors.New("the delivery attempts must be at least 1")
+				}
+
+				if server.Deliveries, err = NewDeliveryQueue(database, ctx.Int("delivery-attempts")); err != nil {
+					return err
+				}
+
+				server.Deliveries.Timeout = ctx.Duration("delivery-timeout")
+			}
+
+			if capacity := ctx.Int("event-history"); capacity > 0 {
+				if server.Events, err = NewEventLog(events, database, capacity); err != nil {
+					return err
+				}
+			}
+
+			server.StaleAfter = ctx.Duration("stale-after")
+			server.StaleUnavailable = ctx.Bool("stale-unavailable")
+			server.MaxConcurrent = ctx.Int("max-concurrent")
+
+			liveInterval := ctx.Duration("live-interval")
+			exporter := new(TimeSeriesExporter)
+
+			if url := ctx.String("influx-url"); url != "" {
+				exporter.Writers = append(exporter.Writers, &InfluxWriter{
+					URL:    url,
+					Token:  ctx.String("influx-token"),
+					Prefix: strings.ReplaceAll(ctx.String("timeseries-prefix"), ".", "_"),
+				})
+			}
+
+			if addr := ctx.String("graphite-addr"); addr != "" {
+				exporter.Writers = append(exporter.Writers, &GraphiteWriter{
+					Addr:   addr,
+					Prefix: ctx.String("timeseries-prefix"),
+				})
+			}
+
+			// The history served to Grafana is kept in the database.
+			if database != nil {
+				exporter.Writers = append(exporter.Writers, database)
+			}
+
+			publisher := NewSinkPublisher(ctx.String("sink-format"), ctx.Bool("sink-snapshots"))
+
+			if publisher.Format != "json" && publisher.Format != "protobuf" {
+				return fmt.Errorf("unknown sink format: %s", publisher.Format)
+			}
+
+			if url := ctx.String("nats-url"); url != "" {
+				sink, err := NewNATSSink(url, ctx.String("nats-subject"))
+
+				if err != nil {
+					return err
+				}
+
+				publisher.Sinks = append(publisher.Sinks, sink)
+			}
+
+			if brokers := ctx.StringSlice("kafka-brokers"); len(brokers) > 0 {
+				publisher.Sinks = append(publisher.Sinks, NewKafkaSink(brokers, ctx.String("kafka-topic"), ctx.String("kafka-state-topic")))
+			}
+
+			var digests *DigestScheduler
+
+			if len(config.Digests) > 0 {
+				if digests, err = NewDigestScheduler(config.Digests, server.Pollers, events); err != nil {
+					return err
+				}
+			}
+
+			var uploader *Uploader
+
+			if bucket := ctx.String("upload-bucket"); bucket != "" {
+				if uploader, err = NewUploader(bucket, ctx.String("upload-endpoint"), ctx.String("upload-access-key"), ctx.String("upload-secret-key"), ctx.String("upload-state-key"), ctx.String("upload-session-key")); err != nil {
+					return err
+				}
+			}
+
+			var bot *DiscordBot
+
+			if token := ctx.String("discord-bot-token"); token != "" && !ctx.Bool("dry-run") {
+				if bot, err = NewDiscordBot(token, ctx.String("discord-topic-channel"), ctx.String("discord-bot-presence"), ctx.String("discord-topic")); err != nil {
+					return err
+				}
+			}
+
+			backfill := database != nil && ctx.Bool("backfill-uploads") && src != nil
+
+			manager := NewChannelManager(ctx.Context, server, scheduler, database, list)
+			manager.NewPoller = newPoller
+			manager.Run = func(ctx context.Context, poller *onyt.Poller) {
+				if redisSync != nil {
+					go redisSync.PublishState(ctx, poller)
+				}
+
+				if interval := config.Channel(poller.ChannelId).LiveInterval; interval > 0 {
+					go poller.RunLive(ctx, interval)
+				} else if liveInterval > 0 {
+					go poller.RunLive(ctx, liveInterval)
+				}
+
+				if len(exporter.Writers) > 0 {
+					go exporter.Watch(ctx, poller)
+				}
+
+				if database != nil {
+					go database.Watch(ctx, poller)
+				}
+
+				if backfill {
+					go func() {
+						if err := database.Backfill(ctx, src, quota, poller.ChannelId); err != nil && ctx.Err() == nil {
+							log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to backfill uploads")
+						}
+					}()
+				}
+
+				if len(publisher.Sinks) > 0 {
+					go publisher.Watch(ctx, poller)
+				}
+
+				if digests != nil {
+					go digests.Watch(ctx, poller)
+				}
+
+				if uploader != nil {
+					go uploader.Watch(ctx, poller)
+				}
+
+				if bot != nil {
+					go bot.Watch(ctx, poller)
+				}
+			}
+
+			if role != "frontend" {
+				server.AdminToken = ctx.String("admin-token")
+				server.Channels = manager
+			}
+
+			if rate := ctx.Float64("rate-limit"); rate > 0 {
+				server.RateLimit = NewRateLimiter(rate, ctx.Int("rate-limit-burst"))
+			}
+
+			if server.Chat, err = NewChatResponder(config.Chat); err != nil {
+				return err
+			}
+
+			if dir := ctx.String("thumb-cache-dir"); dir != "" {
+				if server.Thumbnails, err = NewThumbnailCache(dir, ctx.Int64("thumb-cache-size")<<20, ctx.Duration("thumb-cache-ttl")); err != nil {
+					return err
+				}
+			}
+
+			if role != "poller" {
+				options := HTTPOptions{
+					ReadTimeout:    ctx.Duration("http-read-timeout"),
+					WriteTimeout:   ctx.Duration("http-write-timeout"),
+					IdleTimeout:    ctx.Duration("http-idle-timeout"),
+					MaxHeaderBytes: ctx.Int("http-max-header-bytes"),
+					MaxConns:       ctx.Int("http-max-conns"),
+					TLSCert:        ctx.String("tls-cert"),
+					TLSKey:         ctx.String("tls-key"),
+					H2C:            ctx.Bool("h2c"),
+				}
+
+				listeners, err := systemdListeners()
+
+				if err != nil {
+					return err
+				}
+
+				// Listen before notifying systemd, so the service is reachable
+				// once it is declared ready.
+				if len(listeners) > 0 {
+					options.Listener = listeners[0]
+				} else if options.Listener, err = net.Listen("tcp", fmt.Sprintf(":%d", port)); err != nil {
+					return err
+				}
+
+				go func() {
+					if err := server.ListenAndServe(port, options); err != nil {
+						log.Fatal().Err(err).Msg("Unable to start server")
+					}
+				}()
+			}
+
+			if addr := ctx.String("debug-listen"); addr != "" {
+				go func() {
+					if err := startDebugServer(addr); err != nil {
+						log.Err(err).Msg("Unable to start debug server")
+					}
+				}()
+			}
+
+			if grpcPort := ctx.Int("grpc-port"); grpcPort > 0 && role != "poller" {
+				go func() {
+					if err := startGRPCServer(grpcPort, server, events); err != nil {
+						log.Err(err).Msg("Unable to start gRPC server")
+					}
+				}()
+			}
+
+			if err := sdNotify("READY=1"); err != nil {
+				log.Warn().Err(err).Msg("Unable to notify systemd")
+			}
+
+			go sdWatchdog(ctx.Context)
+
+			if role == "frontend" {
+				return redisSync.Follow(ctx.Context, pollers, events)
+			}
+
+			// Until elected, the instance mirrors the state of the leader as a
+			// frontend. It exits once the leadership is lost, restarting as a
+			// follower.
+			runCtx := ctx.Context
+
+			if election != nil {
+				following, stopFollowing := context.WithCancel(ctx.Context)
+
+				go func() {
+					if err := redisSync.Follow(following, pollers, events); err != nil && following.Err() == nil {
+						log.Err(err).Msg("Unable to follow the leader")
+					}
+				}()
+
+				log.Info().Msg("Waiting for the leadership")
+
+				err := election.Await(ctx.Context)
+
+				stopFollowing()
+
+				if err != nil {
+					return err
+				}
+
+				log.Info().Msg("Elected as leader")
+
+				var lose context.CancelFunc
+
+				runCtx, lose = context.WithCancel(ctx.Context)
+
+				keeping, stopKeeping := context.WithCancel(ctx.Context)
+				kept := make(chan struct{})
+
+				go func() {
+					defer close(kept)
+
+					election.Keep(keeping, lose)
+				}()
+
+				defer func() {
+					stopKeeping()
+					<-kept
+				}()
+			}
+
+			if len(publisher.Sinks) > 0 {
+				publisher.Start(ctx.Context, events)
+			}
+
+			if server.Playlists != nil {
+				go server.Playlists.Run(runCtx, ctx.Duration("monitor-playlist-interval"))
+			}
+
+			if subscriptions != nil {
+				subscriptions.Manager = manager
+
+				go subscriptions.Run(runCtx, ctx.Duration("subscriptions-interval"))
+			}
+
+			if len(config.Notifiers) > 0 {
+				router, err := NewNotifierRouter(config.Notifiers, server.Groups, func(channelId string) *onyt.State {
+					if poller, ok := server.Poller(channelId); ok {
+						return poller.Store.Get()
+					}
+
+					return nil
+				})
+
+				if err != nil {
+					return err
+				}
+
+				for channelId, channel := range config.Channels {
+					if len(channel.Notifiers) == 0 {
+						continue
+					}
+
+					if err := router.Restrict(channelId, channel.Notifiers); err != nil {
+						return fmt.Errorf("channel %s: %w", channelId, err)
+					}
+				}
+
+				router.DryRun = ctx.Bool("dry-run")
+				router.Queue = server.Deliveries
+				router.Start(ctx.Context, events)
+			}
+
+			if database != nil {
+				database.Record(ctx.Context, events)
+			}
+
+			if digests != nil {
+				digests.Start(ctx.Context)
 			}
 
-			go startWebServer(port)
+			if uploader != nil {
+				uploader.Start(ctx.Context, events)
+			}
 
-			for {
-				if err := refresh(src, channel); err != nil {
-					log.Err(err).Msgf("Unable to refresh state")
+			if bot != nil {
+				if err := bot.Start(ctx.Context, server.Pollers); err != nil {
+					return err
 				}
+			}
+
+			if redisSync != nil {
+				defer redisSync.PublishEvents(events)()
+			}
 
-				time.Sleep(time.Minute)
+			for _, poller := range pollers {
+				manager.Track(poller)
 			}
+
+			scheduler.Run(runCtx, list, func(ctx context.Context, source onyt.Source) error {
+				return refreshSource(ctx, source, sources, reporter)
+			})
+
+			if runCtx.Err() != nil && ctx.Context.Err() == nil {
+				return errLeadershipLost
+			}
+
+			return nil
 		},
 	}
 
-	if err := app.Run(os.Args); err != nil {
-		log.Fatal().Err(err)
+	if err := runApp(app, os.Args); err != nil {
+		log.Fatal().Err(err).Msg("Unable to run application")
 	}
 }
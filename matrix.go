@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+type matrixNotifier struct {
+	Homeserver  string `yaml:"homeserver"`
+	AccessToken string `yaml:"accessToken"`
+	RoomId      string `yaml:"roomId"`
+	MsgType     string `yaml:"msgType"`
+
+	txn atomic.Uint64
+}
+
+func newMatrixNotifier(config *NotifierConfig) (Notifier, error) {
+	n := &matrixNotifier{
+		MsgType: "m.notice",
+	}
+
+	if err := config.Decode(n); err != nil {
+		return nil, err
+	}
+
+	if n.Homeserver == "" || n.AccessToken == "" || n.RoomId == "" {
+		return nil, errors.New("missing homeserver, access token or room id")
+	}
+
+	n.Homeserver = strings.TrimSuffix(n.Homeserver, "/")
+
+	return n, nil
+}
+
+type matrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+// formattedMessage renders the HTML counterpart of the default message.
+func formattedMessage(n *Notification) string {
+	v, ok := n.Data.(*onyt.Video)
+
+	if n.Message != "" || !ok || v.Snippet == nil {
+		return ""
+	}
+
+	link := fmt.Sprintf(`<a href="https://www.youtube.com/watch?v=%s">%s</a>`, url.QueryEscape(v.Id), html.EscapeString(v.Snippet.Title))
+	channel := html.EscapeString(channelTitle(n))
+
+	switch n.Type {
+	case "live_started":
+		return fmt.Sprintf("🔴 <b>%s</b> is live: %s", channel, link)
+
+	case "video_uploaded":
+		return fmt.Sprintf("<b>%s</b> uploaded a new video: %s", channel, link)
+	}
+
+	return ""
+}
+
+func (m *matrixNotifier) Notify(ctx context.Context, n *Notification) error {
+	message := &matrixMessage{
+		MsgType:       m.MsgType,
+		Body:          defaultMessage(n),
+		FormattedBody: formattedMessage(n),
+	}
+
+	if message.FormattedBody != "" {
+		message.Format = "org.matrix.custom.html"
+	}
+
+	// Transaction ids make retried requests idempotent, and must be unique
+	// across restarts for the same access token.
+	txnId := fmt.Sprintf("onyt-%d-%d", time.Now().UnixNano(), m.txn.Add(1))
+
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s", m.Homeserver, url.PathEscape(m.RoomId), txnId)
+
+	header := http.Header{
+		"Authorization": {"Bearer " + m.AccessToken},
+	}
+
+	return sendJSON(ctx, http.MethodPut, endpoint, header, message)
+}
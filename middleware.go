@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type contextKey string
+
+const requestIdKey contextKey = "requestId"
+
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func newRequestId() string {
+	b := make([]byte, 8)
+
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
+
+func requestIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey).(string)
+
+	return id
+}
+
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		// The rightmost entry is the one appended by the trusted proxy, the
+		// others being set by the client.
+		if values := r.Header.Values("x-forwarded-for"); len(values) > 0 {
+			entries := strings.Split(values[len(values)-1], ",")
+
+			if ip := strings.TrimSpace(entries[len(entries)-1]); ip != "" {
+				return ip
+			}
+		}
+
+		if ip := r.Header.Get("x-real-ip"); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func requestIdMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("x-request-id")
+
+		if id == "" {
+			id = newRequestId()
+		}
+
+		w.Header().Set("x-request-id", id)
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIdKey, id)))
+	})
+}
+
+func accessLogMiddleware(trustProxy bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		log.Info().
+			Str("requestId", requestIdFromContext(r.Context())).
+			Str("method", r.Method).
+			Str("path", r.URL.Path).
+			Int("status", recorder.status).
+			Int("bytes", recorder.bytes).
+			Dur("latency", time.Since(start)).
+			Str("ip", clientIP(r, trustProxy)).
+			Str("userAgent", r.UserAgent()).
+			Msg("Request handled")
+	})
+}
+
+// jsonpCallback matches the callback names accepted by JSONP, being plain
+// identifiers or dotted paths.
+var jsonpCallback = regexp.MustCompile(`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)*$`)
+
+// jsonWriter buffers the JSON responses to indent them or wrap them into a
+// JSONP callback, passing the other responses through.
+type jsonWriter struct {
+	http.ResponseWriter
+
+	pretty   bool
+	callback string
+
+	decided   bool
+	buffering bool
+	status    int
+	body      bytes.Buffer
+}
+
+func (w *jsonWriter) decide() {
+	if w.decided {
+		return
+	}
+
+	w.decided = true
+	w.buffering = strings.HasPrefix(w.Header().Get("content-type"), "application/json")
+}
+
+func (w *jsonWriter) WriteHeader(status int) {
+	w.decide()
+
+	if w.buffering {
+		w.status = status
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *jsonWriter) Write(b []byte) (int, error) {
+	w.decide()
+
+	if w.buffering {
+		return w.body.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *jsonWriter) Flush() {
+	if w.buffering {
+		return
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *jsonWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *jsonWriter) finish() {
+	if !w.buffering {
+		return
+	}
+
+	body := w.body.Bytes()
+
+	if w.pretty {
+		var indented bytes.Buffer
+
+		if err := json.Indent(&indented, body, "", "  "); err == nil {
+			body = append(indented.Bytes(), '\n')
+		}
+	}
+
+	if w.callback != "" {
+		w.Header().Set("content-type", "application/javascript")
+		w.Header().Set("x-content-type-options", "nosniff")
+
+		// The leading comment prevents the response from being sniffed as
+		// something else than a script.
+		body = []byte("/**/" + w.callback + "(" + strings.TrimRight(string(body), "\n") + ");\n")
+	}
+
+	w.Header().Del("content-length")
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	w.ResponseWriter.Write(body)
+}
+
+// jsonOptionsMiddleware applies the default cache-control header, left to
+// the handlers to override, and the pretty and callback query parameters.
+func jsonOptionsMiddleware(cacheControl string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cacheControl != "" {
+			w.Header().Set("cache-control", cacheControl)
+		}
+
+		query := r.URL.Query()
+
+		pretty := query.Get("pretty") != "" && query.Get("pretty") != "0"
+		callback := query.Get("callback")
+
+		if !pretty && callback == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if callback != "" && !jsonpCallback.MatchString(callback) {
+			http.Error(w, "invalid callback", http.StatusBadRequest)
+			return
+		}
+
+		writer := &jsonWriter{
+			ResponseWriter: w,
+			pretty:         pretty,
+			callback:       callback,
+		}
+
+		next.ServeHTTP(writer, r)
+
+		writer.finish()
+	})
+}
@@ -0,0 +1,747 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/api/youtube/v3"
+)
+
+const mockUploads = 12
+
+// mockGames are the games played on the live streams, switched every
+// minute.
+var mockGames = []string{"Minecraft", "Celeste", "Tetris"}
+
+// MockServer serves canned channels, videos and live pages, generated from
+// the requested channel IDs, to the fetchers of Onyt.
+type MockServer struct {
+	Live      []string
+	LiveCycle time.Duration
+
+	// AgeRestricted are the channel IDs whose live page is age-gated.
+	AgeRestricted []string
+
+	startedAt time.Time
+
+	mu       sync.Mutex
+	channels map[string]bool
+}
+
+func NewMockServer(live []string, liveCycle time.Duration) *MockServer {
+	return &MockServer{
+		Live:      live,
+		LiveCycle: liveCycle,
+		startedAt: time.Now(),
+		channels:  make(map[string]bool),
+	}
+}
+
+// remember keeps track of the requested channels, whose videos can then be
+// looked up by ID.
+func (m *MockServer) remember(channelId string) bool {
+	if !strings.HasPrefix(channelId, "UC") || len(channelId) < 6 {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.channels[channelId] = true
+
+	return true
+}
+
+func (m *MockServer) channelIds() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.channels))
+
+	for id := range m.channels {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// mockId derives a stable video ID from the channel ID.
+func mockId(channelId string, index int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s/%d", channelId, index)))
+	return base64.RawURLEncoding.EncodeToString(sum[:])[:11]
+}
+
+func mockChannelId(playlistId string) string {
+	return "UC" + strings.TrimPrefix(playlistId, "UU")
+}
+
+// liveSince returns when the current stream of the channel started, or zero
+// when the channel is offline. Cycling channels go live every other cycle.
+func (m *MockServer) liveSince(channelId string, now time.Time) time.Time {
+	if contains(m.Live, channelId) || contains(m.Live, "*") {
+		return m.startedAt
+	}
+
+	if m.LiveCycle <= 0 {
+		return time.Time{}
+	}
+
+	cycle := now.Sub(m.startedAt) / m.LiveCycle
+
+	if cycle%2 == 0 {
+		return time.Time{}
+	}
+
+	return m.startedAt.Add(cycle * m.LiveCycle)
+}
+
+// liveId returns the ID of the current stream, changing with each cycle.
+func (m *MockServer) liveId(channelId string, now time.Time) string {
+	since := m.liveSince(channelId, now)
+
+	if since.IsZero() {
+		return ""
+	}
+
+	return mockId(channelId, -int(since.Unix()))
+}
+
+func (m *MockServer) channel(channelId string) *youtube.Channel {
+	return &youtube.Channel{
+		Kind: "youtube#channel",
+		Id:   channelId,
+		Snippet: &youtube.ChannelSnippet{
+			Title:       fmt.Sprintf("Mock Channel %s", channelId[len(channelId)-4:]),
+			Description: "A channel served by the Onyt mock server.",
+			CustomUrl:   "@mock" + strings.ToLower(channelId[len(channelId)-4:]),
+			PublishedAt: m.startedAt.AddDate(-1, 0, 0).UTC().Format(time.RFC3339),
+			Thumbnails: &youtube.ThumbnailDetails{
+				Default: &youtube.Thumbnail{
+					Url:    "https://yt3.ggpht.com/a/default-user=s88-c-k-c0x00ffffff-no-rj",
+					Width:  88,
+					Height: 88,
+				},
+			},
+		},
+		ContentDetails: &youtube.ChannelContentDetails{
+			RelatedPlaylists: &youtube.ChannelContentDetailsRelatedPlaylists{
+				Uploads: "UU" + strings.TrimPrefix(channelId, "UC"),
+			},
+		},
+		Statistics: &youtube.ChannelStatistics{
+			SubscriberCount: 12345,
+			VideoCount:      mockUploads,
+			ViewCount:       987654,
+		},
+	}
+}
+
+func (m *MockServer) videoIds(channelId string, now time.Time) []string {
+	ids := make([]string, 0, mockUploads+1)
+
+	if id := m.liveId(channelId, now); id != "" {
+		ids = append(ids, id)
+	}
+
+	for i := 0; i < mockUploads; i++ {
+		ids = append(ids, mockId(channelId, i))
+	}
+
+	return ids
+}
+
+func (m *MockServer) video(videoId string, now time.Time) *youtube.Video {
+	for _, channelId := range m.channelIds() {
+		if videoId == m.liveId(channelId, now) {
+			since := m.liveSince(channelId, now)
+
+			return &youtube.Video{
+				Kind: "youtube#video",
+				Id:   videoId,
+				Snippet: &youtube.VideoSnippet{
+					ChannelId:            channelId,
+					ChannelTitle:         m.channel(channelId).Snippet.Title,
+					Title:                "Mock live stream",
+					PublishedAt:          since.UTC().Format(time.RFC3339),
+					LiveBroadcastContent: "live",
+					CategoryId:           "20",
+				},
+				TopicDetails: &youtube.VideoTopicDetails{
+					TopicCategories: []string{
+						"https://en.wikipedia.org/wiki/Action_game",
+						"https://en.wikipedia.org/wiki/Video_game_culture",
+					},
+				},
+				ContentDetails: &youtube.VideoContentDetails{
+					Duration: "P0D",
+				},
+				Statistics: &youtube.VideoStatistics{
+					ViewCount: uint64(now.Sub(since) / time.Second),
+					LikeCount: uint64(now.Sub(since) / time.Minute),
+				},
+				LiveStreamingDetails: &youtube.VideoLiveStreamingDetails{
+					ActualStartTime:   since.UTC().Format(time.RFC3339),
+					ConcurrentViewers: 42,
+				},
+			}
+		}
+
+		for i := 0; i < mockUploads; i++ {
+			if videoId == mockId(channelId, i) {
+				return &youtube.Video{
+					Kind: "youtube#video",
+					Id:   videoId,
+					Snippet: &youtube.VideoSnippet{
+						ChannelId:            channelId,
+						ChannelTitle:         m.channel(channelId).Snippet.Title,
+						Title:                fmt.Sprintf("Mock upload #%d", mockUploads-i),
+						Description:          "A video served by the Onyt mock server.",
+						PublishedAt:          m.startedAt.AddDate(0, 0, -2*i).UTC().Format(time.RFC3339),
+						LiveBroadcastContent: "none",
+					},
+					Status: &youtube.VideoStatus{
+						PrivacyStatus: "public",
+					},
+					ContentDetails: &youtube.VideoContentDetails{
+						Duration: fmt.Sprintf("PT%dM%dS", 4+i, 7*i%60),
+					},
+					Statistics: &youtube.VideoStatistics{
+						ViewCount:    uint64(1000 * (mockUploads - i)),
+						LikeCount:    uint64(50 * (mockUploads - i)),
+						CommentCount: uint64(5 * (mockUploads - i)),
+					},
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func writeMockError(w http.ResponseWriter, code int, reason string) {
+	w.Header().Set("content-type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":    code,
+			"message": reason,
+			"errors": []map[string]any{
+				{"reason": reason},
+			},
+		},
+	})
+}
+
+func queryIds(r *http.Request, name string) []string {
+	ids := make([]string, 0)
+
+	for _, value := range r.URL.Query()[name] {
+		for _, id := range strings.Split(value, ",") {
+			if id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
+func (m *MockServer) serveChannels(w http.ResponseWriter, r *http.Request) {
+	resp := &youtube.ChannelListResponse{
+		Kind:  "youtube#channelListResponse",
+		Items: make([]*youtube.Channel, 0),
+	}
+
+	for _, id := range queryIds(r, "id") {
+		if m.remember(id) {
+			resp.Items = append(resp.Items, m.channel(id))
+		}
+	}
+
+	writeJSON(w, resp)
+}
+
+// featuredIds derives the channels featured by the channel.
+func featuredIds(channelId string) []string {
+	ids := make([]string, 0, 2)
+
+	for i := 0; i < 2; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("%s/featured/%d", channelId, i)))
+		ids = append(ids, "UC"+base64.RawURLEncoding.EncodeToString(sum[:])[:22])
+	}
+
+	return ids
+}
+
+func (m *MockServer) serveChannelSections(w http.ResponseWriter, r *http.Request) {
+	channelId := r.URL.Query().Get("channelId")
+
+	resp := &youtube.ChannelSectionListResponse{
+		Kind:  "youtube#channelSectionListResponse",
+		Items: make([]*youtube.ChannelSection, 0),
+	}
+
+	if m.remember(channelId) {
+		position := int64(0)
+
+		resp.Items = append(resp.Items, &youtube.ChannelSection{
+			Kind: "youtube#channelSection",
+			Id:   channelId + ".featured",
+			Snippet: &youtube.ChannelSectionSnippet{
+				ChannelId: channelId,
+				Type:      "multipleChannels",
+				Title:     "Friends",
+				Position:  &position,
+			},
+			ContentDetails: &youtube.ChannelSectionContentDetails{
+				Channels: featuredIds(channelId),
+			},
+		})
+	}
+
+	writeJSON(w, resp)
+}
+
+func (m *MockServer) servePlaylists(w http.ResponseWriter, r *http.Request) {
+	resp := &youtube.PlaylistListResponse{
+		Kind:  "youtube#playlistListResponse",
+		Items: make([]*youtube.Playlist, 0),
+	}
+
+	for _, id := range queryIds(r, "id") {
+		channelId := mockChannelId(id)
+
+		if !m.remember(channelId) {
+			continue
+		}
+
+		resp.Items = append(resp.Items, &youtube.Playlist{
+			Kind: "youtube#playlist",
+			Id:   id,
+			Snippet: &youtube.PlaylistSnippet{
+				ChannelId:   channelId,
+				Title:       fmt.Sprintf("Uploads from Mock Channel %s", channelId[len(channelId)-4:]),
+				PublishedAt: m.startedAt.AddDate(-1, 0, 0).UTC().Format(time.RFC3339),
+			},
+			ContentDetails: &youtube.PlaylistContentDetails{
+				ItemCount: int64(len(m.videoIds(channelId, time.Now()))),
+			},
+		})
+	}
+
+	writeJSON(w, resp)
+}
+
+func (m *MockServer) servePlaylistItems(w http.ResponseWriter, r *http.Request) {
+	channelId := mockChannelId(r.URL.Query().Get("playlistId"))
+
+	if !m.remember(channelId) {
+		writeMockError(w, http.StatusNotFound, "playlistNotFound")
+		return
+	}
+
+	resp := &youtube.PlaylistItemListResponse{
+		Kind:  "youtube#playlistItemListResponse",
+		Items: make([]*youtube.PlaylistItem, 0),
+	}
+
+	now := time.Now()
+	videoIds := m.videoIds(channelId, now)
+
+	sum := sha1.Sum([]byte(strings.Join(videoIds, ",")))
+	resp.Etag = base64.RawURLEncoding.EncodeToString(sum[:])
+
+	if r.Header.Get("if-none-match") == resp.Etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	for _, id := range videoIds {
+		v := m.video(id, now)
+
+		resp.Items = append(resp.Items, &youtube.PlaylistItem{
+			Kind: "youtube#playlistItem",
+			Snippet: &youtube.PlaylistItemSnippet{
+				ChannelId:    channelId,
+				ChannelTitle: v.Snippet.ChannelTitle,
+				Title:        v.Snippet.Title,
+				Description:  v.Snippet.Description,
+				PublishedAt:  v.Snippet.PublishedAt,
+			},
+			ContentDetails: &youtube.PlaylistItemContentDetails{
+				VideoId:          id,
+				VideoPublishedAt: v.Snippet.PublishedAt,
+			},
+		})
+	}
+
+	writeJSON(w, resp)
+}
+
+func (m *MockServer) serveVideos(w http.ResponseWriter, r *http.Request) {
+	resp := &youtube.VideoListResponse{
+		Kind:  "youtube#videoListResponse",
+		Items: make([]*youtube.Video, 0),
+	}
+
+	for _, id := range queryIds(r, "id") {
+		if v := m.video(id, time.Now()); v != nil {
+			resp.Items = append(resp.Items, v)
+		}
+	}
+
+	writeJSON(w, resp)
+}
+
+func (m *MockServer) serveSearch(w http.ResponseWriter, r *http.Request) {
+	channelId := r.URL.Query().Get("channelId")
+
+	resp := &youtube.SearchListResponse{
+		Kind:  "youtube#searchListResponse",
+		Items: make([]*youtube.SearchResult, 0),
+	}
+
+	if m.remember(channelId) {
+		if id := m.liveId(channelId, time.Now()); id != "" {
+			resp.Items = append(resp.Items, &youtube.SearchResult{
+				Kind: "youtube#searchResult",
+				Id: &youtube.ResourceId{
+					Kind:    "youtube#video",
+					VideoId: id,
+				},
+			})
+		}
+	}
+
+	writeJSON(w, resp)
+}
+
+// serveBrowse answers the innertube streams tab with the current stream.
+func (m *MockServer) serveBrowse(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		BrowseId string `json:"browseId"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !m.remember(req.BrowseId) {
+		http.Error(w, "invalid browse request", http.StatusBadRequest)
+		return
+	}
+
+	contents := make([]any, 0)
+
+	if id := m.liveId(req.BrowseId, time.Now()); id != "" {
+		contents = append(contents, map[string]any{
+			"richItemRenderer": map[string]any{
+				"content": map[string]any{
+					"videoRenderer": map[string]any{
+						"videoId": id,
+						"badges": []any{
+							map[string]any{
+								"metadataBadgeRenderer": map[string]any{
+									"style": "BADGE_STYLE_TYPE_LIVE_NOW",
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	writeJSON(w, map[string]any{
+		"contents": map[string]any{
+			"twoColumnBrowseResultsRenderer": map[string]any{
+				"tabs": []any{
+					map[string]any{
+						"tabRenderer": map[string]any{
+							"content": map[string]any{
+								"richGridRenderer": map[string]any{
+									"contents": contents,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+// serveChannelPage answers the live page with a canonical link to the current
+// stream, and the other tabs with an empty ytInitialData.
+func (m *MockServer) serveChannelPage(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/channel/"), "/"), "/")
+
+	if !m.remember(parts[0]) {
+		http.NotFound(w, r)
+		return
+	}
+
+	canonical := fmt.Sprintf("https://www.youtube.com/channel/%s", parts[0])
+
+	if id := m.liveId(parts[0], time.Now()); id != "" && len(parts) > 1 && parts[1] == "live" {
+		if contains(m.AgeRestricted, parts[0]) {
+			w.Header().Set("content-type", "text/html; charset=utf-8")
+
+			fmt.Fprintf(w, `<!DOCTYPE html><html><head><link rel="canonical" href="%s"></head><body><script>var ytInitialPlayerResponse = {"playabilityStatus":{"status":"LOGIN_REQUIRED","reason":"Sign in to confirm your age"}};</script></body></html>`, canonical)
+			return
+		}
+
+		canonical = fmt.Sprintf("https://www.youtube.com/watch?v=%s", id)
+	}
+
+	w.Header().Set("content-type", "text/html; charset=utf-8")
+
+	fmt.Fprintf(w, `<!DOCTYPE html><html><head><link rel="canonical" href="%s"></head><body><script>var ytInitialData = {"contents":{}};</script></body></html>`, canonical)
+}
+
+// serveEmbedLive answers the embedded player of the live stream of a channel,
+// with a canonical link to the stream.
+func (m *MockServer) serveEmbedLive(w http.ResponseWriter, r *http.Request) {
+	channelId := r.URL.Query().Get("channel")
+
+	if !m.remember(channelId) {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("content-type", "text/html; charset=utf-8")
+
+	if id := m.liveId(channelId, time.Now()); id != "" {
+		fmt.Fprintf(w, `<!DOCTYPE html><html><head><link rel="canonical" href="https://www.youtube.com/watch?v=%s"></head><body></body></html>`, id)
+		return
+	}
+
+	fmt.Fprint(w, `<!DOCTYPE html><html><head></head><body></body></html>`)
+}
+
+// serveWatchPage answers the watch page of a video, showing the game played
+// when live.
+func (m *MockServer) serveWatchPage(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	v := m.video(r.URL.Query().Get("v"), now)
+
+	if v == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	contents := map[string]any{}
+
+	if v.Snippet.LiveBroadcastContent == "live" {
+		game := mockGames[int(now.Sub(m.startedAt)/time.Minute)%len(mockGames)]
+
+		contents["richMetadataRenderer"] = map[string]any{
+			"style": "RICH_METADATA_RENDERER_STYLE_BOX_ART",
+			"title": map[string]any{"simpleText": game},
+		}
+	}
+
+	if v.Snippet.LiveBroadcastContent == "live" {
+		contents["videoPrimaryInfoRenderer"] = map[string]any{
+			"viewCount": map[string]any{
+				"videoViewCountRenderer": map[string]any{
+					"viewCount": map[string]any{"runs": []map[string]any{{"text": "1,337"}, {"text": " watching now"}}},
+					"isLive":    true,
+				},
+			},
+		}
+	}
+
+	data, _ := json.Marshal(map[string]any{"contents": contents})
+
+	player, _ := json.Marshal(map[string]any{
+		"endscreen": map[string]any{
+			"endscreenRenderer": map[string]any{
+				"elements": []map[string]any{
+					{
+						"endscreenElementRenderer": map[string]any{
+							"style":    "VIDEO",
+							"title":    map[string]any{"simpleText": "Mock upload #12"},
+							"startMs":  "5000",
+							"endMs":    "20000",
+							"endpoint": map[string]any{"watchEndpoint": map[string]any{"videoId": mockId(v.Snippet.ChannelId, 0)}},
+						},
+					},
+					{
+						"endscreenElementRenderer": map[string]any{
+							"style":    "SUBSCRIBE",
+							"title":    map[string]any{"simpleText": v.Snippet.ChannelTitle},
+							"startMs":  "5000",
+							"endMs":    "20000",
+							"endpoint": map[string]any{"browseEndpoint": map[string]any{"browseId": v.Snippet.ChannelId}},
+						},
+					},
+				},
+			},
+		},
+	})
+
+	w.Header().Set("content-type", "text/html; charset=utf-8")
+
+	fmt.Fprintf(w, `<!DOCTYPE html><html><body><script>var ytInitialPlayerResponse = %s;var meta = document.querySelector('meta');</script><script>var ytInitialData = %s;</script></body></html>`, player, data)
+}
+
+// serveSkipSegments answers the SponsorBlock segments of the uploads, the
+// live streams having none.
+func (m *MockServer) serveSkipSegments(w http.ResponseWriter, r *http.Request) {
+	v := m.video(r.URL.Query().Get("videoID"), time.Now())
+
+	if v == nil || v.Snippet.LiveBroadcastContent == "live" {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	duration := float64(onyt.ParseDuration(v.ContentDetails.Duration))
+
+	segments := []map[string]any{
+		{"category": "intro", "actionType": "skip", "segment": []float64{0, 5.5}, "UUID": v.Id + "-intro"},
+		{"category": "sponsor", "actionType": "skip", "segment": []float64{30, 62.25}, "UUID": v.Id + "-sponsor"},
+		{"category": "outro", "actionType": "skip", "segment": []float64{duration - 20, duration}, "UUID": v.Id + "-outro"},
+	}
+
+	categories := []string{"sponsor"}
+	json.Unmarshal([]byte(r.URL.Query().Get("categories")), &categories)
+
+	result := make([]map[string]any, 0, len(segments))
+
+	for _, segment := range segments {
+		if contains(categories, segment["category"].(string)) {
+			result = append(result, segment)
+		}
+	}
+
+	writeJSON(w, result)
+}
+
+// serveVotes answers the votes of a video estimated by Return YouTube
+// Dislike.
+func (m *MockServer) serveVotes(w http.ResponseWriter, r *http.Request) {
+	v := m.video(r.URL.Query().Get("videoId"), time.Now())
+
+	if v == nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	dislikes := v.Statistics.LikeCount / 10
+
+	writeJSON(w, map[string]any{
+		"id":        v.Id,
+		"likes":     v.Statistics.LikeCount,
+		"dislikes":  dislikes,
+		"viewCount": v.Statistics.ViewCount,
+		"rating":    1 + 4*float64(v.Statistics.LikeCount)/float64(v.Statistics.LikeCount+dislikes+1),
+	})
+}
+
+// serveOEmbed answers the oEmbed of the mock videos, the others being
+// unknown.
+func (m *MockServer) serveOEmbed(w http.ResponseWriter, r *http.Request) {
+	u, err := url.Parse(r.URL.Query().Get("url"))
+
+	if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	v := m.video(u.Query().Get("v"), time.Now())
+
+	if v == nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"type":        "video",
+		"title":       v.Snippet.Title,
+		"author_name": v.Snippet.ChannelTitle,
+	})
+}
+
+// serveHandle answers the page of a handle with a canonical link to a channel
+// derived from it.
+func (m *MockServer) serveHandle(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/@") {
+		http.NotFound(w, r)
+		return
+	}
+
+	sum := sha1.Sum([]byte(strings.ToLower(r.URL.Path)))
+	channelId := "UC" + base64.RawURLEncoding.EncodeToString(sum[:])[:22]
+
+	w.Header().Set("content-type", "text/html; charset=utf-8")
+
+	fmt.Fprintf(w, `<!DOCTYPE html><html><head><link rel="canonical" href="https://www.youtube.com/channel/%s"></head></html>`, channelId)
+}
+
+func (m *MockServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/youtube/v3/channels", m.serveChannels)
+	mux.HandleFunc("/youtube/v3/channelSections", m.serveChannelSections)
+	mux.HandleFunc("/youtube/v3/playlists", m.servePlaylists)
+	mux.HandleFunc("/youtube/v3/playlistItems", m.servePlaylistItems)
+	mux.HandleFunc("/youtube/v3/videos", m.serveVideos)
+	mux.HandleFunc("/youtube/v3/search", m.serveSearch)
+	mux.HandleFunc("/youtubei/v1/browse", m.serveBrowse)
+	mux.HandleFunc("/channel/", m.serveChannelPage)
+	mux.HandleFunc("/watch", m.serveWatchPage)
+	mux.HandleFunc("/embed/live_stream", m.serveEmbedLive)
+	mux.HandleFunc("/api/skipSegments", m.serveSkipSegments)
+	mux.HandleFunc("/votes", m.serveVotes)
+	mux.HandleFunc("/oembed", m.serveOEmbed)
+	mux.HandleFunc("/", m.serveHandle)
+
+	mux.HandleFunc("/youtube/v3/", func(w http.ResponseWriter, r *http.Request) {
+		writeMockError(w, http.StatusNotFound, "notFound")
+	})
+
+	return mux
+}
+
+var mockServerCommand = &cli.Command{
+	Name:  "mockserver",
+	Usage: "Serve canned channels, videos and live pages to develop and test offline, pointed at with --youtube-url",
+	Flags: []cli.Flag{
+		&cli.IntFlag{
+			Name:    "port",
+			Aliases: []string{"p"},
+			Usage:   "The mock server port to use",
+			Value:   3001,
+		},
+		&cli.StringSliceFlag{
+			Name:  "live",
+			Usage: "The channel IDs always live, or * for every channel",
+		},
+		&cli.DurationFlag{
+			Name:  "live-cycle",
+			Usage: "The duration after which the other channels go live or offline, disabled when zero",
+			Value: 10 * time.Minute,
+		},
+		&cli.StringSliceFlag{
+			Name:  "age-restricted",
+			Usage: "The channel IDs whose live page is age-gated, their streams being found from the embedded player",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		mock := NewMockServer(ctx.StringSlice("live"), ctx.Duration("live-cycle"))
+		mock.AgeRestricted = ctx.StringSlice("age-restricted")
+		addr := fmt.Sprintf(":%d", ctx.Int("port"))
+
+		log.Info().Str("addr", addr).Msg("Serving mock YouTube")
+
+		return http.ListenAndServe(addr, mock.Handler())
+	},
+}
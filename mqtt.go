@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"text/template"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+type mqttNotifier struct {
+	URL      string `yaml:"url"`
+	ClientId string `yaml:"clientId"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Topic    string `yaml:"topic"`
+	QoS      byte   `yaml:"qos"`
+	Retain   bool   `yaml:"retain"`
+
+	client mqtt.Client
+	topic  *template.Template
+}
+
+func newMQTTNotifier(config *NotifierConfig) (Notifier, error) {
+	n := &mqttNotifier{
+		ClientId: "onyt",
+		Topic:    "onyt/{{.ChannelId}}/{{.Type}}",
+	}
+
+	if err := config.Decode(n); err != nil {
+		return nil, err
+	}
+
+	if n.URL == "" {
+		return nil, errors.New("missing broker url")
+	}
+
+	topic, err := template.New("topic").Parse(n.Topic)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options := mqtt.NewClientOptions().
+		AddBroker(n.URL).
+		SetClientID(n.ClientId).
+		SetUsername(n.Username).
+		SetPassword(n.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true)
+
+	n.client = mqtt.NewClient(options)
+	n.client.Connect()
+
+	n.topic = topic
+
+	return n, nil
+}
+
+func (m *mqttNotifier) Notify(ctx context.Context, n *Notification) error {
+	var topic strings.Builder
+
+	if err := m.topic.Execute(&topic, n); err != nil {
+		return err
+	}
+
+	payload := []byte(n.Message)
+
+	if n.Message == "" {
+		data, err := json.Marshal(n.Event)
+
+		if err != nil {
+			return err
+		}
+
+		payload = data
+	}
+
+	token := m.client.Publish(topic.String(), m.QoS, m.Retain, payload)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-token.Done():
+		return token.Error()
+	}
+}
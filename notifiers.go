@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+	"gopkg.in/yaml.v3"
+)
+
+type Notification struct {
+	onyt.Event
+
+	State   *onyt.State `json:"-"`
+	Message string      `json:"-"`
+}
+
+// Notifier delivers events to an external service.
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+type NotifierRoute struct {
+	Events   []string `yaml:"events"`
+	Channels []string `yaml:"channels"`
+	Groups   []string `yaml:"groups"`
+	Template string   `yaml:"template"`
+}
+
+type NotifierConfig struct {
+	Name     string          `yaml:"name"`
+	Type     string          `yaml:"type"`
+	Template string          `yaml:"template"`
+	Routes   []NotifierRoute `yaml:"routes"`
+
+	node yaml.Node
+}
+
+func (c *NotifierConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain NotifierConfig
+
+	if err := node.Decode((*plain)(c)); err != nil {
+		return err
+	}
+
+	c.node = *node
+
+	return nil
+}
+
+// Decode decodes the type-specific settings of the notifier.
+func (c *NotifierConfig) Decode(v any) error {
+	return c.node.Decode(v)
+}
+
+// notifierClient sends the webhooks of the notifiers, bounded in case the
+// attempts aren't.
+var notifierClient = &http.Client{
+	Timeout: time.Minute,
+}
+
+var notifierTypes = map[string]func(config *NotifierConfig) (Notifier, error){
+	"discord": newDiscordNotifier,
+	"http":    newHTTPNotifier,
+	"matrix":  newMatrixNotifier,
+	"mqtt":    newMQTTNotifier,
+	"slack":   newSlackNotifier,
+	"smtp":    newSMTPNotifier,
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+type notifierRoute struct {
+	events []string
+
+	// channels are those of the route and of its groups, every channel
+	// matching when nil.
+	channels []string
+	template *template.Template
+}
+
+func (r *notifierRoute) match(evt onyt.Event) bool {
+	if len(r.events) > 0 && !contains(r.events, evt.Type) && !contains(r.events, "*") {
+		return false
+	}
+
+	return r.channels == nil || contains(r.channels, evt.ChannelId)
+}
+
+type routedNotifier struct {
+	name     string
+	notifier Notifier
+	template *template.Template
+	routes   []*notifierRoute
+}
+
+// route returns whether the notifier receives the event, and the template
+// rendering its message.
+func (n *routedNotifier) route(evt onyt.Event) (*template.Template, bool) {
+	if len(n.routes) == 0 {
+		return n.template, true
+	}
+
+	for _, route := range n.routes {
+		if route.match(evt) {
+			if route.template != nil {
+				return route.template, true
+			}
+
+			return n.template, true
+		}
+	}
+
+	return nil, false
+}
+
+// NotifierRouter dispatches the emitted events to the notifiers whose routes
+// match them.
+type NotifierRouter struct {
+	notifiers []*routedNotifier
+	states    func(channelId string) *onyt.State
+
+	// DryRun logs the notifications instead of sending them.
+	DryRun bool
+
+	// Queue delivers the notifications at least once when set, instead of
+	// sending them once.
+	Queue *DeliveryQueue
+
+	// Channels restricts the events of a channel to the named notifiers.
+	Channels map[string][]string
+}
+
+func NewNotifierRouter(configs []*NotifierConfig, groups map[string][]string, states func(channelId string) *onyt.State) (*NotifierRouter, error) {
+	router := &NotifierRouter{
+		states: states,
+	}
+
+	for i, config := range configs {
+		if config.Name == "" {
+			config.Name = fmt.Sprintf("%s-%d", config.Type, i+1)
+		}
+
+		factory, ok := notifierTypes[config.Type]
+
+		if !ok {
+			return nil, fmt.Errorf("unknown notifier type: %s", config.Type)
+		}
+
+		notifier, err := factory(config)
+
+		if err != nil {
+			return nil, fmt.Errorf("notifier %s: %w", config.Name, err)
+		}
+
+		routed := &routedNotifier{
+			name:     config.Name,
+			notifier: notifier,
+		}
+
+		if routed.template, err = parseTemplate(config.Name, config.Template); err != nil {
+			return nil, fmt.Errorf("notifier %s: %w", config.Name, err)
+		}
+
+		for j, r := range config.Routes {
+			route := &notifierRoute{
+				events: r.Events,
+			}
+
+			if len(r.Channels) > 0 || len(r.Groups) > 0 {
+				route.channels = append(make([]string, 0), r.Channels...)
+			}
+
+			for _, name := range r.Groups {
+				channelIds, ok := groups[name]
+
+				if !ok {
+					return nil, fmt.Errorf("notifier %s: unknown group: %s", config.Name, name)
+				}
+
+				route.channels = append(route.channels, channelIds...)
+			}
+
+			if route.template, err = parseTemplate(fmt.Sprintf("%s-route-%d", config.Name, j+1), r.Template); err != nil {
+				return nil, fmt.Errorf("notifier %s: %w", config.Name, err)
+			}
+
+			routed.routes = append(routed.routes, route)
+		}
+
+		router.notifiers = append(router.notifiers, routed)
+	}
+
+	return router, nil
+}
+
+// Restrict sends the events of the channel to the named notifiers only.
+func (r *NotifierRouter) Restrict(channelId string, names []string) error {
+	for _, name := range names {
+		known := false
+
+		for _, n := range r.notifiers {
+			if n.name == name {
+				known = true
+			}
+		}
+
+		if !known {
+			return fmt.Errorf("unknown notifier: %s", name)
+		}
+	}
+
+	if r.Channels == nil {
+		r.Channels = make(map[string][]string)
+	}
+
+	r.Channels[channelId] = names
+
+	return nil
+}
+
+func (r *NotifierRouter) dispatch(ctx context.Context, evt onyt.Event) {
+	names, restricted := r.Channels[evt.ChannelId]
+
+	for _, n := range r.notifiers {
+		if restricted && !contains(names, n.name) {
+			continue
+		}
+
+		tmpl, ok := n.route(evt)
+
+		if !ok {
+			continue
+		}
+
+		notification := &Notification{
+			Event: evt,
+			State: r.states(evt.ChannelId),
+		}
+
+		if tmpl != nil {
+			var message strings.Builder
+
+			if err := tmpl.Execute(&message, notification); err != nil {
+				log.Err(err).Str("notifier", n.name).Msg("Unable to render notification")
+				continue
+			}
+
+			notification.Message = message.String()
+		}
+
+		if r.DryRun {
+			log.Info().Str("notifier", n.name).Str("type", evt.Type).Str("channel", evt.ChannelId).Str("message", notification.Message).Msg("Skipping notification in dry run")
+			continue
+		}
+
+		if r.Queue != nil {
+			r.Queue.Enqueue(n.name, notification)
+			continue
+		}
+
+		go func(n *routedNotifier) {
+			if err := n.notifier.Notify(ctx, notification); err != nil {
+				log.Err(err).Str("notifier", n.name).Str("type", evt.Type).Msg("Unable to send notification")
+			}
+		}(n)
+	}
+}
+
+// send sends the queued delivery to its notifier.
+func (r *NotifierRouter) send(ctx context.Context, d *Delivery) error {
+	for _, n := range r.notifiers {
+		if n.name == d.Notifier {
+			return n.notifier.Notify(ctx, &Notification{
+				Event:   d.Event,
+				State:   r.states(d.Event.ChannelId),
+				Message: d.Message,
+			})
+		}
+	}
+
+	return fmt.Errorf("unknown notifier: %s", d.Notifier)
+}
+
+// Start dispatches the events emitted on the bus until the context is done.
+func (r *NotifierRouter) Start(ctx context.Context, events *onyt.EventBus) {
+	if r.Queue != nil {
+		go r.Queue.Run(ctx, r.send)
+	}
+
+	unsubscribe := events.Subscribe(func(evt onyt.Event) {
+		r.dispatch(ctx, evt)
+	})
+
+	go func() {
+		<-ctx.Done()
+
+		unsubscribe()
+	}()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func channelTitle(n *Notification) string {
+	if n.State != nil && n.State.Channel != nil && n.State.Channel.Snippet != nil {
+		return n.State.Channel.Snippet.Title
+	}
+
+	return n.ChannelId
+}
+
+// defaultMessage describes the notification when no template is configured.
+func defaultMessage(n *Notification) string {
+	if n.Message != "" {
+		return n.Message
+	}
+
+	if d, ok := n.Data.(*Digest); ok {
+		return d.Summary()
+	}
+
+	if v, ok := n.Data.(*onyt.Video); ok && v.Snippet != nil {
+		url := fmt.Sprintf("https://www.youtube.com/watch?v=%s", v.Id)
+
+		switch n.Type {
+		case "live_started":
+			return fmt.Sprintf("%s is live: %s %s", channelTitle(n), v.Snippet.Title, url)
+
+		case "video_uploaded":
+			return fmt.Sprintf("%s uploaded a new video: %s %s", channelTitle(n), v.Snippet.Title, url)
+		}
+	}
+
+	if item, ok := n.Data.(*onyt.PlaylistItem); ok && item.Video != nil && item.Video.Snippet != nil {
+		url := fmt.Sprintf("https://www.youtube.com/watch?v=%s&list=%s", item.Video.Id, item.PlaylistId)
+
+		return fmt.Sprintf("%s was added to %s: %s", item.Video.Snippet.Title, item.PlaylistTitle, url)
+	}
+
+	return fmt.Sprintf("%s: %s", channelTitle(n), n.Type)
+}
+
+func postJSON(ctx context.Context, url string, v any) error {
+	return sendJSON(ctx, http.MethodPost, url, nil, v)
+}
+
+func sendJSON(ctx context.Context, method, url string, header http.Header, v any) error {
+	body, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	req.Header.Set("content-type", "application/json")
+
+	resp, err := notifierClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+	"google.golang.org/api/youtube/v3"
+)
+
+const (
+	oembedWidth  = 640
+	oembedHeight = 360
+)
+
+type OEmbed struct {
+	Version         string `json:"version"`
+	Type            string `json:"type"`
+	Title           string `json:"title,omitempty"`
+	AuthorName      string `json:"author_name,omitempty"`
+	AuthorURL       string `json:"author_url,omitempty"`
+	ProviderName    string `json:"provider_name"`
+	ProviderURL     string `json:"provider_url"`
+	CacheAge        int    `json:"cache_age"`
+	ThumbnailURL    string `json:"thumbnail_url,omitempty"`
+	ThumbnailWidth  int64  `json:"thumbnail_width,omitempty"`
+	ThumbnailHeight int64  `json:"thumbnail_height,omitempty"`
+	HTML            string `json:"html"`
+	Width           int    `json:"width"`
+	Height          int    `json:"height"`
+}
+
+func bestThumbnailSize(details *youtube.ThumbnailDetails) *youtube.Thumbnail {
+	if details == nil {
+		return nil
+	}
+
+	for _, thumbnail := range []*youtube.Thumbnail{details.Maxres, details.Standard, details.High, details.Medium, details.Default} {
+		if thumbnail != nil {
+			return thumbnail
+		}
+	}
+
+	return nil
+}
+
+// oembedSize fits the default player size within the requested bounds,
+// keeping its aspect ratio.
+func oembedSize(query url.Values) (int, int) {
+	width, height := oembedWidth, oembedHeight
+
+	if v, err := strconv.Atoi(query.Get("maxwidth")); err == nil && v > 0 && v < width {
+		width, height = v, v*oembedHeight/oembedWidth
+	}
+
+	if v, err := strconv.Atoi(query.Get("maxheight")); err == nil && v > 0 && v < height {
+		width, height = v*oembedWidth/oembedHeight, v
+	}
+
+	return width, height
+}
+
+func serveOEmbed(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	query := r.URL.Query()
+
+	if format := query.Get("format"); format != "" && format != "json" {
+		http.Error(w, "unsupported format", http.StatusNotImplemented)
+		return
+	}
+
+	state := poller.Store.Get()
+	video := state.LiveVideo
+
+	if video == nil && len(state.Videos) > 0 {
+		video = state.Videos[0]
+	}
+
+	if video == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	width, height := oembedSize(query)
+
+	doc := &OEmbed{
+		Version:      "1.0",
+		Type:         "video",
+		ProviderName: "YouTube",
+		ProviderURL:  "https://www.youtube.com/",
+		CacheAge:     60,
+		Width:        width,
+		Height:       height,
+	}
+
+	if video.Snippet != nil {
+		doc.Title = video.Snippet.Title
+		doc.AuthorName = video.Snippet.ChannelTitle
+		doc.AuthorURL = "https://www.youtube.com/channel/" + video.Snippet.ChannelId
+
+		if thumbnail := bestThumbnailSize(video.Snippet.Thumbnails); thumbnail != nil {
+			doc.ThumbnailURL = thumbnail.Url
+			doc.ThumbnailWidth = thumbnail.Width
+			doc.ThumbnailHeight = thumbnail.Height
+		}
+	}
+
+	doc.HTML = fmt.Sprintf(`<iframe width="%d" height="%d" src="https://www.youtube.com/embed/%s" title="%s" frameborder="0" allow="accelerometer; autoplay; clipboard-write; encrypted-media; gyroscope; picture-in-picture" allowfullscreen></iframe>`,
+		width, height, url.PathEscape(video.Id), html.EscapeString(doc.Title))
+
+	w.Header().Set("cache-control", "max-age=60")
+	w.Header().Set("access-control-allow-origin", "*")
+
+	writeJSON(w, doc)
+}
@@ -0,0 +1,95 @@
+package onyt
+
+import (
+	"sort"
+	"time"
+)
+
+type MonthlyUploads struct {
+	Month   string `json:"month"`
+	Uploads int    `json:"uploads"`
+}
+
+// UploadAnalytics describes the upload cadence of a channel.
+type UploadAnalytics struct {
+	Uploads             int               `json:"uploads"`
+	FirstUploadAt       *time.Time        `json:"firstUploadAt"`
+	LastUploadAt        *time.Time        `json:"lastUploadAt"`
+	AverageDaysBetween  float64           `json:"averageDaysBetween"`
+	DaysSinceLastUpload float64           `json:"daysSinceLastUpload"`
+	StreakWeeks         int               `json:"streakWeeks"`
+	Months              []*MonthlyUploads `json:"months"`
+}
+
+// startOfWeek returns the Monday midnight starting the week of the time.
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// AnalyzeUploads computes the cadence of the uploads published at the given
+// times. The streak counts the consecutive weeks with an upload, the current
+// one included when it already has one.
+func AnalyzeUploads(times []time.Time, now time.Time, loc *time.Location, months int) *UploadAnalytics {
+	analytics := &UploadAnalytics{
+		Uploads: len(times),
+		Months:  make([]*MonthlyUploads, months),
+	}
+
+	local := now.In(loc)
+
+	for i := range analytics.Months {
+		analytics.Months[i] = &MonthlyUploads{
+			Month: time.Date(local.Year(), local.Month()-time.Month(months-1-i), 1, 0, 0, 0, 0, loc).Format("2006-01"),
+		}
+	}
+
+	if len(times) == 0 {
+		return analytics
+	}
+
+	sorted := make([]time.Time, len(times))
+	copy(sorted, times)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Before(sorted[j])
+	})
+
+	first, last := sorted[0], sorted[len(sorted)-1]
+
+	analytics.FirstUploadAt = &first
+	analytics.LastUploadAt = &last
+	analytics.DaysSinceLastUpload = now.Sub(last).Hours() / 24
+
+	if len(sorted) > 1 {
+		analytics.AverageDaysBetween = last.Sub(first).Hours() / 24 / float64(len(sorted)-1)
+	}
+
+	weeks := make(map[time.Time]bool)
+	counts := make(map[string]int)
+
+	for _, t := range sorted {
+		t = t.In(loc)
+
+		weeks[startOfWeek(t)] = true
+		counts[t.Format("2006-01")]++
+	}
+
+	for _, month := range analytics.Months {
+		month.Uploads = counts[month.Month]
+	}
+
+	week := startOfWeek(local)
+
+	if !weeks[week] {
+		week = week.AddDate(0, 0, -7)
+	}
+
+	for weeks[week] {
+		analytics.StreakWeeks++
+		week = week.AddDate(0, 0, -7)
+	}
+
+	return analytics
+}
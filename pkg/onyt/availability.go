@@ -0,0 +1,123 @@
+package onyt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// The availabilities of an archived video, the privacy statuses of the API
+// along with the removed videos.
+const (
+	AvailabilityPublic   = "public"
+	AvailabilityUnlisted = "unlisted"
+	AvailabilityPrivate  = "private"
+	AvailabilityRemoved  = "removed"
+)
+
+// Availability is whether a video can still be watched, with its current
+// title when it can.
+type Availability struct {
+	Status string `json:"status"`
+	Title  string `json:"title,omitempty"`
+}
+
+// FetchAvailabilities returns the availability of the videos, at 1 quota unit
+// per 50 videos. The API leaves out both the private and the removed videos,
+// which are told apart with the oEmbed endpoint.
+func FetchAvailabilities(ctx context.Context, service *youtube.Service, quota *QuotaMeter, videoIds []string) (map[string]*Availability, error) {
+	availabilities := make(map[string]*Availability, len(videoIds))
+
+	for start := 0; start < len(videoIds); start += 50 {
+		end := start + 50
+
+		if end > len(videoIds) {
+			end = len(videoIds)
+		}
+
+		quota.Add(1)
+
+		resp, err := service.Videos.List([]string{"snippet", "status"}).
+			Id(videoIds[start:end]...).
+			Context(ctx).
+			Do()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range resp.Items {
+			availability := &Availability{
+				Status: AvailabilityPublic,
+			}
+
+			if v.Status != nil && v.Status.PrivacyStatus != "" {
+				availability.Status = v.Status.PrivacyStatus
+			}
+
+			if v.Snippet != nil {
+				availability.Title = v.Snippet.Title
+			}
+
+			availabilities[v.Id] = availability
+		}
+	}
+
+	for _, videoId := range videoIds {
+		if _, ok := availabilities[videoId]; ok {
+			continue
+		}
+
+		status, err := probeOEmbed(ctx, videoId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		availabilities[videoId] = &Availability{
+			Status: status,
+		}
+	}
+
+	return availabilities, nil
+}
+
+// probeOEmbed tells whether a video left out by the API is private or
+// removed, as the oEmbed endpoint answers a 401 for the private videos.
+func probeOEmbed(ctx context.Context, videoId string) (string, error) {
+	query := url.Values{
+		"url":    {"https://www.youtube.com/watch?v=" + videoId},
+		"format": {"json"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.youtube.com/oembed?"+query.Encode(), nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return AvailabilityPrivate, nil
+
+	case http.StatusBadRequest, http.StatusNotFound:
+		return AvailabilityRemoved, nil
+
+	// The API may briefly lag behind a video made public again.
+	case http.StatusOK:
+		return AvailabilityPublic, nil
+	}
+
+	return "", fmt.Errorf("unexpected oembed status: %s", resp.Status)
+}
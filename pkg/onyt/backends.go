@@ -0,0 +1,157 @@
+package onyt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Backend provides channel, video and live data for a resource.
+type Backend interface {
+	Name() string
+	FetchChannel(ctx context.Context, channelId string) (*youtube.Channel, error)
+	FetchUploadIds(ctx context.Context, channel *youtube.Channel) ([]string, error)
+	FetchVideos(ctx context.Context, videoIds []string) ([]*youtube.Video, error)
+	DetectLive(ctx context.Context, channelId string) (*LiveStreams, error)
+}
+
+var (
+	ErrNoAPIKey        = errors.New("no YouTube API key has been provided")
+	ErrNoBackends      = errors.New("no backend configured")
+	ErrChannelNotFound = errors.New("channel not found")
+)
+
+func withFailover[T any](resource string, backends []Backend, fn func(b Backend) (T, error)) (T, error) {
+	var (
+		result T
+		err    error
+	)
+
+	if len(backends) == 0 {
+		return result, fmt.Errorf("%s: %w", resource, ErrNoBackends)
+	}
+
+	for i, b := range backends {
+		if result, err = fn(b); err == nil {
+			return result, nil
+		}
+
+		if i < len(backends)-1 {
+			log.Warn().Err(err).Str("resource", resource).Str("backend", b.Name()).Msg("Backend failed, trying next one")
+
+			DefaultMetrics.CountFallback(resource + "/" + backends[i+1].Name())
+		}
+	}
+
+	return result, err
+}
+
+// ResolveBackends returns the backends matching the given names, in order.
+func ResolveBackends(available map[string]Backend, names []string) ([]Backend, error) {
+	result := make([]Backend, 0, len(names))
+
+	for _, name := range names {
+		b, ok := available[name]
+
+		if !ok {
+			return nil, fmt.Errorf("unknown backend: %s", name)
+		}
+
+		result = append(result, b)
+	}
+
+	return result, nil
+}
+
+// Client fetches channel data from its backends, failing over to the next
+// backend of a resource when one returns an error.
+type Client struct {
+	Channel []Backend
+	Videos  []Backend
+	Live    []Backend
+}
+
+func (c *Client) FetchChannel(ctx context.Context, channelId string) (*youtube.Channel, error) {
+	return withFailover("channel", c.Channel, func(b Backend) (*youtube.Channel, error) {
+		return b.FetchChannel(ctx, channelId)
+	})
+}
+
+func (c *Client) FetchUploadIds(ctx context.Context, channel *youtube.Channel) ([]string, error) {
+	return withFailover("videos", c.Videos, func(b Backend) ([]string, error) {
+		return b.FetchUploadIds(ctx, channel)
+	})
+}
+
+func (c *Client) FetchVideos(ctx context.Context, videoIds []string) ([]*youtube.Video, error) {
+	return withFailover("videos", c.Videos, func(b Backend) ([]*youtube.Video, error) {
+		return b.FetchVideos(ctx, videoIds)
+	})
+}
+
+func (c *Client) DetectLive(ctx context.Context, channelId string) (*LiveStreams, error) {
+	return withFailover("live", c.Live, func(b Backend) (*LiveStreams, error) {
+		return b.DetectLive(ctx, channelId)
+	})
+}
+
+func getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %w", url, &StatusError{"response", resp.StatusCode, resp.Status})
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func uploadsPlaylistId(channelId string) string {
+	if strings.HasPrefix(channelId, "UC") {
+		return "UU" + channelId[2:]
+	}
+
+	return ""
+}
+
+func formatUnix(seconds int64) string {
+	if seconds <= 0 {
+		return ""
+	}
+
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
+
+func uniqueStrings(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0)
+
+	for _, list := range lists {
+		for _, v := range list {
+			if !seen[v] {
+				seen[v] = true
+				result = append(result, v)
+			}
+		}
+	}
+
+	return result
+}
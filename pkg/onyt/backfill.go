@@ -0,0 +1,56 @@
+package onyt
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// FetchUploads pages through the whole uploads playlist of the channel,
+// calling fn with each page of videos. Only their snippet is filled.
+func FetchUploads(ctx context.Context, src *youtube.Service, quota *QuotaMeter, channelId string, fn func(videos []*Video) error) error {
+	call := src.PlaylistItems.List([]string{"contentDetails", "snippet"}).
+		PlaylistId("UU" + strings.TrimPrefix(channelId, "UC")).
+		MaxResults(50)
+
+	for {
+		quota.Add(1)
+
+		resp, err := call.Context(ctx).Do()
+
+		if err != nil {
+			return err
+		}
+
+		videos := make([]*Video, 0, len(resp.Items))
+
+		for _, item := range resp.Items {
+			if item.Snippet == nil || item.ContentDetails == nil {
+				continue
+			}
+
+			videos = append(videos, &Video{Video: &youtube.Video{
+				Id: item.ContentDetails.VideoId,
+				Snippet: &youtube.VideoSnippet{
+					ChannelId:    item.Snippet.ChannelId,
+					ChannelTitle: item.Snippet.ChannelTitle,
+					Title:        item.Snippet.Title,
+					Description:  item.Snippet.Description,
+					PublishedAt:  item.ContentDetails.VideoPublishedAt,
+					Thumbnails:   item.Snippet.Thumbnails,
+				},
+			}})
+		}
+
+		if err := fn(videos); err != nil {
+			return err
+		}
+
+		if resp.NextPageToken == "" {
+			return nil
+		}
+
+		call.PageToken(resp.NextPageToken)
+	}
+}
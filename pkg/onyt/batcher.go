@@ -0,0 +1,138 @@
+package onyt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// maxBatchIds is the maximum number of IDs accepted by videos.list.
+const maxBatchIds = 50
+
+type videoBatch struct {
+	ids    []string
+	seen   map[string]bool
+	done   chan struct{}
+	videos []*youtube.Video
+	err    error
+}
+
+func newVideoBatch() *videoBatch {
+	return &videoBatch{
+		seen: make(map[string]bool),
+		done: make(chan struct{}),
+	}
+}
+
+// VideoBatcher coalesces the videos.list calls of the channels refreshed
+// around the same time into shared calls of up to 50 IDs. A batch is sent
+// once full, or when the window following its first request elapses.
+type VideoBatcher struct {
+	Window time.Duration
+
+	fetch func(ctx context.Context, videoIds []string) ([]*youtube.Video, error)
+
+	mu      sync.Mutex
+	pending *videoBatch
+}
+
+func NewVideoBatcher(window time.Duration, fetch func(ctx context.Context, videoIds []string) ([]*youtube.Video, error)) *VideoBatcher {
+	return &VideoBatcher{
+		Window: window,
+		fetch:  fetch,
+	}
+}
+
+func (b *VideoBatcher) send(batch *videoBatch) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	batch.videos, batch.err = b.fetch(ctx, batch.ids)
+
+	close(batch.done)
+}
+
+// enqueue adds the IDs to the pending batches, returning the batches the
+// caller waits for.
+func (b *VideoBatcher) enqueue(videoIds []string) []*videoBatch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	batches := make([]*videoBatch, 0, 1)
+
+	for _, id := range videoIds {
+		if b.pending == nil {
+			batch := newVideoBatch()
+
+			time.AfterFunc(b.Window, func() {
+				b.mu.Lock()
+
+				if b.pending != batch {
+					b.mu.Unlock()
+					return
+				}
+
+				b.pending = nil
+				b.mu.Unlock()
+
+				b.send(batch)
+			})
+
+			b.pending = batch
+		}
+
+		batch := b.pending
+
+		if len(batches) == 0 || batches[len(batches)-1] != batch {
+			batches = append(batches, batch)
+		}
+
+		if batch.seen[id] {
+			continue
+		}
+
+		batch.seen[id] = true
+		batch.ids = append(batch.ids, id)
+
+		if len(batch.ids) == maxBatchIds {
+			b.pending = nil
+			go b.send(batch)
+		}
+	}
+
+	return batches
+}
+
+// Fetch returns the videos once the batches holding their IDs are sent.
+func (b *VideoBatcher) Fetch(ctx context.Context, videoIds []string) ([]*youtube.Video, error) {
+	wanted := make(map[string]bool, len(videoIds))
+
+	for _, id := range videoIds {
+		wanted[id] = true
+	}
+
+	videos := make([]*youtube.Video, 0, len(videoIds))
+
+	for _, batch := range b.enqueue(videoIds) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-batch.done:
+		}
+
+		if batch.err != nil {
+			return nil, batch.err
+		}
+
+		for _, v := range batch.videos {
+			if wanted[v.Id] {
+				videos = append(videos, v)
+				delete(wanted, v.Id)
+			}
+		}
+	}
+
+	return videos, nil
+}
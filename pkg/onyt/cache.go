@@ -0,0 +1,67 @@
+package onyt
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// TTLCache is an in-memory cache whose entries expire after their TTL, or
+// never when it is zero.
+type TTLCache[V any] struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry[V]
+}
+
+func NewTTLCache[V any]() *TTLCache[V] {
+	return &TTLCache[V]{
+		entries: make(map[string]*cacheEntry[V]),
+	}
+}
+
+func (c *TTLCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+
+		var zero V
+		return zero, false
+	}
+
+	return entry.value, true
+}
+
+func (c *TTLCache[V]) Set(key string, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry[V]{
+		value: value,
+	}
+
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	c.entries[key] = entry
+}
+
+func (c *TTLCache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
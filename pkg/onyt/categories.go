@@ -0,0 +1,171 @@
+package onyt
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/youtube/v3"
+)
+
+// categoryNames are the names of the video categories, which are the same in
+// every region.
+var categoryNames = map[string]string{
+	"1":  "Film & Animation",
+	"2":  "Autos & Vehicles",
+	"10": "Music",
+	"15": "Pets & Animals",
+	"17": "Sports",
+	"19": "Travel & Events",
+	"20": "Gaming",
+	"22": "People & Blogs",
+	"23": "Comedy",
+	"24": "Entertainment",
+	"25": "News & Politics",
+	"26": "Howto & Style",
+	"27": "Education",
+	"28": "Science & Technology",
+	"29": "Nonprofits & Activism",
+}
+
+// Category is the category of a video, along with the game played when
+// scraped from the watch page.
+type Category struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+	Game string `json:"game,omitempty"`
+}
+
+type CategoryChange struct {
+	VideoId string    `json:"videoId"`
+	From    *Category `json:"from"`
+	To      *Category `json:"to"`
+}
+
+func videoCategory(v *youtube.Video, game string) *Category {
+	if v.Snippet == nil || v.Snippet.CategoryId == "" {
+		return nil
+	}
+
+	return &Category{
+		Id:   v.Snippet.CategoryId,
+		Name: categoryNames[v.Snippet.CategoryId],
+		Game: game,
+	}
+}
+
+// videoTopics returns the names of the Wikipedia articles given as topics
+// of the video.
+func videoTopics(v *youtube.Video) []string {
+	if v.TopicDetails == nil {
+		return nil
+	}
+
+	topics := make([]string, 0, len(v.TopicDetails.TopicCategories))
+
+	for _, value := range v.TopicDetails.TopicCategories {
+		u, err := url.Parse(value)
+
+		if err != nil {
+			continue
+		}
+
+		if name, err := url.PathUnescape(path.Base(u.Path)); err == nil && name != "" {
+			topics = append(topics, strings.ReplaceAll(name, "_", " "))
+		}
+	}
+
+	return topics
+}
+
+func sameCategory(a, b *Category) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Id == b.Id && a.Game == b.Game
+}
+
+// scrapeGame returns the game shown under a video on its watch page, none
+// when the video isn't about a game.
+func scrapeGame(ctx context.Context, videoId string) (string, error) {
+	data, err := fetchInitialData(ctx, fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoId))
+
+	if err != nil {
+		return "", err
+	}
+
+	var game string
+
+	walkRenderers(data, "richMetadataRenderer", func(renderer map[string]any) {
+		if game == "" && renderer["style"] == "RICH_METADATA_RENDERER_STYLE_BOX_ART" {
+			game = textOf(renderer["title"])
+		}
+	})
+
+	return game, nil
+}
+
+type trackedGame struct {
+	name      string
+	scrapedAt time.Time
+}
+
+// GameTracker scrapes the game played on the live streams from their watch
+// page, at most once per interval for each stream.
+type GameTracker struct {
+	Locale Locale
+
+	interval time.Duration
+
+	mu    sync.Mutex
+	games map[string]*trackedGame
+}
+
+func NewGameTracker(interval time.Duration) *GameTracker {
+	return &GameTracker{
+		interval: interval,
+		games:    make(map[string]*trackedGame),
+	}
+}
+
+// Track sets the game of the live videos, forgetting the streams which
+// ended. The last known game is kept when a scrape fails.
+func (t *GameTracker) Track(ctx context.Context, videos []*Video) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	games := make(map[string]*trackedGame, len(videos))
+
+	for _, v := range videos {
+		game, ok := t.games[v.Id]
+
+		if !ok || time.Since(game.scrapedAt) >= t.interval {
+			name, err := scrapeGame(withLocale(ctx, t.Locale), v.Id)
+
+			if err != nil {
+				log.Warn().Err(err).Str("video", v.Id).Msg("Unable to scrape game")
+			}
+
+			if !ok {
+				game = new(trackedGame)
+			}
+
+			if err == nil {
+				game.name = name
+			}
+
+			game.scrapedAt = time.Now()
+		}
+
+		games[v.Id] = game
+		v.game = game.name
+	}
+
+	t.games = games
+}
@@ -0,0 +1,58 @@
+package onyt
+
+import (
+	"google.golang.org/api/youtube/v3"
+)
+
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type LiveUpdate struct {
+	VideoId string    `json:"videoId"`
+	Changes []*Change `json:"changes"`
+}
+
+func liveFields(v *youtube.Video) map[string]string {
+	fields := map[string]string{}
+
+	if v.Snippet != nil {
+		fields["title"] = v.Snippet.Title
+		fields["thumbnail"] = BestThumbnail(v.Snippet.Thumbnails)
+		fields["categoryId"] = v.Snippet.CategoryId
+	}
+
+	return fields
+}
+
+func diffLiveVideo(prev, next *youtube.Video) *LiveUpdate {
+	if prev == nil || next == nil || prev.Id != next.Id {
+		return nil
+	}
+
+	update := &LiveUpdate{
+		VideoId: next.Id,
+		Changes: make([]*Change, 0),
+	}
+
+	oldFields := liveFields(prev)
+	newFields := liveFields(next)
+
+	for _, field := range []string{"title", "thumbnail", "categoryId"} {
+		if oldFields[field] != newFields[field] {
+			update.Changes = append(update.Changes, &Change{
+				Field: field,
+				Old:   oldFields[field],
+				New:   newFields[field],
+			})
+		}
+	}
+
+	if len(update.Changes) == 0 {
+		return nil
+	}
+
+	return update
+}
@@ -0,0 +1,79 @@
+package onyt
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Chapter struct {
+	Title        string `json:"title"`
+	StartSeconds int64  `json:"startSeconds"`
+	EndSeconds   int64  `json:"endSeconds"`
+}
+
+var (
+	chapterStartRe = regexp.MustCompile(`^[\[(]?((?:\d{1,2}:)?\d{1,2}:\d{2})[\])]?\s*(?:[-–—:|.]\s*)?(.+)$`)
+	chapterEndRe   = regexp.MustCompile(`^(.+?)\s*(?:[-–—:|]\s*)?[\[(]?((?:\d{1,2}:)?\d{1,2}:\d{2})[\])]?$`)
+)
+
+func parseTimestamp(value string) int64 {
+	var seconds int64
+
+	for _, part := range strings.Split(value, ":") {
+		n, _ := strconv.ParseInt(part, 10, 64)
+		seconds = seconds*60 + n
+	}
+
+	return seconds
+}
+
+// ParseChapters extracts the timestamped chapters of a description following
+// the YouTube rules: at least three chapters in ascending order, the first one
+// starting at zero.
+func ParseChapters(description string, durationSeconds int64) []*Chapter {
+	chapters := make([]*Chapter, 0)
+
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+
+		var timestamp, title string
+
+		if sm := chapterStartRe.FindStringSubmatch(line); sm != nil {
+			timestamp, title = sm[1], sm[2]
+		} else if sm := chapterEndRe.FindStringSubmatch(line); sm != nil {
+			timestamp, title = sm[2], sm[1]
+		} else {
+			continue
+		}
+
+		start := parseTimestamp(timestamp)
+
+		if len(chapters) == 0 && start != 0 {
+			continue
+		}
+
+		if len(chapters) > 0 && start <= chapters[len(chapters)-1].StartSeconds {
+			break
+		}
+
+		chapters = append(chapters, &Chapter{
+			Title:        strings.TrimSpace(title),
+			StartSeconds: start,
+		})
+	}
+
+	if len(chapters) < 3 {
+		return make([]*Chapter, 0)
+	}
+
+	for i, chapter := range chapters {
+		if i+1 < len(chapters) {
+			chapter.EndSeconds = chapters[i+1].StartSeconds
+		} else {
+			chapter.EndSeconds = durationSeconds
+		}
+	}
+
+	return chapters
+}
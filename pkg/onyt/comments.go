@@ -0,0 +1,132 @@
+package onyt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/youtube/v3"
+)
+
+type Comment struct {
+	Id              string `json:"id"`
+	Author          string `json:"author"`
+	AuthorChannelId string `json:"authorChannelId"`
+	AuthorAvatarURL string `json:"authorAvatarUrl"`
+	Text            string `json:"text"`
+	LikeCount       int64  `json:"likeCount"`
+	ReplyCount      int64  `json:"replyCount"`
+	PublishedAt     int64  `json:"publishedAt"`
+}
+
+// CommentFetcher keeps the top comment threads of the latest upload and live
+// video, each fetch costing one quota unit.
+type CommentFetcher struct {
+	Service *youtube.Service
+	Count   int64
+	Quota   *QuotaMeter
+
+	mu       sync.RWMutex
+	comments map[string][]*Comment
+}
+
+func NewCommentFetcher(src *youtube.Service, count int64, quota *QuotaMeter) *CommentFetcher {
+	return &CommentFetcher{
+		Service:  src,
+		Count:    count,
+		Quota:    quota,
+		comments: make(map[string][]*Comment),
+	}
+}
+
+func (f *CommentFetcher) fetch(ctx context.Context, videoId string) ([]*Comment, error) {
+	f.Quota.Add(1)
+
+	resp, err := f.Service.CommentThreads.List([]string{"snippet"}).
+		VideoId(videoId).
+		Order("relevance").
+		TextFormat("plainText").
+		MaxResults(f.Count).
+		Context(ctx).
+		Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]*Comment, 0, len(resp.Items))
+
+	for _, thread := range resp.Items {
+		if thread.Snippet == nil || thread.Snippet.TopLevelComment == nil || thread.Snippet.TopLevelComment.Snippet == nil {
+			continue
+		}
+
+		snippet := thread.Snippet.TopLevelComment.Snippet
+
+		comment := &Comment{
+			Id:              thread.Id,
+			Author:          snippet.AuthorDisplayName,
+			AuthorAvatarURL: snippet.AuthorProfileImageUrl,
+			Text:            snippet.TextDisplay,
+			LikeCount:       snippet.LikeCount,
+			ReplyCount:      thread.Snippet.TotalReplyCount,
+		}
+
+		if snippet.AuthorChannelId != nil {
+			comment.AuthorChannelId = snippet.AuthorChannelId.Value
+		}
+
+		if t, err := time.Parse(time.RFC3339, snippet.PublishedAt); err == nil {
+			comment.PublishedAt = t.Unix()
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, nil
+}
+
+// Refresh replaces the kept comments with the ones of the given videos.
+func (f *CommentFetcher) Refresh(ctx context.Context, videoIds []string) {
+	if f.Service == nil {
+		return
+	}
+
+	comments := make(map[string][]*Comment, len(videoIds))
+
+	for _, videoId := range videoIds {
+		if !f.Quota.Allow(1) {
+			log.Warn().Str("video", videoId).Msg("Skipping comments fetch, quota limit reached")
+
+			if previous, ok := f.Get(videoId); ok {
+				comments[videoId] = previous
+			}
+
+			continue
+		}
+
+		result, err := f.fetch(ctx, videoId)
+
+		if err != nil {
+			log.Warn().Err(err).Str("video", videoId).Msg("Unable to fetch comments")
+			result = make([]*Comment, 0)
+		}
+
+		comments[videoId] = result
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.comments = comments
+}
+
+func (f *CommentFetcher) Get(videoId string) ([]*Comment, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	comments, ok := f.comments[videoId]
+
+	return comments, ok
+}
@@ -0,0 +1,278 @@
+package onyt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ytInitialDataRe = regexp.MustCompile(`(?s)var ytInitialData = (\{.*?\});\s*</script>`)
+
+	errNoInitialData = errors.New("page did not contain ytInitialData")
+)
+
+type CommunityPoll struct {
+	Choices    []string `json:"choices"`
+	TotalVotes string   `json:"totalVotes"`
+}
+
+type CommunityPost struct {
+	Id            string         `json:"id"`
+	URL           string         `json:"url"`
+	Text          string         `json:"text"`
+	PublishedText string         `json:"publishedText"`
+	LikeCount     string         `json:"likeCount"`
+	Images        []string       `json:"images"`
+	Poll          *CommunityPoll `json:"poll,omitempty"`
+	VideoId       string         `json:"videoId,omitempty"`
+}
+
+func fetchPage(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("accept-language", localeFrom(ctx).acceptLanguage())
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if consentRedirect(resp) {
+		return nil, errConsentRequired
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{"page", resp.StatusCode, resp.Status}
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func fetchInitialData(ctx context.Context, url string) (map[string]any, error) {
+	body, err := fetchPage(ctx, url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseInitialData(body)
+}
+
+func parseInitialData(body []byte) (map[string]any, error) {
+	sm := ytInitialDataRe.FindSubmatch(body)
+
+	if sm == nil {
+		return nil, errNoInitialData
+	}
+
+	var data map[string]any
+
+	if err := json.Unmarshal(sm[1], &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// jsonPath returns the value found by following the keys and indexes of a
+// decoded JSON document.
+func jsonPath(node any, keys ...any) any {
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			m, ok := node.(map[string]any)
+
+			if !ok {
+				return nil
+			}
+
+			node = m[k]
+
+		case int:
+			a, ok := node.([]any)
+
+			if !ok || k >= len(a) {
+				return nil
+			}
+
+			node = a[k]
+		}
+	}
+
+	return node
+}
+
+func textOf(node any) string {
+	if text, ok := jsonPath(node, "simpleText").(string); ok {
+		return text
+	}
+
+	runs, _ := jsonPath(node, "runs").([]any)
+
+	var sb strings.Builder
+
+	for _, run := range runs {
+		text, _ := jsonPath(run, "text").(string)
+		sb.WriteString(text)
+	}
+
+	return sb.String()
+}
+
+func lastThumbnail(node any) string {
+	thumbnails, _ := jsonPath(node, "thumbnails").([]any)
+
+	if len(thumbnails) == 0 {
+		return ""
+	}
+
+	url, _ := jsonPath(thumbnails[len(thumbnails)-1], "url").(string)
+
+	return url
+}
+
+func parseCommunityPost(renderer map[string]any) *CommunityPost {
+	id, _ := renderer["postId"].(string)
+
+	post := &CommunityPost{
+		Id:            id,
+		URL:           fmt.Sprintf("https://www.youtube.com/post/%s", id),
+		Text:          textOf(renderer["contentText"]),
+		PublishedText: textOf(renderer["publishedTimeText"]),
+		LikeCount:     textOf(renderer["voteCount"]),
+		Images:        make([]string, 0),
+	}
+
+	attachment := renderer["backstageAttachment"]
+
+	if image := lastThumbnail(jsonPath(attachment, "backstageImageRenderer", "image")); image != "" {
+		post.Images = append(post.Images, image)
+	}
+
+	images, _ := jsonPath(attachment, "postMultiImageRenderer", "images").([]any)
+
+	for _, image := range images {
+		if url := lastThumbnail(jsonPath(image, "backstageImageRenderer", "image")); url != "" {
+			post.Images = append(post.Images, url)
+		}
+	}
+
+	if poll, ok := jsonPath(attachment, "pollRenderer").(map[string]any); ok {
+		post.Poll = &CommunityPoll{
+			Choices:    make([]string, 0),
+			TotalVotes: textOf(poll["totalVotes"]),
+		}
+
+		choices, _ := poll["choices"].([]any)
+
+		for _, choice := range choices {
+			post.Poll.Choices = append(post.Poll.Choices, textOf(jsonPath(choice, "text")))
+		}
+	}
+
+	if videoId, ok := jsonPath(attachment, "videoRenderer", "videoId").(string); ok {
+		post.VideoId = videoId
+	}
+
+	return post
+}
+
+func FetchCommunityPosts(ctx context.Context, channelId string) ([]*CommunityPost, error) {
+	data, err := fetchInitialData(ctx, fmt.Sprintf("https://www.youtube.com/channel/%s/community", channelId))
+
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]*CommunityPost, 0)
+	found := make(map[string]bool)
+
+	walkRenderers(data, "backstagePostRenderer", func(renderer map[string]any) {
+		if post := parseCommunityPost(renderer); post.Id != "" && !found[post.Id] {
+			found[post.Id] = true
+			posts = append(posts, post)
+		}
+	})
+
+	return posts, nil
+}
+
+// CommunityTracker scrapes the Community tab of a channel at most once per
+// interval and reports the posts not seen before.
+type CommunityTracker struct {
+	Locale Locale
+
+	interval time.Duration
+
+	mu     sync.RWMutex
+	lastAt time.Time
+	posts  []*CommunityPost
+	seen   map[string]bool
+}
+
+func NewCommunityTracker(interval time.Duration) *CommunityTracker {
+	return &CommunityTracker{
+		interval: interval,
+		posts:    make([]*CommunityPost, 0),
+	}
+}
+
+// Track returns the new posts, none being reported on the first scrape.
+func (t *CommunityTracker) Track(ctx context.Context, channelId string) ([]*CommunityPost, error) {
+	t.mu.RLock()
+	due := time.Since(t.lastAt) >= t.interval
+	t.mu.RUnlock()
+
+	if !due {
+		return nil, nil
+	}
+
+	posts, err := FetchCommunityPosts(withLocale(ctx, t.Locale), channelId)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.lastAt = time.Now()
+
+	if err != nil {
+		return nil, err
+	}
+
+	fresh := make([]*CommunityPost, 0)
+	seen := make(map[string]bool, len(posts))
+
+	for _, post := range posts {
+		if t.seen != nil && !t.seen[post.Id] {
+			fresh = append(fresh, post)
+		}
+
+		seen[post.Id] = true
+	}
+
+	t.posts = posts
+	t.seen = seen
+
+	return fresh, nil
+}
+
+func (t *CommunityTracker) Posts() []*CommunityPost {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.posts
+}
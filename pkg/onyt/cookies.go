@@ -0,0 +1,134 @@
+package onyt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+var errConsentRequired = errors.New("redirected to the consent page")
+
+// The consent cookies skipping the cookie banner, SOCS being the one checked
+// in the EU and CONSENT the one of the older variants.
+var consentCookies = []*http.Cookie{
+	{
+		Name:   "SOCS",
+		Value:  "CAI",
+		Path:   "/",
+		Domain: ".youtube.com",
+		Secure: true,
+	},
+	{
+		Name:   "CONSENT",
+		Value:  "YES+42",
+		Path:   "/",
+		Domain: ".youtube.com",
+		Secure: true,
+	},
+}
+
+var youtubeURL = &url.URL{Scheme: "https", Host: "www.youtube.com", Path: "/"}
+
+func newCookieJar() *cookiejar.Jar {
+	jar, err := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	jar.SetCookies(youtubeURL, consentCookies)
+
+	return jar
+}
+
+// DefaultCookieJar holds the cookies sent by the scrapers.
+var DefaultCookieJar = newCookieJar()
+
+// LoadCookies adds the cookies of a Netscape cookies.txt file, as exported by
+// browser extensions or yt-dlp, to the default cookie jar. They take
+// precedence over the consent cookies.
+func LoadCookies(path string) error {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	cookies, err := parseCookies(file)
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for host, items := range cookies {
+		DefaultCookieJar.SetCookies(&url.URL{Scheme: "https", Host: host, Path: "/"}, items)
+	}
+
+	return nil
+}
+
+func parseCookies(r io.Reader) (map[string][]*http.Cookie, error) {
+	cookies := make(map[string][]*http.Cookie)
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		httpOnly := strings.HasPrefix(text, "#HttpOnly_")
+
+		if httpOnly {
+			text = strings.TrimPrefix(text, "#HttpOnly_")
+		}
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 fields, got %d", line, len(fields))
+		}
+
+		cookie := &http.Cookie{
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Name:     fields[5],
+			Value:    fields[6],
+			HttpOnly: httpOnly,
+		}
+
+		// Host-only cookies keep their domain empty.
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = fields[0]
+		}
+
+		if expires, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expires > 0 {
+			cookie.Expires = time.Unix(expires, 0)
+		}
+
+		host := strings.TrimPrefix(fields[0], ".")
+		cookies[host] = append(cookies[host], cookie)
+	}
+
+	return cookies, scanner.Err()
+}
+
+// consentRedirect reports whether the response landed on the consent page,
+// meaning the consent cookies were rejected.
+func consentRedirect(resp *http.Response) bool {
+	return resp.Request != nil && resp.Request.URL.Host == "consent.youtube.com"
+}
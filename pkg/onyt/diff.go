@@ -0,0 +1,146 @@
+package onyt
+
+// ChannelDelta is the change of the channel statistics between two states.
+type ChannelDelta struct {
+	Subscribers int64 `json:"subscribers"`
+	Views       int64 `json:"views"`
+	Videos      int64 `json:"videos"`
+}
+
+// VideoDelta is the change of the statistics of a video found in both states.
+type VideoDelta struct {
+	VideoId  string    `json:"videoId"`
+	Views    int64     `json:"views"`
+	Likes    int64     `json:"likes"`
+	Comments int64     `json:"comments"`
+	Viewers  int64     `json:"viewers,omitempty"`
+	Changes  []*Change `json:"changes,omitempty"`
+}
+
+// StateDiff is the structured difference between two versions of a state.
+type StateDiff struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+
+	Channel *ChannelDelta `json:"channel"`
+
+	Added   []*Video      `json:"added"`
+	Removed []*Video      `json:"removed"`
+	Changed []*VideoDelta `json:"changed"`
+
+	LiveStarted []string `json:"liveStarted"`
+	LiveEnded   []string `json:"liveEnded"`
+}
+
+func delta(from, to uint64) int64 {
+	return int64(to) - int64(from)
+}
+
+// stateVideos returns the videos of the state by ID, in order.
+func stateVideos(state *State) ([]string, map[string]*Video) {
+	ids := make([]string, 0)
+	videos := make(map[string]*Video)
+
+	for _, list := range [][]*Video{state.LiveVideos, state.Videos, state.UpcomingVideos} {
+		for _, v := range list {
+			if _, ok := videos[v.Id]; ok || v.Video == nil {
+				continue
+			}
+
+			ids = append(ids, v.Id)
+			videos[v.Id] = v
+		}
+	}
+
+	return ids, videos
+}
+
+func diffVideo(prev, next *Video) *VideoDelta {
+	d := &VideoDelta{
+		VideoId: next.Id,
+	}
+
+	if prev.Statistics != nil && next.Statistics != nil {
+		d.Views = delta(prev.Statistics.ViewCount, next.Statistics.ViewCount)
+		d.Likes = delta(prev.Statistics.LikeCount, next.Statistics.LikeCount)
+		d.Comments = delta(prev.Statistics.CommentCount, next.Statistics.CommentCount)
+	}
+
+	if prev.LiveStreamingDetails != nil && next.LiveStreamingDetails != nil {
+		d.Viewers = delta(prev.LiveStreamingDetails.ConcurrentViewers, next.LiveStreamingDetails.ConcurrentViewers)
+	}
+
+	if update := diffLiveVideo(prev.Video, next.Video); update != nil {
+		d.Changes = update.Changes
+	}
+
+	if d.Views == 0 && d.Likes == 0 && d.Comments == 0 && d.Viewers == 0 && len(d.Changes) == 0 {
+		return nil
+	}
+
+	return d
+}
+
+// DiffStates returns the statistics deltas and the added, removed and changed
+// videos between the states.
+func DiffStates(from, to *State) *StateDiff {
+	diff := &StateDiff{
+		Channel:     new(ChannelDelta),
+		Added:       make([]*Video, 0),
+		Removed:     make([]*Video, 0),
+		Changed:     make([]*VideoDelta, 0),
+		LiveStarted: make([]string, 0),
+		LiveEnded:   make([]string, 0),
+	}
+
+	if a, b := from.Channel, to.Channel; a != nil && b != nil && a.Statistics != nil && b.Statistics != nil {
+		diff.Channel.Subscribers = delta(a.Statistics.SubscriberCount, b.Statistics.SubscriberCount)
+		diff.Channel.Views = delta(a.Statistics.ViewCount, b.Statistics.ViewCount)
+		diff.Channel.Videos = delta(a.Statistics.VideoCount, b.Statistics.VideoCount)
+	}
+
+	previousIds, previous := stateVideos(from)
+	nextIds, next := stateVideos(to)
+
+	for _, id := range nextIds {
+		prev, ok := previous[id]
+
+		if !ok {
+			diff.Added = append(diff.Added, next[id])
+			continue
+		}
+
+		if d := diffVideo(prev, next[id]); d != nil {
+			diff.Changed = append(diff.Changed, d)
+		}
+	}
+
+	for _, id := range previousIds {
+		if _, ok := next[id]; !ok {
+			diff.Removed = append(diff.Removed, previous[id])
+		}
+	}
+
+	wasLive := make(map[string]bool, len(from.LiveVideos))
+	isLive := make(map[string]bool, len(to.LiveVideos))
+
+	for _, v := range from.LiveVideos {
+		wasLive[v.Id] = true
+	}
+
+	for _, v := range to.LiveVideos {
+		isLive[v.Id] = true
+
+		if !wasLive[v.Id] {
+			diff.LiveStarted = append(diff.LiveStarted, v.Id)
+		}
+	}
+
+	for _, v := range from.LiveVideos {
+		if !isLive[v.Id] {
+			diff.LiveEnded = append(diff.LiveEnded, v.Id)
+		}
+	}
+
+	return diff
+}
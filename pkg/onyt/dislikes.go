@@ -0,0 +1,105 @@
+package onyt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// dislikesTTL is how long the dislikes of a video are cached.
+const dislikesTTL = 15 * time.Minute
+
+// Dislikes are the dislikes of a video estimated by Return YouTube Dislike,
+// along with the rating out of 5.
+type Dislikes struct {
+	Count  int64   `json:"count"`
+	Rating float64 `json:"rating"`
+}
+
+type rydVotes struct {
+	Dislikes int64   `json:"dislikes"`
+	Rating   float64 `json:"rating"`
+}
+
+// DislikesClient fetches the estimated dislikes of the videos from the
+// Return YouTube Dislike API.
+type DislikesClient struct {
+	URL string
+
+	cache *TTLCache[*Dislikes]
+}
+
+func NewDislikesClient(baseURL string) *DislikesClient {
+	return &DislikesClient{
+		URL:   strings.TrimSuffix(baseURL, "/"),
+		cache: NewTTLCache[*Dislikes](),
+	}
+}
+
+// Dislikes returns the estimated dislikes of the video, nil when unknown.
+func (c *DislikesClient) Dislikes(ctx context.Context, videoId string) (*Dislikes, error) {
+	if dislikes, ok := c.cache.Get(videoId); ok {
+		return dislikes, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL+"/votes?"+url.Values{"videoId": {videoId}}.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	var dislikes *Dislikes
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var votes rydVotes
+
+		if err := json.NewDecoder(resp.Body).Decode(&votes); err != nil {
+			return nil, err
+		}
+
+		dislikes = &Dislikes{
+			Count:  votes.Dislikes,
+			Rating: votes.Rating,
+		}
+
+	// The API answers a 404 for the videos it doesn't know of.
+	case http.StatusNotFound:
+
+	default:
+		return nil, fmt.Errorf("unexpected dislikes status: %s", resp.Status)
+	}
+
+	c.cache.Set(videoId, dislikes, dislikesTTL)
+
+	return dislikes, nil
+}
+
+// Enrich attaches their estimated dislikes to the videos, leaving out the
+// ones whose dislikes couldn't be fetched.
+func (c *DislikesClient) Enrich(ctx context.Context, videos []*Video) {
+	for _, v := range videos {
+		dislikes, err := c.Dislikes(ctx, v.Id)
+
+		if err != nil {
+			log.Warn().Err(err).Str("video", v.Id).Msg("Unable to fetch dislikes")
+			continue
+		}
+
+		v.dislikes = dislikes
+	}
+}
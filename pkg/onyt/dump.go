@@ -0,0 +1,109 @@
+package onyt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var dumpNameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Dump is a transport writing each exchange to a timestamped JSON file, which
+// can be fed back with a Replay.
+type Dump struct {
+	Transport http.RoundTripper
+
+	dir     string
+	counter atomic.Uint64
+}
+
+func NewDump(dir string, transport http.RoundTripper) (*Dump, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &Dump{
+		Transport: transport,
+		dir:       dir,
+	}, nil
+}
+
+func (d *Dump) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+
+	if req.Body != nil {
+		var err error
+
+		requestBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	resp, err := d.Transport.RoundTrip(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// The cookies are left out, the dumps being meant to be shared.
+	header := resp.Header.Clone()
+	header.Del("set-cookie")
+
+	exchange := &Exchange{
+		Method:      req.Method,
+		URL:         exchangeURL(req.URL),
+		RequestBody: string(requestBody),
+		Status:      resp.StatusCode,
+		Header:      header,
+		Body:        string(body),
+	}
+
+	if err := d.write(exchange); err != nil {
+		log.Err(err).Str("url", exchange.URL).Msg("Unable to dump response")
+	}
+
+	return resp, nil
+}
+
+func (d *Dump) write(exchange *Exchange) error {
+	name := dumpNameRe.ReplaceAllString(strings.SplitN(exchange.URL, "://", 2)[1], "_")
+
+	if len(name) > 100 {
+		name = name[:100]
+	}
+
+	// The counter keeps the names unique and ordered within the same instant.
+	name = fmt.Sprintf("%s-%06d-%s.json", time.Now().UTC().Format("20060102T150405.000000"), d.counter.Add(1)%1000000, name)
+
+	data, err := json.MarshalIndent(exchange, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(d.dir, name), data, 0o644)
+}
@@ -0,0 +1,171 @@
+package onyt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// The kinds of refresh failures.
+const (
+	ErrorQuotaExceeded   = "quota_exceeded"
+	ErrorInvalidKey      = "invalid_key"
+	ErrorChannelNotFound = "channel_not_found"
+	ErrorScrapeBlocked   = "scrape_blocked"
+	ErrorThrottled       = "throttled"
+	ErrorNetwork         = "network"
+	ErrorUnknown         = "unknown"
+)
+
+// StatusError reports an unexpected HTTP status returned by a scraped page or
+// an unofficial API.
+type StatusError struct {
+	Source     string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected %s status: %s", e.Source, e.Status)
+}
+
+// RefreshError is a refresh failure along with its kind.
+type RefreshError struct {
+	Kind string
+	Err  error
+}
+
+func (e *RefreshError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RefreshError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError returns the kind of the refresh failure.
+func ClassifyError(err error) string {
+	var (
+		refreshErr *RefreshError
+		apiErr     *googleapi.Error
+		statusErr  *StatusError
+		throttled  *ThrottledError
+		netErr     net.Error
+	)
+
+	switch {
+	case errors.As(err, &refreshErr):
+		return refreshErr.Kind
+
+	case errors.As(err, &throttled):
+		return ErrorThrottled
+
+	case errors.Is(err, ErrChannelNotFound):
+		return ErrorChannelNotFound
+
+	case errors.As(err, &apiErr):
+		for _, item := range apiErr.Errors {
+			switch item.Reason {
+			case "quotaExceeded", "dailyLimitExceeded":
+				return ErrorQuotaExceeded
+
+			case "rateLimitExceeded", "userRateLimitExceeded":
+				return ErrorThrottled
+
+			case "keyInvalid", "keyExpired", "accessNotConfigured", "ipRefererBlocked", "forbidden":
+				return ErrorInvalidKey
+
+			case "channelNotFound", "notFound":
+				return ErrorChannelNotFound
+			}
+		}
+
+		if apiErr.Code == http.StatusBadRequest || apiErr.Code == http.StatusUnauthorized {
+			return ErrorInvalidKey
+		}
+
+	case errors.As(err, &statusErr):
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests:
+			return ErrorThrottled
+
+		case http.StatusForbidden:
+			return ErrorScrapeBlocked
+
+		case http.StatusNotFound:
+			return ErrorChannelNotFound
+		}
+
+	case errors.Is(err, errLiveScrapeFailed), errors.Is(err, errInnertubeNoContents), errors.Is(err, errNoInitialData), errors.Is(err, errConsentRequired):
+		return ErrorScrapeBlocked
+
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return ErrorNetwork
+	}
+
+	return ErrorUnknown
+}
+
+type ErrorCount struct {
+	Kind        string `json:"kind"`
+	Count       uint64 `json:"count"`
+	LastAt      int64  `json:"lastAt"`
+	LastMessage string `json:"lastMessage"`
+}
+
+// ErrorStats counts the refresh failures by kind.
+type ErrorStats struct {
+	mu     sync.RWMutex
+	counts map[string]*ErrorCount
+}
+
+func NewErrorStats() *ErrorStats {
+	return &ErrorStats{
+		counts: make(map[string]*ErrorCount),
+	}
+}
+
+func (s *ErrorStats) Record(kind string, err error, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count, ok := s.counts[kind]
+
+	if !ok {
+		count = &ErrorCount{
+			Kind: kind,
+		}
+
+		s.counts[kind] = count
+	}
+
+	count.Count++
+	count.LastAt = now.Unix()
+	count.LastMessage = err.Error()
+}
+
+// List returns the counts, the most recent failures first.
+func (s *ErrorStats) List() []*ErrorCount {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*ErrorCount, 0, len(s.counts))
+
+	for _, count := range s.counts {
+		c := *count
+		result = append(result, &c)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastAt > result[j].LastAt
+	})
+
+	return result
+}
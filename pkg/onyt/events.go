@@ -0,0 +1,55 @@
+package onyt
+
+import (
+	"sync"
+	"time"
+)
+
+type Event struct {
+	Type      string    `json:"type"`
+	ChannelId string    `json:"channelId"`
+	Time      time.Time `json:"time"`
+	Data      any       `json:"data"`
+}
+
+type EventHandler func(evt Event)
+
+// EventBus dispatches emitted events to its subscribers.
+type EventBus struct {
+	mu       sync.RWMutex
+	nextId   int
+	handlers map[int]EventHandler
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		handlers: make(map[int]EventHandler),
+	}
+}
+
+// Subscribe registers a handler and returns a function removing it.
+func (b *EventBus) Subscribe(handler EventHandler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextId
+	b.nextId++
+
+	b.handlers[id] = handler
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.handlers, id)
+	}
+}
+
+func (b *EventBus) Emit(evt Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, handler := range b.handlers {
+		handler(evt)
+	}
+}
@@ -0,0 +1,222 @@
+package onyt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ytInitialPlayerResponseRe = regexp.MustCompile(`(?s)var ytInitialPlayerResponse = (\{.*?\});\s*(?:var |</script>)`)
+
+	errNoPlayerResponse = errors.New("page did not contain ytInitialPlayerResponse")
+)
+
+// EndScreenElement is an element shown over the last seconds of a video,
+// such as another video, a playlist, a channel or a website.
+type EndScreenElement struct {
+	Type         string  `json:"type"`
+	Title        string  `json:"title"`
+	Id           string  `json:"id,omitempty"`
+	URL          string  `json:"url,omitempty"`
+	StartSeconds float64 `json:"startSeconds"`
+	EndSeconds   float64 `json:"endSeconds"`
+}
+
+// VideoExtras is the data of a video scraped from its watch page, which the
+// API doesn't expose.
+type VideoExtras struct {
+	// WatchingNow is the approximate number of viewers shown on the live
+	// streams and the premieres, including those waiting for them to start.
+	WatchingNow *int64              `json:"watchingNow,omitempty"`
+	MembersOnly bool                `json:"membersOnly"`
+	EndScreen   []*EndScreenElement `json:"endScreen"`
+}
+
+func parseWatchingNow(data map[string]any) *int64 {
+	var count *int64
+
+	walkRenderers(data, "videoViewCountRenderer", func(renderer map[string]any) {
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+
+			return -1
+		}, textOf(renderer["viewCount"]))
+
+		if n, err := strconv.ParseInt(digits, 10, 64); err == nil && count == nil {
+			count = &n
+		}
+	})
+
+	return count
+}
+
+func parseMembersOnly(data map[string]any) bool {
+	membersOnly := false
+
+	walkRenderers(data, "videoPrimaryInfoRenderer", func(renderer map[string]any) {
+		walkRenderers(renderer["badges"], "metadataBadgeRenderer", func(badge map[string]any) {
+			if badge["style"] == "BADGE_STYLE_TYPE_MEMBERS_ONLY" {
+				membersOnly = true
+			}
+		})
+	})
+
+	return membersOnly
+}
+
+func parseMilliseconds(node any) float64 {
+	value, _ := node.(string)
+
+	ms, err := strconv.ParseInt(value, 10, 64)
+
+	if err != nil {
+		return 0
+	}
+
+	return float64(ms) / 1000
+}
+
+func parseEndScreen(player map[string]any) []*EndScreenElement {
+	elements := make([]*EndScreenElement, 0)
+
+	items, _ := jsonPath(player, "endscreen", "endscreenRenderer", "elements").([]any)
+
+	for _, item := range items {
+		renderer, ok := jsonPath(item, "endscreenElementRenderer").(map[string]any)
+
+		if !ok {
+			continue
+		}
+
+		style, _ := renderer["style"].(string)
+
+		element := &EndScreenElement{
+			Type:         strings.ToLower(style),
+			Title:        textOf(renderer["title"]),
+			StartSeconds: parseMilliseconds(renderer["startMs"]),
+			EndSeconds:   parseMilliseconds(renderer["endMs"]),
+		}
+
+		switch style {
+		case "VIDEO":
+			element.Id, _ = jsonPath(renderer, "endpoint", "watchEndpoint", "videoId").(string)
+			element.URL = fmt.Sprintf("https://www.youtube.com/watch?v=%s", element.Id)
+
+		case "PLAYLIST":
+			element.Id, _ = jsonPath(renderer, "endpoint", "watchEndpoint", "playlistId").(string)
+			element.URL = fmt.Sprintf("https://www.youtube.com/playlist?list=%s", element.Id)
+
+		case "CHANNEL", "SUBSCRIBE":
+			element.Id, _ = jsonPath(renderer, "endpoint", "browseEndpoint", "browseId").(string)
+			element.URL = fmt.Sprintf("https://www.youtube.com/channel/%s", element.Id)
+
+		case "WEBSITE":
+			element.URL, _ = jsonPath(renderer, "endpoint", "urlEndpoint", "url").(string)
+		}
+
+		elements = append(elements, element)
+	}
+
+	return elements
+}
+
+// scrapeExtras returns the extras of a video, the number of viewers being
+// only parsed when it is live or upcoming.
+func scrapeExtras(ctx context.Context, video *Video) (*VideoExtras, error) {
+	body, err := fetchPage(ctx, fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.Id))
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := parseInitialData(body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sm := ytInitialPlayerResponseRe.FindSubmatch(body)
+
+	if sm == nil {
+		return nil, errNoPlayerResponse
+	}
+
+	var player map[string]any
+
+	if err := json.Unmarshal(sm[1], &player); err != nil {
+		return nil, err
+	}
+
+	extras := &VideoExtras{
+		MembersOnly: parseMembersOnly(data),
+		EndScreen:   parseEndScreen(player),
+	}
+
+	if s := video.Snippet; s != nil && (s.LiveBroadcastContent == "live" || s.LiveBroadcastContent == "upcoming") {
+		extras.WatchingNow = parseWatchingNow(data)
+	}
+
+	return extras, nil
+}
+
+// WatchPageScraper attaches the extras scraped from their watch page to the
+// live and upcoming videos, and to the latest uploads, scraping each video at
+// most once per interval.
+type WatchPageScraper struct {
+	Locale Locale
+
+	// Videos is the number of latest uploads enriched with their extras.
+	Videos int
+
+	interval time.Duration
+	cache    *TTLCache[*VideoExtras]
+}
+
+func NewWatchPageScraper(interval time.Duration, videos int) *WatchPageScraper {
+	return &WatchPageScraper{
+		Videos:   videos,
+		interval: interval,
+		cache:    NewTTLCache[*VideoExtras](),
+	}
+}
+
+// Enrich attaches their extras to the videos, leaving out the ones whose
+// watch page couldn't be scraped.
+func (s *WatchPageScraper) Enrich(ctx context.Context, videos []*Video) {
+	for _, v := range videos {
+		extras, ok := s.cache.Get(v.Id)
+
+		if !ok {
+			scraped, err := scrapeExtras(withLocale(ctx, s.Locale), v)
+
+			if err != nil {
+				log.Warn().Err(err).Str("video", v.Id).Msg("Unable to scrape watch page")
+				continue
+			}
+
+			s.cache.Set(v.Id, scraped, s.interval)
+			extras = scraped
+		}
+
+		v.extras = extras
+	}
+}
+
+// Latest returns the latest uploads to enrich.
+func (s *WatchPageScraper) Latest(videos []*Video) []*Video {
+	if len(videos) > s.Videos {
+		return videos[:s.Videos]
+	}
+
+	return videos
+}
@@ -0,0 +1,152 @@
+package onyt
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// scrapeHosts lists the hosts of the scraped pages, the API being left
+// untouched.
+var scrapeHosts = map[string]bool{
+	"www.youtube.com": true,
+	"m.youtube.com":   true,
+}
+
+// ScrapeGuard is a transport making the scrapes less likely to be blocked,
+// each of its measures being disabled when left empty.
+type ScrapeGuard struct {
+	Transport http.RoundTripper
+
+	// UserAgents are rotated across the scrapes, and AcceptLanguage replaces
+	// the languages of the locale.
+	UserAgents     []string
+	AcceptLanguage string
+
+	// Pacing is the average delay between two scrapes of a host, randomized
+	// by half on both sides.
+	Pacing time.Duration
+
+	// Cooldown is how long the scrapes of a host are held back after it
+	// blocked one, with a 403 or a redirection to its captcha.
+	Cooldown time.Duration
+
+	mu        sync.Mutex
+	userAgent int
+	pacedAt   map[string]time.Time
+	cooldowns map[string]*HostThrottle
+}
+
+func NewScrapeGuard(transport http.RoundTripper) *ScrapeGuard {
+	return &ScrapeGuard{
+		Transport: transport,
+		pacedAt:   make(map[string]time.Time),
+		cooldowns: make(map[string]*HostThrottle),
+	}
+}
+
+// pace returns how long to wait before scraping the host, booking the next
+// slot.
+func (g *ScrapeGuard) pace(host string, now time.Time) time.Duration {
+	if g.Pacing <= 0 {
+		return 0
+	}
+
+	at := g.pacedAt[host]
+
+	if at.Before(now) {
+		at = now
+	}
+
+	g.pacedAt[host] = at.Add(g.Pacing/2 + time.Duration(rand.Int63n(int64(g.Pacing))))
+
+	return at.Sub(now)
+}
+
+func blocked(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusForbidden {
+		return true
+	}
+
+	return resp.StatusCode >= 300 && resp.StatusCode < 400 && strings.Contains(resp.Header.Get("location"), "/sorry/")
+}
+
+func (g *ScrapeGuard) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+
+	if !scrapeHosts[host] {
+		return g.Transport.RoundTrip(req)
+	}
+
+	now := time.Now()
+
+	g.mu.Lock()
+
+	if cooldown, ok := g.cooldowns[host]; ok && now.Before(cooldown.Until) {
+		g.mu.Unlock()
+		return nil, &ThrottledError{host, cooldown.Until}
+	}
+
+	wait := g.pace(host, now)
+
+	var userAgent string
+
+	if len(g.UserAgents) > 0 {
+		userAgent = g.UserAgents[g.userAgent%len(g.UserAgents)]
+		g.userAgent++
+	}
+
+	g.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+
+		case <-timer.C:
+		}
+	}
+
+	req = req.Clone(req.Context())
+
+	if userAgent != "" {
+		req.Header.Set("user-agent", userAgent)
+	}
+
+	if g.AcceptLanguage != "" {
+		req.Header.Set("accept-language", g.AcceptLanguage)
+	}
+
+	resp, err := g.Transport.RoundTrip(req)
+
+	if err != nil || g.Cooldown <= 0 || !blocked(resp) {
+		return resp, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cooldown, ok := g.cooldowns[host]
+
+	if !ok {
+		cooldown = &HostThrottle{
+			Host: host,
+		}
+
+		g.cooldowns[host] = cooldown
+	}
+
+	cooldown.Strikes++
+	cooldown.Until = time.Now().Add(g.Cooldown)
+
+	log.Warn().Str("host", host).Int("status", resp.StatusCode).Time("until", cooldown.Until).Msg("Scrape blocked, cooling down")
+
+	return resp, nil
+}
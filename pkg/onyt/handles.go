@@ -0,0 +1,84 @@
+package onyt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+var (
+	errHandleNotResolved = errors.New("channel page did not contain a canonical link")
+
+	channelURLRe = regexp.MustCompile(`^https://www\.youtube\.com/channel/(UC[\w-]{22})$`)
+
+	// Handles never move to another channel, so they're cached for good.
+	handles = NewTTLCache[string]()
+)
+
+// ResolveHandle returns the ID of the channel whose handle, such as "@name",
+// is given, by scraping its canonical link.
+func ResolveHandle(ctx context.Context, handle string) (string, error) {
+	handle = "@" + strings.TrimPrefix(handle, "@")
+
+	if channelId, ok := handles.Get(strings.ToLower(handle)); ok {
+		return channelId, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.youtube.com/"+url.PathEscape(handle), nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if consentRedirect(resp) {
+		return "", errConsentRequired
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%s: %w", handle, ErrChannelNotFound)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{"channel page", resp.StatusCode, resp.Status}
+	}
+
+	doc, err := html.Parse(resp.Body)
+
+	if err != nil {
+		return "", err
+	}
+
+	node := cascadia.Query(doc, cascadia.MustCompile("link[rel='canonical']"))
+
+	if node == nil {
+		return "", errHandleNotResolved
+	}
+
+	for _, v := range node.Attr {
+		if v.Key != "href" {
+			continue
+		}
+
+		if sm := channelURLRe.FindStringSubmatch(v.Val); len(sm) > 0 {
+			handles.Set(strings.ToLower(handle), sm[1], 0)
+			return sm[1], nil
+		}
+	}
+
+	return "", errHandleNotResolved
+}
@@ -0,0 +1,136 @@
+package onyt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+type HolodexChannel struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	EnglishName string `json:"english_name,omitempty"`
+	Org         string `json:"org,omitempty"`
+	Photo       string `json:"photo,omitempty"`
+}
+
+type HolodexVideo struct {
+	Id          string            `json:"id"`
+	Title       string            `json:"title"`
+	Type        string            `json:"type"`
+	TopicId     string            `json:"topic_id,omitempty"`
+	Status      string            `json:"status"`
+	AvailableAt string            `json:"available_at,omitempty"`
+	Channel     *HolodexChannel   `json:"channel,omitempty"`
+	Mentions    []*HolodexChannel `json:"mentions,omitempty"`
+}
+
+type HolodexExtras struct {
+	Organization    string            `json:"organization"`
+	Suborganization string            `json:"suborganization"`
+	Group           string            `json:"group"`
+	TopTopics       []string          `json:"topTopics"`
+	VideoId         string            `json:"videoId"`
+	Topic           string            `json:"topic"`
+	Mentions        []*HolodexChannel `json:"mentions"`
+	Collabs         []*HolodexVideo   `json:"collabs"`
+}
+
+type Extras struct {
+	Holodex *HolodexExtras `json:"holodex,omitempty"`
+}
+
+type holodexChannelDetails struct {
+	Org       string   `json:"org"`
+	Suborg    string   `json:"suborg"`
+	Group     string   `json:"group"`
+	TopTopics []string `json:"top_topics"`
+}
+
+type HolodexClient struct {
+	apiKey string
+}
+
+func NewHolodexClient(apiKey string) *HolodexClient {
+	return &HolodexClient{
+		apiKey: apiKey,
+	}
+}
+
+func (c *HolodexClient) get(ctx context.Context, path string, query url.Values, v any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://holodex.net/api/v2"+path+"?"+query.Encode(), nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("x-apikey", c.apiKey)
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected holodex status: %s", resp.Status)
+	}
+
+	return true, json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *HolodexClient) Fetch(ctx context.Context, channelId string, videoId string) (*HolodexExtras, error) {
+	var channel holodexChannelDetails
+
+	found, err := c.get(ctx, "/channels/"+url.PathEscape(channelId), nil, &channel)
+
+	if err != nil || !found {
+		return nil, err
+	}
+
+	extras := &HolodexExtras{
+		Organization:    channel.Org,
+		Suborganization: channel.Suborg,
+		Group:           channel.Group,
+		TopTopics:       channel.TopTopics,
+		VideoId:         videoId,
+		Mentions:        make([]*HolodexChannel, 0),
+		Collabs:         make([]*HolodexVideo, 0),
+	}
+
+	if videoId != "" {
+		var video HolodexVideo
+
+		found, err := c.get(ctx, "/videos/"+url.PathEscape(videoId), url.Values{"include": {"mentions"}}, &video)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if found {
+			extras.Topic = video.TopicId
+
+			if video.Mentions != nil {
+				extras.Mentions = video.Mentions
+			}
+		}
+	}
+
+	query := url.Values{
+		"limit": {"10"},
+	}
+
+	if _, err := c.get(ctx, "/channels/"+url.PathEscape(channelId)+"/collabs", query, &extras.Collabs); err != nil {
+		return nil, err
+	}
+
+	return extras, nil
+}
@@ -0,0 +1,152 @@
+package onyt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+const (
+	innertubeClientName    = "WEB"
+	innertubeClientVersion = "2.20230607.06.00"
+	innertubeStreamsParams = "EgdzdHJlYW1z8gYECgJ6AA=="
+)
+
+var errInnertubeNoContents = errors.New("innertube response did not contain any contents")
+
+type innertubeRequest struct {
+	Context  innertubeContext `json:"context"`
+	BrowseId string           `json:"browseId"`
+	Params   string           `json:"params,omitempty"`
+}
+
+type innertubeContext struct {
+	Client innertubeClient `json:"client"`
+}
+
+type innertubeClient struct {
+	ClientName    string `json:"clientName"`
+	ClientVersion string `json:"clientVersion"`
+	Hl            string `json:"hl"`
+	Gl            string `json:"gl,omitempty"`
+}
+
+func fetchInnertubeBrowse(ctx context.Context, browseId string, params string) (map[string]any, error) {
+	body, err := json.Marshal(innertubeRequest{
+		Context: innertubeContext{
+			Client: innertubeClient{
+				ClientName:    innertubeClientName,
+				ClientVersion: innertubeClientVersion,
+				Hl:            localeFrom(ctx).acceptLanguage(),
+				Gl:            localeFrom(ctx).Region,
+			},
+		},
+		BrowseId: browseId,
+		Params:   params,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://www.youtube.com/youtubei/v1/browse?prettyPrint=false", bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("content-type", "application/json")
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{"innertube", resp.StatusCode, resp.Status}
+	}
+
+	var data map[string]any
+
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	if _, ok := data["contents"]; !ok {
+		return nil, errInnertubeNoContents
+	}
+
+	return data, nil
+}
+
+func DetectInnertubeLive(ctx context.Context, channelId string) (*LiveStreams, error) {
+	data, err := fetchInnertubeBrowse(ctx, channelId, innertubeStreamsParams)
+
+	if err != nil {
+		return nil, err
+	}
+
+	streams := newLiveStreams()
+
+	walkRenderers(data, "videoRenderer", func(renderer map[string]any) {
+		videoId, _ := renderer["videoId"].(string)
+
+		if videoId == "" {
+			return
+		}
+
+		switch {
+		case isLiveRenderer(renderer):
+			streams.VideoIds = append(streams.VideoIds, videoId)
+
+		case renderer["upcomingEventData"] != nil:
+			streams.UpcomingVideoIds = append(streams.UpcomingVideoIds, videoId)
+		}
+	})
+
+	streams.VideoIds = uniqueStrings(streams.VideoIds)
+	streams.UpcomingVideoIds = uniqueStrings(streams.UpcomingVideoIds)
+
+	return streams, nil
+}
+
+func walkRenderers(node any, name string, fn func(renderer map[string]any)) {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if renderer, ok := child.(map[string]any); ok && key == name {
+				fn(renderer)
+			}
+
+			walkRenderers(child, name, fn)
+		}
+
+	case []any:
+		for _, child := range v {
+			walkRenderers(child, name, fn)
+		}
+	}
+}
+
+func isLiveRenderer(renderer map[string]any) bool {
+	live := false
+
+	walkRenderers(renderer["badges"], "metadataBadgeRenderer", func(badge map[string]any) {
+		if badge["style"] == "BADGE_STYLE_TYPE_LIVE_NOW" {
+			live = true
+		}
+	})
+
+	walkRenderers(renderer["thumbnailOverlays"], "thumbnailOverlayTimeStatusRenderer", func(overlay map[string]any) {
+		if overlay["style"] == "LIVE" {
+			live = true
+		}
+	})
+
+	return live
+}
@@ -0,0 +1,254 @@
+package onyt
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/youtube/v3"
+)
+
+type invidiousImage struct {
+	Quality string `json:"quality"`
+	URL     string `json:"url"`
+	Width   int64  `json:"width"`
+	Height  int64  `json:"height"`
+}
+
+type invidiousChannel struct {
+	Author           string            `json:"author"`
+	AuthorId         string            `json:"authorId"`
+	AuthorThumbnails []*invidiousImage `json:"authorThumbnails"`
+	SubCount         uint64            `json:"subCount"`
+	TotalViews       uint64            `json:"totalViews"`
+	Description      string            `json:"description"`
+}
+
+type invidiousVideo struct {
+	Type              string            `json:"type"`
+	VideoId           string            `json:"videoId"`
+	Title             string            `json:"title"`
+	Description       string            `json:"description"`
+	Author            string            `json:"author"`
+	AuthorId          string            `json:"authorId"`
+	Published         int64             `json:"published"`
+	PremiereTimestamp int64             `json:"premiereTimestamp"`
+	LengthSeconds     int64             `json:"lengthSeconds"`
+	ViewCount         uint64            `json:"viewCount"`
+	LikeCount         uint64            `json:"likeCount"`
+	LiveNow           bool              `json:"liveNow"`
+	IsUpcoming        bool              `json:"isUpcoming"`
+	Keywords          []string          `json:"keywords"`
+	VideoThumbnails   []*invidiousImage `json:"videoThumbnails"`
+}
+
+type invidiousVideoList struct {
+	Videos []*invidiousVideo `json:"videos"`
+}
+
+type InvidiousBackend struct {
+	baseURL string
+}
+
+func NewInvidiousBackend(baseURL string) *InvidiousBackend {
+	return &InvidiousBackend{
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (b *InvidiousBackend) Name() string {
+	return "invidious"
+}
+
+func (b *InvidiousBackend) endpoint(format string, args ...any) string {
+	return b.baseURL + "/api/v1" + fmt.Sprintf(format, args...)
+}
+
+func (b *InvidiousBackend) FetchChannel(ctx context.Context, channelId string) (*youtube.Channel, error) {
+	var data invidiousChannel
+
+	if err := getJSON(ctx, b.endpoint("/channels/%s", url.PathEscape(channelId)), &data); err != nil {
+		return nil, err
+	}
+
+	if data.AuthorId == "" {
+		return nil, ErrChannelNotFound
+	}
+
+	thumbnails := &youtube.ThumbnailDetails{}
+
+	for _, image := range data.AuthorThumbnails {
+		thumbnail := &youtube.Thumbnail{
+			Url:    image.URL,
+			Width:  image.Width,
+			Height: image.Height,
+		}
+
+		switch {
+		case image.Width >= 800:
+			thumbnails.High = thumbnail
+		case image.Width >= 240:
+			thumbnails.Medium = thumbnail
+		case image.Width >= 88:
+			thumbnails.Default = thumbnail
+		}
+	}
+
+	return &youtube.Channel{
+		Id: data.AuthorId,
+		ContentDetails: &youtube.ChannelContentDetails{
+			RelatedPlaylists: &youtube.ChannelContentDetailsRelatedPlaylists{
+				Uploads: uploadsPlaylistId(data.AuthorId),
+			},
+		},
+		Snippet: &youtube.ChannelSnippet{
+			Title:       data.Author,
+			Description: data.Description,
+			Thumbnails:  thumbnails,
+		},
+		Statistics: &youtube.ChannelStatistics{
+			SubscriberCount: data.SubCount,
+			ViewCount:       data.TotalViews,
+		},
+	}, nil
+}
+
+func (b *InvidiousBackend) fetchVideoList(ctx context.Context, tab string, channelId string) ([]*invidiousVideo, error) {
+	var data invidiousVideoList
+
+	if err := getJSON(ctx, b.endpoint("/channels/%s/%s", url.PathEscape(channelId), tab), &data); err != nil {
+		return nil, err
+	}
+
+	return data.Videos, nil
+}
+
+func (b *InvidiousBackend) FetchUploadIds(ctx context.Context, channel *youtube.Channel) ([]string, error) {
+	videos, err := b.fetchVideoList(ctx, "videos", channel.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	uploadIds := make([]string, 0, len(videos))
+
+	for _, v := range videos {
+		uploadIds = append(uploadIds, v.VideoId)
+	}
+
+	return uploadIds, nil
+}
+
+func (b *InvidiousBackend) FetchVideos(ctx context.Context, videoIds []string) ([]*youtube.Video, error) {
+	videos := make([]*youtube.Video, len(videoIds))
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.SetLimit(4)
+
+	for i, id := range videoIds {
+		i, id := i, id
+
+		g.Go(func() error {
+			var data invidiousVideo
+
+			if err := getJSON(ctx, b.endpoint("/videos/%s", url.PathEscape(id)), &data); err != nil {
+				return err
+			}
+
+			videos[i] = data.toVideo()
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return videos, nil
+}
+
+func (b *InvidiousBackend) DetectLive(ctx context.Context, channelId string) (*LiveStreams, error) {
+	videos, err := b.fetchVideoList(ctx, "streams", channelId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	streams := newLiveStreams()
+
+	for _, v := range videos {
+		switch {
+		case v.LiveNow:
+			streams.VideoIds = append(streams.VideoIds, v.VideoId)
+
+		case v.IsUpcoming:
+			streams.UpcomingVideoIds = append(streams.UpcomingVideoIds, v.VideoId)
+		}
+	}
+
+	return streams, nil
+}
+
+func (v *invidiousVideo) toVideo() *youtube.Video {
+	thumbnails := &youtube.ThumbnailDetails{}
+
+	for _, image := range v.VideoThumbnails {
+		thumbnail := &youtube.Thumbnail{
+			Url:    image.URL,
+			Width:  image.Width,
+			Height: image.Height,
+		}
+
+		switch image.Quality {
+		case "maxres", "maxresdefault":
+			thumbnails.Maxres = thumbnail
+		case "sddefault":
+			thumbnails.Standard = thumbnail
+		case "high":
+			thumbnails.High = thumbnail
+		case "medium":
+			thumbnails.Medium = thumbnail
+		case "default":
+			thumbnails.Default = thumbnail
+		}
+	}
+
+	video := &youtube.Video{
+		Id: v.VideoId,
+		ContentDetails: &youtube.VideoContentDetails{
+			Duration: fmt.Sprintf("PT%dS", v.LengthSeconds),
+		},
+		Snippet: &youtube.VideoSnippet{
+			ChannelId:            v.AuthorId,
+			ChannelTitle:         v.Author,
+			Title:                v.Title,
+			Description:          v.Description,
+			PublishedAt:          formatUnix(v.Published),
+			Tags:                 v.Keywords,
+			Thumbnails:           thumbnails,
+			LiveBroadcastContent: "none",
+		},
+		Statistics: &youtube.VideoStatistics{
+			ViewCount: v.ViewCount,
+			LikeCount: v.LikeCount,
+		},
+	}
+
+	switch {
+	case v.LiveNow:
+		video.Snippet.LiveBroadcastContent = "live"
+		video.LiveStreamingDetails = &youtube.VideoLiveStreamingDetails{}
+
+	case v.IsUpcoming:
+		video.Snippet.LiveBroadcastContent = "upcoming"
+		video.LiveStreamingDetails = &youtube.VideoLiveStreamingDetails{
+			ScheduledStartTime: formatUnix(v.PremiereTimestamp),
+		}
+	}
+
+	return video
+}
@@ -0,0 +1,146 @@
+package onyt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+type KeywordRule struct {
+	Name     string   `json:"name" yaml:"name"`
+	Pattern  string   `json:"pattern" yaml:"pattern"`
+	Keywords []string `json:"keywords" yaml:"keywords"`
+	Fields   []string `json:"fields" yaml:"fields"`
+	Kinds    []string `json:"kinds" yaml:"kinds"`
+}
+
+type KeywordMatch struct {
+	Rule    string `json:"rule"`
+	Kind    string `json:"kind"`
+	VideoId string `json:"videoId"`
+	Title   string `json:"title"`
+	Field   string `json:"field"`
+	Match   string `json:"match"`
+}
+
+type keywordMatcher struct {
+	rule     KeywordRule
+	pattern  *regexp.Regexp
+	keywords []string
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *keywordMatcher) match(text string) string {
+	if m.pattern != nil {
+		if match := m.pattern.FindString(text); match != "" {
+			return match
+		}
+	}
+
+	lower := strings.ToLower(text)
+
+	for _, keyword := range m.keywords {
+		if i := strings.Index(lower, keyword); i >= 0 {
+			return lower[i : i+len(keyword)]
+		}
+	}
+
+	return ""
+}
+
+// KeywordMatcher matches the titles and descriptions of new uploads and live
+// streams against regular expressions and case-insensitive keywords.
+type KeywordMatcher struct {
+	matchers []*keywordMatcher
+}
+
+func NewKeywordMatcher(rules []KeywordRule) (*KeywordMatcher, error) {
+	result := &KeywordMatcher{
+		matchers: make([]*keywordMatcher, 0, len(rules)),
+	}
+
+	for i, rule := range rules {
+		matcher := &keywordMatcher{
+			rule: rule,
+		}
+
+		if matcher.rule.Name == "" {
+			matcher.rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+
+		if len(matcher.rule.Fields) == 0 {
+			matcher.rule.Fields = []string{"title", "description"}
+		}
+
+		if len(matcher.rule.Kinds) == 0 {
+			matcher.rule.Kinds = []string{"upload", "live"}
+		}
+
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile(rule.Pattern)
+
+			if err != nil {
+				return nil, fmt.Errorf("keyword rule %s: %w", matcher.rule.Name, err)
+			}
+
+			matcher.pattern = pattern
+		}
+
+		for _, keyword := range rule.Keywords {
+			matcher.keywords = append(matcher.keywords, strings.ToLower(keyword))
+		}
+
+		result.matchers = append(result.matchers, matcher)
+	}
+
+	return result, nil
+}
+
+// Match returns the first match of each rule applying to the kind of video,
+// either "upload" or "live".
+func (k *KeywordMatcher) Match(kind string, video *youtube.Video) []*KeywordMatch {
+	matches := make([]*KeywordMatch, 0)
+
+	if video.Snippet == nil {
+		return matches
+	}
+
+	texts := map[string]string{
+		"title":       video.Snippet.Title,
+		"description": video.Snippet.Description,
+	}
+
+	for _, matcher := range k.matchers {
+		if !contains(matcher.rule.Kinds, kind) {
+			continue
+		}
+
+		for _, field := range matcher.rule.Fields {
+			if match := matcher.match(texts[field]); match != "" {
+				matches = append(matches, &KeywordMatch{
+					Rule:    matcher.rule.Name,
+					Kind:    kind,
+					VideoId: video.Id,
+					Title:   video.Snippet.Title,
+					Field:   field,
+					Match:   match,
+				})
+
+				break
+			}
+		}
+	}
+
+	return matches
+}
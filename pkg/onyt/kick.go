@@ -0,0 +1,89 @@
+package onyt
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type kickChannel struct {
+	Id        int64  `json:"id"`
+	Slug      string `json:"slug"`
+	Followers uint64 `json:"followers_count"`
+	User      struct {
+		Username   string `json:"username"`
+		ProfilePic string `json:"profile_pic"`
+	} `json:"user"`
+	Livestream *struct {
+		Id          int64  `json:"id"`
+		SessionName string `json:"session_title"`
+		Viewers     uint64 `json:"viewer_count"`
+		CreatedAt   string `json:"created_at"`
+		Thumbnail   struct {
+			URL string `json:"url"`
+		} `json:"thumbnail"`
+		Categories []struct {
+			Name string `json:"name"`
+		} `json:"categories"`
+	} `json:"livestream"`
+}
+
+type KickSource struct {
+	slug string
+}
+
+func NewKickSource(slug string) *KickSource {
+	return &KickSource{
+		slug: slug,
+	}
+}
+
+func (s *KickSource) Platform() string {
+	return "kick"
+}
+
+func (s *KickSource) Refresh(ctx context.Context) (*SourceState, error) {
+	var data kickChannel
+
+	if err := getJSON(ctx, fmt.Sprintf("https://kick.com/api/v2/channels/%s", url.PathEscape(s.slug)), &data); err != nil {
+		return nil, err
+	}
+
+	result := &SourceState{
+		Platform: s.Platform(),
+		Channel: &SourceChannel{
+			Id:        fmt.Sprint(data.Id),
+			Name:      data.User.Username,
+			URL:       fmt.Sprintf("https://kick.com/%s", data.Slug),
+			AvatarURL: data.User.ProfilePic,
+			Followers: data.Followers,
+		},
+		Streams:     make([]*SourceStream, 0),
+		Videos:      make([]*SourceVideo, 0),
+		RefreshedAt: time.Now().Unix(),
+	}
+
+	if ls := data.Livestream; ls != nil {
+		stream := &SourceStream{
+			Id:           fmt.Sprint(ls.Id),
+			Title:        ls.SessionName,
+			URL:          result.Channel.URL,
+			ThumbnailURL: ls.Thumbnail.URL,
+			Viewers:      ls.Viewers,
+		}
+
+		if len(ls.Categories) > 0 {
+			stream.Category = ls.Categories[0].Name
+		}
+
+		if t, err := time.Parse("2006-01-02 15:04:05", ls.CreatedAt); err == nil {
+			stream.StartedAt = t.Unix()
+		}
+
+		result.Live = true
+		result.Streams = append(result.Streams, stream)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,133 @@
+package onyt
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+type Link struct {
+	URL    string `json:"url"`
+	Host   string `json:"host"`
+	Kind   string `json:"kind"`
+	Label  string `json:"label,omitempty"`
+	Source string `json:"source"`
+}
+
+var (
+	linkRe = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+	linkKinds = map[string]string{
+		"twitch.tv":          "twitch",
+		"twitter.com":        "twitter",
+		"x.com":              "twitter",
+		"instagram.com":      "instagram",
+		"tiktok.com":         "tiktok",
+		"facebook.com":       "facebook",
+		"reddit.com":         "reddit",
+		"github.com":         "github",
+		"discord.gg":         "discord",
+		"discord.com":        "discord",
+		"patreon.com":        "patreon",
+		"ko-fi.com":          "kofi",
+		"paypal.me":          "paypal",
+		"paypal.com":         "paypal",
+		"streamlabs.com":     "donation",
+		"streamelements.com": "donation",
+		"youtube.com":        "youtube",
+		"youtu.be":           "youtube",
+		"amazon.com":         "store",
+		"amzn.to":            "store",
+		"teespring.com":      "store",
+		"creator-spring.com": "store",
+		"etsy.com":           "store",
+		"shopify.com":        "store",
+		"myshopify.com":      "store",
+		"redbubble.com":      "store",
+		"merchbar.com":       "store",
+	}
+)
+
+func linkKind(host string) string {
+	host = strings.TrimPrefix(host, "www.")
+
+	for {
+		if kind, ok := linkKinds[host]; ok {
+			return kind
+		}
+
+		i := strings.IndexByte(host, '.')
+
+		if i < 0 || strings.IndexByte(host[i+1:], '.') < 0 {
+			return "website"
+		}
+
+		host = host[i+1:]
+	}
+}
+
+// ExtractLinks returns the URLs of a text, labelled with the text preceding
+// them on their line.
+func ExtractLinks(text, source string) []*Link {
+	links := make([]*Link, 0)
+
+	for _, line := range strings.Split(text, "\n") {
+		offset := 0
+
+		for _, loc := range linkRe.FindAllStringIndex(line, -1) {
+			raw := strings.TrimRight(line[loc[0]:loc[1]], ".,;:!?)]}")
+
+			u, err := url.Parse(raw)
+
+			if err != nil || u.Host == "" {
+				continue
+			}
+
+			host := strings.ToLower(u.Hostname())
+
+			links = append(links, &Link{
+				URL:    raw,
+				Host:   host,
+				Kind:   linkKind(host),
+				Label:  strings.Trim(line[offset:loc[0]], " \t-–—:|•►▶→>([,"),
+				Source: source,
+			})
+
+			offset = loc[1]
+		}
+	}
+
+	return links
+}
+
+func collectLinks(state *State) []*Link {
+	var links []*Link
+
+	if c := state.Channel; c != nil && c.Snippet != nil {
+		links = append(links, ExtractLinks(c.Snippet.Description, "channel")...)
+	}
+
+	videos := state.LiveVideos
+
+	if len(state.Videos) > 0 {
+		videos = append(videos[:len(videos):len(videos)], state.Videos[0])
+	}
+
+	for _, v := range videos {
+		if v.Snippet != nil {
+			links = append(links, ExtractLinks(v.Snippet.Description, v.Id)...)
+		}
+	}
+
+	seen := make(map[string]bool, len(links))
+	result := make([]*Link, 0, len(links))
+
+	for _, link := range links {
+		if !seen[link.URL] {
+			seen[link.URL] = true
+			result = append(result, link)
+		}
+	}
+
+	return result
+}
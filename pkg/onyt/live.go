@@ -0,0 +1,271 @@
+package onyt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/html"
+)
+
+type LiveStreams struct {
+	VideoIds         []string
+	UpcomingVideoIds []string
+}
+
+type LiveDetector func(ctx context.Context, channelId string) (*LiveStreams, error)
+
+// LiveDetectionSearch uses the search API, costing 100 quota units per call.
+const LiveDetectionSearch = "search"
+
+var (
+	errLiveScrapeFailed = errors.New("live page did not contain a canonical link")
+
+	re = regexp.MustCompile(`(?i)https://www\.youtube\.com/watch\?v=(.+)`)
+
+	// LiveDetectors lists the scraping live detection methods by name, the
+	// search API being handled by the backend itself.
+	LiveDetectors = map[string]LiveDetector{
+		"canonical": DetectCanonicalLive,
+		"innertube": DetectInnertubeLive,
+	}
+)
+
+func newLiveStreams(videoIds ...string) *LiveStreams {
+	streams := &LiveStreams{
+		VideoIds:         make([]string, 0),
+		UpcomingVideoIds: make([]string, 0),
+	}
+
+	for _, id := range videoIds {
+		if id != "" {
+			streams.VideoIds = append(streams.VideoIds, id)
+		}
+	}
+
+	return streams
+}
+
+// ParseLiveDetection parses a comma-separated list of live detection methods,
+// tried in order until one succeeds. "off" disables live detection, leaving
+// it to the uploads playlist.
+func ParseLiveDetection(value string) ([]string, error) {
+	methods := make([]string, 0)
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+
+		switch {
+		case name == "off":
+			if value != name {
+				return nil, errors.New("live detection \"off\" can't be combined with other methods")
+			}
+
+			return methods, nil
+
+		case name != LiveDetectionSearch && LiveDetectors[name] == nil:
+			return nil, fmt.Errorf("unknown live detection method: %s", name)
+		}
+
+		methods = append(methods, name)
+	}
+
+	return methods, nil
+}
+
+func (b *YouTubeBackend) detectLive(ctx context.Context, method string, channelId string) (*LiveStreams, error) {
+	if method != LiveDetectionSearch {
+		return LiveDetectors[method](ctx, channelId)
+	}
+
+	if b.Service == nil {
+		return nil, ErrNoAPIKey
+	}
+
+	liveVideoId, err := b.SearchFallback.Fetch(ctx, b.Service, b.Quota, channelId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newLiveStreams(liveVideoId), nil
+}
+
+type liveDetectionKey struct{}
+
+// withLiveDetection overrides the live detection methods of the backend for
+// a channel.
+func withLiveDetection(ctx context.Context, methods []string) context.Context {
+	return context.WithValue(ctx, liveDetectionKey{}, methods)
+}
+
+func (b *YouTubeBackend) DetectLive(ctx context.Context, channelId string) (*LiveStreams, error) {
+	ctx = withLocale(ctx, b.Locale)
+
+	methods, ok := ctx.Value(liveDetectionKey{}).([]string)
+
+	if !ok {
+		methods = b.LiveDetection
+	}
+
+	var lastErr error
+
+	for i, method := range methods {
+		streams, err := b.detectLive(ctx, method, channelId)
+
+		if err == nil {
+			return streams, nil
+		}
+
+		if i+1 < len(methods) {
+			log.Warn().Err(err).Str("method", method).Str("next", methods[i+1]).Msg("Unable to detect live, failing over")
+
+			DefaultMetrics.CountFallback("live detection/" + methods[i+1])
+		}
+
+		lastErr = err
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return newLiveStreams(), nil
+}
+
+func DetectCanonicalLive(ctx context.Context, channelId string) (*LiveStreams, error) {
+	liveVideoId, err := fetchLiveVideoId(ctx, channelId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newLiveStreams(liveVideoId), nil
+}
+
+// ageGatedStatuses are the playability statuses of the live pages hidden
+// behind an age check when signed out, which have no canonical link.
+var ageGatedStatuses = map[string]bool{
+	"LOGIN_REQUIRED":            true,
+	"AGE_CHECK_REQUIRED":        true,
+	"AGE_VERIFICATION_REQUIRED": true,
+}
+
+func fetchLiveVideoId(ctx context.Context, channelId string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://www.youtube.com/channel/%s/live", channelId), nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("accept-language", localeFrom(ctx).acceptLanguage())
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if consentRedirect(resp) {
+		return "", errConsentRequired
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &StatusError{"live page", resp.StatusCode, resp.Status}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return "", err
+	}
+
+	liveVideoId, err := canonicalVideoId(body)
+
+	// The age-restricted streams would otherwise appear offline, their
+	// embed still linking to them.
+	if liveVideoId == "" && ageGated(body) {
+		DefaultMetrics.CountFallback("live detection/embed")
+
+		return fetchEmbedLiveVideoId(ctx, channelId)
+	}
+
+	return liveVideoId, err
+}
+
+// fetchEmbedLiveVideoId returns the ID of the live video of the channel from
+// its embedded player, which isn't age-gated.
+func fetchEmbedLiveVideoId(ctx context.Context, channelId string) (string, error) {
+	body, err := fetchPage(ctx, "https://www.youtube.com/embed/live_stream?"+url.Values{"channel": {channelId}}.Encode())
+
+	if err != nil {
+		return "", err
+	}
+
+	return canonicalVideoId(body)
+}
+
+// ageGated tells whether the player of the page asks the viewer to confirm
+// their age.
+func ageGated(body []byte) bool {
+	sm := ytInitialPlayerResponseRe.FindSubmatch(body)
+
+	if sm == nil {
+		return false
+	}
+
+	var player struct {
+		PlayabilityStatus struct {
+			Status string `json:"status"`
+		} `json:"playabilityStatus"`
+	}
+
+	if err := json.Unmarshal(sm[1], &player); err != nil {
+		return false
+	}
+
+	return ageGatedStatuses[player.PlayabilityStatus.Status]
+}
+
+// canonicalVideoId returns the ID of the video the canonical link of the page
+// points to, none when it points elsewhere.
+func canonicalVideoId(body []byte) (string, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+
+	if err != nil {
+		return "", err
+	}
+
+	sel, err := cascadia.Parse("link[rel='canonical']")
+
+	if err != nil {
+		return "", err
+	}
+
+	node := cascadia.Query(doc, sel)
+
+	if node == nil {
+		return "", errLiveScrapeFailed
+	}
+
+	for _, v := range node.Attr {
+		if v.Key == "href" {
+			if sm := re.FindStringSubmatch(v.Val); len(sm) > 0 {
+				return sm[1], nil
+			}
+		}
+	}
+
+	return "", nil
+}
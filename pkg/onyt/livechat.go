@@ -0,0 +1,277 @@
+package onyt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/youtube/v3"
+)
+
+var liveChatEventTypes = map[string]string{
+	"superChatEvent":           "super_chat",
+	"superStickerEvent":        "super_sticker",
+	"newSponsorEvent":          "new_member",
+	"memberMilestoneChatEvent": "member_milestone",
+	"membershipGiftingEvent":   "membership_gift",
+}
+
+type LiveChatEvent struct {
+	Type            string `json:"type"`
+	VideoId         string `json:"videoId"`
+	Author          string `json:"author"`
+	AuthorChannelId string `json:"authorChannelId"`
+	Message         string `json:"message,omitempty"`
+	Amount          string `json:"amount,omitempty"`
+	AmountMicros    uint64 `json:"amountMicros,omitempty"`
+	Currency        string `json:"currency,omitempty"`
+	Level           string `json:"level,omitempty"`
+	Months          int64  `json:"months,omitempty"`
+	GiftCount       int64  `json:"giftCount,omitempty"`
+	PublishedAt     int64  `json:"publishedAt"`
+}
+
+type LiveChatTotals struct {
+	VideoId           string             `json:"videoId"`
+	SuperChats        int64              `json:"superChats"`
+	SuperStickers     int64              `json:"superStickers"`
+	NewMembers        int64              `json:"newMembers"`
+	MemberMilestones  int64              `json:"memberMilestones"`
+	GiftedMemberships int64              `json:"giftedMemberships"`
+	Amounts           map[string]float64 `json:"amounts"`
+}
+
+// LiveChatTracker polls the chat of the live stream, with an OAuth
+// authorized service, for Super Chats and memberships. Each poll costs about
+// five quota units.
+type LiveChatTracker struct {
+	Service  *youtube.Service
+	Quota    *QuotaMeter
+	Interval time.Duration
+	Pause    *Pause
+
+	mu     sync.RWMutex
+	chatId string
+	cancel context.CancelFunc
+	totals *LiveChatTotals
+	recent []*LiveChatEvent
+}
+
+func NewLiveChatTracker(src *youtube.Service, quota *QuotaMeter, interval time.Duration) *LiveChatTracker {
+	return &LiveChatTracker{
+		Service:  src,
+		Quota:    quota,
+		Interval: interval,
+		totals:   newLiveChatTotals(""),
+		recent:   make([]*LiveChatEvent, 0),
+	}
+}
+
+func newLiveChatTotals(videoId string) *LiveChatTotals {
+	return &LiveChatTotals{
+		VideoId: videoId,
+		Amounts: make(map[string]float64),
+	}
+}
+
+// Track starts polling the chat of the live video, stopping the previous
+// poll. An empty chat ID stops polling.
+func (t *LiveChatTracker) Track(videoId, chatId string, emit func(eventType string, data any)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if chatId == t.chatId {
+		return
+	}
+
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	t.chatId = chatId
+
+	if chatId == "" {
+		return
+	}
+
+	if t.totals.VideoId != videoId {
+		t.totals = newLiveChatTotals(videoId)
+		t.recent = make([]*LiveChatEvent, 0)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	go t.poll(ctx, videoId, chatId, emit)
+}
+
+func (t *LiveChatTracker) poll(ctx context.Context, videoId, chatId string, emit func(eventType string, data any)) {
+	var (
+		pageToken string
+		backlog   = true
+	)
+
+	for {
+		if t.Pause.Paused() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(t.Interval):
+			}
+
+			continue
+		}
+
+		t.Quota.Add(5)
+
+		call := t.Service.LiveChatMessages.List(chatId, []string{"snippet", "authorDetails"}).
+			MaxResults(2000)
+
+		if pageToken != "" {
+			call.PageToken(pageToken)
+		}
+
+		resp, err := call.Context(ctx).Do()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		delay := t.Interval
+
+		if err != nil {
+			log.Warn().Err(err).Str("video", videoId).Msg("Unable to poll live chat")
+		} else {
+			pageToken = resp.NextPageToken
+
+			for _, message := range resp.Items {
+				if evt := t.record(videoId, chatId, message); evt != nil && !backlog {
+					emit(evt.Type, evt)
+				}
+			}
+
+			backlog = false
+
+			if polling := time.Duration(resp.PollingIntervalMillis) * time.Millisecond; polling > delay {
+				delay = polling
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (t *LiveChatTracker) record(videoId, chatId string, message *youtube.LiveChatMessage) *LiveChatEvent {
+	snippet := message.Snippet
+
+	if snippet == nil {
+		return nil
+	}
+
+	eventType, ok := liveChatEventTypes[snippet.Type]
+
+	if !ok {
+		return nil
+	}
+
+	evt := &LiveChatEvent{
+		Type:    eventType,
+		VideoId: videoId,
+	}
+
+	if author := message.AuthorDetails; author != nil {
+		evt.Author = author.DisplayName
+		evt.AuthorChannelId = author.ChannelId
+	}
+
+	if at, err := time.Parse(time.RFC3339, snippet.PublishedAt); err == nil {
+		evt.PublishedAt = at.Unix()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.chatId != chatId {
+		return nil
+	}
+
+	totals := t.totals
+
+	switch {
+	case snippet.SuperChatDetails != nil:
+		d := snippet.SuperChatDetails
+
+		evt.Message = d.UserComment
+		evt.Amount = d.AmountDisplayString
+		evt.AmountMicros = d.AmountMicros
+		evt.Currency = d.Currency
+
+		totals.SuperChats++
+		totals.Amounts[d.Currency] += float64(d.AmountMicros) / 1e6
+
+	case snippet.SuperStickerDetails != nil:
+		d := snippet.SuperStickerDetails
+
+		evt.Amount = d.AmountDisplayString
+		evt.AmountMicros = d.AmountMicros
+		evt.Currency = d.Currency
+
+		totals.SuperStickers++
+		totals.Amounts[d.Currency] += float64(d.AmountMicros) / 1e6
+
+	case snippet.NewSponsorDetails != nil:
+		evt.Level = snippet.NewSponsorDetails.MemberLevelName
+
+		totals.NewMembers++
+
+	case snippet.MemberMilestoneChatDetails != nil:
+		d := snippet.MemberMilestoneChatDetails
+
+		evt.Message = d.UserComment
+		evt.Level = d.MemberLevelName
+		evt.Months = d.MemberMonth
+
+		totals.MemberMilestones++
+
+	case snippet.MembershipGiftingDetails != nil:
+		d := snippet.MembershipGiftingDetails
+
+		evt.Level = d.GiftMembershipsLevelName
+		evt.GiftCount = d.GiftMembershipsCount
+
+		totals.GiftedMemberships += d.GiftMembershipsCount
+
+	default:
+		return nil
+	}
+
+	t.recent = append(t.recent, evt)
+
+	if len(t.recent) > 100 {
+		t.recent = t.recent[len(t.recent)-100:]
+	}
+
+	return evt
+}
+
+// Totals returns the totals of the current, or last, live stream along with
+// its most recent events.
+func (t *LiveChatTracker) Totals() (*LiveChatTotals, []*LiveChatEvent) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	totals := *t.totals
+	totals.Amounts = make(map[string]float64, len(t.totals.Amounts))
+
+	for currency, amount := range t.totals.Amounts {
+		totals.Amounts[currency] = amount
+	}
+
+	return &totals, append(make([]*LiveChatEvent, 0, len(t.recent)), t.recent...)
+}
@@ -0,0 +1,46 @@
+package onyt
+
+import (
+	"context"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// Locale selects the language, and region, of the returned metadata.
+type Locale struct {
+	Language string
+	Region   string
+}
+
+type localeKey struct{}
+
+func withLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+func localeFrom(ctx context.Context) Locale {
+	locale, _ := ctx.Value(localeKey{}).(Locale)
+	return locale
+}
+
+func (l Locale) acceptLanguage() string {
+	if l.Language == "" {
+		return "en"
+	}
+
+	return l.Language
+}
+
+func localizeChannel(channel *youtube.Channel) {
+	if s := channel.Snippet; s != nil && s.Localized != nil {
+		s.Title = s.Localized.Title
+		s.Description = s.Localized.Description
+	}
+}
+
+func localizeVideo(video *youtube.Video) {
+	if s := video.Snippet; s != nil && s.Localized != nil {
+		s.Title = s.Localized.Title
+		s.Description = s.Localized.Description
+	}
+}
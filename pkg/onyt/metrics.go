@@ -0,0 +1,144 @@
+package onyt
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// StageStats are the durations of a stage of the refresh pipeline.
+type StageStats struct {
+	Count        int64   `json:"count"`
+	Errors       int64   `json:"errors"`
+	TotalSeconds float64 `json:"totalSeconds"`
+	LastSeconds  float64 `json:"lastSeconds"`
+	MaxSeconds   float64 `json:"maxSeconds"`
+}
+
+// PipelineStats are the internals of the refresh pipeline since startup.
+type PipelineStats struct {
+	Stages    map[string]*StageStats `json:"stages"`
+	Errors    map[string]int64       `json:"errors"`
+	Fallbacks map[string]int64       `json:"fallbacks"`
+	Events    map[string]int64       `json:"events"`
+}
+
+// PipelineMetrics counts the stage durations, the errors by reason, the
+// fallback activations and the emitted events of the refresh pipeline.
+type PipelineMetrics struct {
+	mu        sync.Mutex
+	stages    map[string]*StageStats
+	errors    map[string]int64
+	fallbacks map[string]int64
+	events    map[string]int64
+}
+
+func NewPipelineMetrics() *PipelineMetrics {
+	return &PipelineMetrics{
+		stages:    make(map[string]*StageStats),
+		errors:    make(map[string]int64),
+		fallbacks: make(map[string]int64),
+		events:    make(map[string]int64),
+	}
+}
+
+// DefaultMetrics observes the pipelines of every poller.
+var DefaultMetrics = NewPipelineMetrics()
+
+// ObserveStage records the duration of a stage, failed when err is set.
+func (m *PipelineMetrics) ObserveStage(name string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.stages[name]
+
+	if !ok {
+		stats = new(StageStats)
+		m.stages[name] = stats
+	}
+
+	seconds := d.Seconds()
+
+	stats.Count++
+	stats.TotalSeconds += seconds
+	stats.LastSeconds = seconds
+
+	if seconds > stats.MaxSeconds {
+		stats.MaxSeconds = seconds
+	}
+
+	if err != nil {
+		stats.Errors++
+	}
+}
+
+// CountError records a failed refresh by the reason of its error.
+func (m *PipelineMetrics) CountError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.errors[ErrorReason(err)]++
+}
+
+// CountFallback records that the named fallback was tried after a failure.
+func (m *PipelineMetrics) CountFallback(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.fallbacks[name]++
+}
+
+func (m *PipelineMetrics) CountEvent(eventType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events[eventType]++
+}
+
+func copyCounts(counts map[string]int64) map[string]int64 {
+	result := make(map[string]int64, len(counts))
+
+	for key, count := range counts {
+		result[key] = count
+	}
+
+	return result
+}
+
+// Stats returns a copy of the metrics.
+func (m *PipelineMetrics) Stats() *PipelineStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := &PipelineStats{
+		Stages:    make(map[string]*StageStats, len(m.stages)),
+		Errors:    copyCounts(m.errors),
+		Fallbacks: copyCounts(m.fallbacks),
+		Events:    copyCounts(m.events),
+	}
+
+	for name, stage := range m.stages {
+		s := *stage
+		stats.Stages[name] = &s
+	}
+
+	return stats
+}
+
+// ErrorReason returns the reason given by the YouTube API for the error, its
+// kind otherwise.
+func ErrorReason(err error) string {
+	var apiErr *googleapi.Error
+
+	if errors.As(err, &apiErr) {
+		for _, item := range apiErr.Errors {
+			if item.Reason != "" {
+				return item.Reason
+			}
+		}
+	}
+
+	return ClassifyError(err)
+}
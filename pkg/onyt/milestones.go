@@ -0,0 +1,152 @@
+package onyt
+
+import (
+	"sort"
+	"sync"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+type MilestoneRule struct {
+	Every      uint64   `json:"every" yaml:"every"`
+	Thresholds []uint64 `json:"thresholds" yaml:"thresholds"`
+}
+
+// Highest returns the highest milestone reached by the value.
+func (r MilestoneRule) Highest(value uint64) uint64 {
+	var result uint64
+
+	if r.Every > 0 {
+		result = value / r.Every * r.Every
+	}
+
+	for _, threshold := range r.Thresholds {
+		if threshold <= value && threshold > result {
+			result = threshold
+		}
+	}
+
+	return result
+}
+
+type Milestone struct {
+	Metric    string `json:"metric"`
+	Milestone uint64 `json:"milestone"`
+	Value     uint64 `json:"value"`
+}
+
+// MilestoneStore persists the last milestone reached by each channel metric.
+type MilestoneStore interface {
+	LastMilestone(channelId, metric string) (uint64, bool, error)
+	SetMilestone(channelId, metric string, milestone uint64) error
+}
+
+type memoryMilestoneStore struct {
+	mu     sync.Mutex
+	values map[string]uint64
+}
+
+func (s *memoryMilestoneStore) LastMilestone(channelId, metric string) (uint64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.values[channelId+"/"+metric]
+
+	return value, ok, nil
+}
+
+func (s *memoryMilestoneStore) SetMilestone(channelId, metric string, milestone uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.values[channelId+"/"+metric] = milestone
+
+	return nil
+}
+
+// MilestoneTracker reports the subscriber, view and video count milestones
+// crossed by a channel. The first observation of a metric only records its
+// current milestone.
+type MilestoneTracker struct {
+	Rules map[string]MilestoneRule
+	Store MilestoneStore
+}
+
+func NewMilestoneTracker(rules map[string]MilestoneRule, store MilestoneStore) *MilestoneTracker {
+	if store == nil {
+		store = &memoryMilestoneStore{
+			values: make(map[string]uint64),
+		}
+	}
+
+	return &MilestoneTracker{
+		Rules: rules,
+		Store: store,
+	}
+}
+
+// ChannelMetrics returns the public statistics of the channel by name.
+func ChannelMetrics(channel *youtube.Channel) map[string]uint64 {
+	if channel == nil || channel.Statistics == nil {
+		return nil
+	}
+
+	metrics := map[string]uint64{
+		"views":  channel.Statistics.ViewCount,
+		"videos": channel.Statistics.VideoCount,
+	}
+
+	if !channel.Statistics.HiddenSubscriberCount {
+		metrics["subscribers"] = channel.Statistics.SubscriberCount
+	}
+
+	return metrics
+}
+
+func (t *MilestoneTracker) Track(channelId string, channel *youtube.Channel) ([]*Milestone, error) {
+	metrics := ChannelMetrics(channel)
+	names := make([]string, 0, len(metrics))
+
+	for name := range metrics {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	reached := make([]*Milestone, 0)
+
+	for _, name := range names {
+		rule, ok := t.Rules[name]
+
+		if !ok {
+			continue
+		}
+
+		value := metrics[name]
+		highest := rule.Highest(value)
+
+		last, ok, err := t.Store.LastMilestone(channelId, name)
+
+		if err != nil {
+			return reached, err
+		}
+
+		if ok && highest <= last {
+			continue
+		}
+
+		if err := t.Store.SetMilestone(channelId, name, highest); err != nil {
+			return reached, err
+		}
+
+		if ok {
+			reached = append(reached, &Milestone{
+				Metric:    name,
+				Milestone: highest,
+				Value:     value,
+			})
+		}
+	}
+
+	return reached, nil
+}
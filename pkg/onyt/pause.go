@@ -0,0 +1,35 @@
+package onyt
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Pause suspends the API consumption of the scheduler and the trackers
+// sharing it while set, the served state being kept as is.
+type Pause struct {
+	since atomic.Int64
+}
+
+// Set pauses or resumes, doing nothing when already in that state.
+func (p *Pause) Set(paused bool) {
+	if paused {
+		p.since.CompareAndSwap(0, time.Now().Unix())
+	} else {
+		p.since.Store(0)
+	}
+}
+
+// Paused tells whether the API consumption is suspended, never when nil.
+func (p *Pause) Paused() bool {
+	return p != nil && p.since.Load() != 0
+}
+
+// Since returns when the API consumption was suspended, zero when running.
+func (p *Pause) Since() int64 {
+	if p == nil {
+		return 0
+	}
+
+	return p.since.Load()
+}
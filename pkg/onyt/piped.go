@@ -0,0 +1,222 @@
+package onyt
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/api/youtube/v3"
+)
+
+type pipedStreamItem struct {
+	URL      string `json:"url"`
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Uploaded int64  `json:"uploaded"`
+	Duration int64  `json:"duration"`
+	Views    int64  `json:"views"`
+}
+
+type pipedChannel struct {
+	Id              string             `json:"id"`
+	Name            string             `json:"name"`
+	AvatarURL       string             `json:"avatarUrl"`
+	Description     string             `json:"description"`
+	SubscriberCount int64              `json:"subscriberCount"`
+	RelatedStreams  []*pipedStreamItem `json:"relatedStreams"`
+}
+
+type pipedStream struct {
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	UploadDate   string   `json:"uploadDate"`
+	Uploader     string   `json:"uploader"`
+	UploaderURL  string   `json:"uploaderUrl"`
+	ThumbnailURL string   `json:"thumbnailUrl"`
+	Duration     int64    `json:"duration"`
+	Views        int64    `json:"views"`
+	Likes        int64    `json:"likes"`
+	Livestream   bool     `json:"livestream"`
+	Tags         []string `json:"tags"`
+}
+
+type PipedBackend struct {
+	baseURL string
+}
+
+func NewPipedBackend(baseURL string) *PipedBackend {
+	return &PipedBackend{
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (b *PipedBackend) Name() string {
+	return "piped"
+}
+
+func (b *PipedBackend) endpoint(format string, args ...any) string {
+	return b.baseURL + fmt.Sprintf(format, args...)
+}
+
+func (b *PipedBackend) fetchChannel(ctx context.Context, channelId string) (*pipedChannel, error) {
+	var data pipedChannel
+
+	if err := getJSON(ctx, b.endpoint("/channel/%s", url.PathEscape(channelId)), &data); err != nil {
+		return nil, err
+	}
+
+	if data.Id == "" {
+		return nil, ErrChannelNotFound
+	}
+
+	return &data, nil
+}
+
+func (b *PipedBackend) FetchChannel(ctx context.Context, channelId string) (*youtube.Channel, error) {
+	data, err := b.fetchChannel(ctx, channelId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &youtube.Channel{
+		Id: data.Id,
+		ContentDetails: &youtube.ChannelContentDetails{
+			RelatedPlaylists: &youtube.ChannelContentDetailsRelatedPlaylists{
+				Uploads: uploadsPlaylistId(data.Id),
+			},
+		},
+		Snippet: &youtube.ChannelSnippet{
+			Title:       data.Name,
+			Description: data.Description,
+			Thumbnails: &youtube.ThumbnailDetails{
+				Default: &youtube.Thumbnail{
+					Url: data.AvatarURL,
+				},
+			},
+		},
+		Statistics: &youtube.ChannelStatistics{
+			SubscriberCount: nonNegative(data.SubscriberCount),
+		},
+	}, nil
+}
+
+func (b *PipedBackend) FetchUploadIds(ctx context.Context, channel *youtube.Channel) ([]string, error) {
+	data, err := b.fetchChannel(ctx, channel.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	uploadIds := make([]string, 0, len(data.RelatedStreams))
+
+	for _, item := range data.RelatedStreams {
+		if id := pipedVideoId(item.URL); id != "" {
+			uploadIds = append(uploadIds, id)
+		}
+	}
+
+	return uploadIds, nil
+}
+
+func (b *PipedBackend) FetchVideos(ctx context.Context, videoIds []string) ([]*youtube.Video, error) {
+	videos := make([]*youtube.Video, len(videoIds))
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.SetLimit(4)
+
+	for i, id := range videoIds {
+		i, id := i, id
+
+		g.Go(func() error {
+			var data pipedStream
+
+			if err := getJSON(ctx, b.endpoint("/streams/%s", url.PathEscape(id)), &data); err != nil {
+				return err
+			}
+
+			videos[i] = data.toVideo(id)
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return videos, nil
+}
+
+func (b *PipedBackend) DetectLive(ctx context.Context, channelId string) (*LiveStreams, error) {
+	data, err := b.fetchChannel(ctx, channelId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	streams := newLiveStreams()
+
+	for _, item := range data.RelatedStreams {
+		if item.Duration < 0 {
+			streams.VideoIds = append(streams.VideoIds, pipedVideoId(item.URL))
+		}
+	}
+
+	return streams, nil
+}
+
+func nonNegative(n int64) uint64 {
+	if n < 0 {
+		return 0
+	}
+
+	return uint64(n)
+}
+
+func pipedVideoId(value string) string {
+	u, err := url.Parse(value)
+
+	if err != nil {
+		return ""
+	}
+
+	return u.Query().Get("v")
+}
+
+func (s *pipedStream) toVideo(videoId string) *youtube.Video {
+	video := &youtube.Video{
+		Id: videoId,
+		ContentDetails: &youtube.VideoContentDetails{
+			Duration: fmt.Sprintf("PT%dS", nonNegative(s.Duration)),
+		},
+		Snippet: &youtube.VideoSnippet{
+			ChannelId:    strings.TrimPrefix(s.UploaderURL, "/channel/"),
+			ChannelTitle: s.Uploader,
+			Title:        s.Title,
+			Description:  s.Description,
+			PublishedAt:  s.UploadDate,
+			Tags:         s.Tags,
+			Thumbnails: &youtube.ThumbnailDetails{
+				High: &youtube.Thumbnail{
+					Url: s.ThumbnailURL,
+				},
+			},
+			LiveBroadcastContent: "none",
+		},
+		Statistics: &youtube.VideoStatistics{
+			ViewCount: nonNegative(s.Views),
+			LikeCount: nonNegative(s.Likes),
+		},
+	}
+
+	if s.Livestream {
+		video.Snippet.LiveBroadcastContent = "live"
+		video.LiveStreamingDetails = &youtube.VideoLiveStreamingDetails{}
+	}
+
+	return video
+}
@@ -0,0 +1,177 @@
+package onyt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/youtube/v3"
+)
+
+// PlaylistItem is a video added to a monitored playlist.
+type PlaylistItem struct {
+	PlaylistId    string `json:"playlistId"`
+	PlaylistTitle string `json:"playlistTitle"`
+	Video         *Video `json:"video"`
+}
+
+// PlaylistMonitor follows arbitrary playlists, such as a series curated apart
+// from the uploads, emitting playlist_item_added once a video is added to
+// one of them.
+type PlaylistMonitor struct {
+	Service *youtube.Service
+	Quota   *QuotaMeter
+	Events  *EventBus
+	Pause   *Pause
+	Locale  Locale
+	Ids     []string
+
+	mu        sync.RWMutex
+	playlists map[string]*Playlist
+}
+
+func NewPlaylistMonitor(src *youtube.Service, quota *QuotaMeter, events *EventBus, ids []string) *PlaylistMonitor {
+	return &PlaylistMonitor{
+		Service:   src,
+		Quota:     quota,
+		Events:    events,
+		Ids:       ids,
+		playlists: make(map[string]*Playlist),
+	}
+}
+
+func (m *PlaylistMonitor) emit(playlist *Playlist, video *Video) {
+	log.Info().Str("type", "playlist_item_added").Str("channel", playlist.ChannelId).Str("playlist", playlist.Id).Msg("Event emitted")
+
+	DefaultMetrics.CountEvent("playlist_item_added")
+
+	if m.Events == nil {
+		return
+	}
+
+	m.Events.Emit(Event{
+		Type:      "playlist_item_added",
+		ChannelId: playlist.ChannelId,
+		Time:      time.Now(),
+		Data: &PlaylistItem{
+			PlaylistId:    playlist.Id,
+			PlaylistTitle: playlist.Title,
+			Video:         video,
+		},
+	})
+}
+
+// Run refreshes the playlists at the given interval until the context is
+// done.
+func (m *PlaylistMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if !m.Pause.Paused() {
+			if err := m.Refresh(ctx); err != nil && ctx.Err() == nil {
+				log.Warn().Err(err).Msg("Unable to refresh monitored playlists")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh fetches the playlists along with their videos, emitting the videos
+// added since the previous refresh. The first one only records them.
+func (m *PlaylistMonitor) Refresh(ctx context.Context) error {
+	items := make([]*youtube.Playlist, 0, len(m.Ids))
+
+	for start := 0; start < len(m.Ids); start += 50 {
+		end := start + 50
+
+		if end > len(m.Ids) {
+			end = len(m.Ids)
+		}
+
+		m.Quota.Add(1)
+
+		call := m.Service.Playlists.List([]string{"contentDetails", "snippet"}).
+			Id(m.Ids[start:end]...).
+			MaxResults(50)
+
+		if m.Locale.Language != "" {
+			call.Hl(m.Locale.Language)
+		}
+
+		resp, err := call.Context(ctx).Do()
+
+		if err != nil {
+			return err
+		}
+
+		items = append(items, resp.Items...)
+	}
+
+	for _, item := range items {
+		playlist := toPlaylist(item, m.Locale)
+		videos, err := fetchPlaylistVideos(ctx, m.Service, m.Quota, m.Locale, playlist.Id)
+
+		if err != nil {
+			log.Warn().Err(err).Str("playlist", playlist.Id).Msg("Unable to fetch playlist videos")
+			continue
+		}
+
+		playlist.Videos = videos
+
+		m.mu.Lock()
+		previous, ok := m.playlists[playlist.Id]
+		m.playlists[playlist.Id] = playlist
+		m.mu.Unlock()
+
+		if !ok {
+			continue
+		}
+
+		known := make(map[string]bool, len(previous.Videos))
+
+		for _, v := range previous.Videos {
+			known[v.Id] = true
+		}
+
+		for _, v := range playlist.Videos {
+			if !known[v.Id] {
+				m.emit(playlist, v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Playlist returns the monitored playlist, once fetched.
+func (m *PlaylistMonitor) Playlist(id string) (*Playlist, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	playlist, ok := m.playlists[id]
+
+	return playlist, ok
+}
+
+// Playlists returns the fetched monitored playlists, in the configured order.
+func (m *PlaylistMonitor) Playlists() []*Playlist {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*Playlist, 0, len(m.playlists))
+
+	for _, id := range m.Ids {
+		if playlist, ok := m.playlists[id]; ok {
+			result = append(result, playlist)
+		}
+	}
+
+	return result
+}
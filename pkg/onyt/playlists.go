@@ -0,0 +1,229 @@
+package onyt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+const maxPlaylistItems = 200
+
+type Playlist struct {
+	Id           string   `json:"id"`
+	ChannelId    string   `json:"channelId,omitempty"`
+	URL          string   `json:"url"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	ThumbnailURL string   `json:"thumbnailUrl"`
+	ItemCount    int64    `json:"itemCount"`
+	PublishedAt  int64    `json:"publishedAt"`
+	Videos       []*Video `json:"videos,omitempty"`
+}
+
+// PlaylistTracker lists the public playlists of a channel at most once per
+// interval, expanding the configured ones into their videos.
+type PlaylistTracker struct {
+	Service  *youtube.Service
+	Quota    *QuotaMeter
+	Interval time.Duration
+	Expand   []string
+	Locale   Locale
+
+	mu        sync.RWMutex
+	lastAt    time.Time
+	playlists []*Playlist
+}
+
+func NewPlaylistTracker(src *youtube.Service, quota *QuotaMeter, interval time.Duration, expand []string) *PlaylistTracker {
+	return &PlaylistTracker{
+		Service:   src,
+		Quota:     quota,
+		Interval:  interval,
+		Expand:    expand,
+		playlists: make([]*Playlist, 0),
+	}
+}
+
+func toPlaylist(item *youtube.Playlist, locale Locale) *Playlist {
+	playlist := &Playlist{
+		Id:  item.Id,
+		URL: fmt.Sprintf("https://www.youtube.com/playlist?list=%s", item.Id),
+	}
+
+	if item.Snippet != nil {
+		playlist.ChannelId = item.Snippet.ChannelId
+		playlist.Title = item.Snippet.Title
+		playlist.Description = item.Snippet.Description
+
+		if l := item.Snippet.Localized; l != nil && locale.Language != "" {
+			playlist.Title = l.Title
+			playlist.Description = l.Description
+		}
+
+		playlist.ThumbnailURL = BestThumbnail(item.Snippet.Thumbnails)
+
+		if at, err := time.Parse(time.RFC3339, item.Snippet.PublishedAt); err == nil {
+			playlist.PublishedAt = at.Unix()
+		}
+	}
+
+	if item.ContentDetails != nil {
+		playlist.ItemCount = item.ContentDetails.ItemCount
+	}
+
+	return playlist
+}
+
+func (t *PlaylistTracker) fetchPlaylists(ctx context.Context, channelId string) ([]*Playlist, error) {
+	playlists := make([]*Playlist, 0)
+
+	call := t.Service.Playlists.List([]string{"contentDetails", "snippet"}).
+		ChannelId(channelId).
+		MaxResults(50)
+
+	if t.Locale.Language != "" {
+		call.Hl(t.Locale.Language)
+	}
+
+	for {
+		t.Quota.Add(1)
+
+		resp, err := call.Context(ctx).Do()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range resp.Items {
+			playlists = append(playlists, toPlaylist(item, t.Locale))
+		}
+
+		if resp.NextPageToken == "" {
+			return playlists, nil
+		}
+
+		call.PageToken(resp.NextPageToken)
+	}
+}
+
+// fetchPlaylistVideos returns the first maxPlaylistItems videos of the
+// playlist, in order.
+func fetchPlaylistVideos(ctx context.Context, service *youtube.Service, quota *QuotaMeter, locale Locale, playlistId string) ([]*Video, error) {
+	videoIds := make([]string, 0)
+
+	call := service.PlaylistItems.List([]string{"contentDetails"}).
+		PlaylistId(playlistId).
+		MaxResults(50)
+
+	for len(videoIds) < maxPlaylistItems {
+		quota.Add(1)
+
+		resp, err := call.Context(ctx).Do()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range resp.Items {
+			videoIds = append(videoIds, item.ContentDetails.VideoId)
+		}
+
+		if resp.NextPageToken == "" {
+			break
+		}
+
+		call.PageToken(resp.NextPageToken)
+	}
+
+	videos := make([]*youtube.Video, 0, len(videoIds))
+
+	for start := 0; start < len(videoIds); start += 50 {
+		end := start + 50
+
+		if end > len(videoIds) {
+			end = len(videoIds)
+		}
+
+		quota.Add(1)
+
+		call := service.Videos.List([]string{"contentDetails", "snippet", "statistics"}).
+			Id(videoIds[start:end]...)
+
+		if locale.Language != "" {
+			call.Hl(locale.Language)
+		}
+
+		resp, err := call.Context(ctx).Do()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if locale.Language != "" {
+			for _, v := range resp.Items {
+				localizeVideo(v)
+			}
+		}
+
+		videos = append(videos, resp.Items...)
+	}
+
+	return WrapVideos(videos), nil
+}
+
+// Refresh updates the playlists once the interval elapsed since the last
+// attempt.
+func (t *PlaylistTracker) Refresh(ctx context.Context, channelId string) error {
+	t.mu.RLock()
+	due := time.Since(t.lastAt) >= t.Interval
+	t.mu.RUnlock()
+
+	if !due || t.Service == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	t.lastAt = time.Now()
+	t.mu.Unlock()
+
+	playlists, err := t.fetchPlaylists(ctx, channelId)
+
+	if err != nil {
+		return err
+	}
+
+	byId := make(map[string]*Playlist, len(playlists))
+
+	for _, playlist := range playlists {
+		byId[playlist.Id] = playlist
+	}
+
+	for _, id := range t.Expand {
+		playlist, ok := byId[id]
+
+		if !ok {
+			continue
+		}
+
+		if playlist.Videos, err = fetchPlaylistVideos(ctx, t.Service, t.Quota, t.Locale, id); err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.playlists = playlists
+
+	return nil
+}
+
+func (t *PlaylistTracker) Playlists() []*Playlist {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.playlists
+}
@@ -0,0 +1,720 @@
+package onyt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/youtube/v3"
+)
+
+var tracer = otel.Tracer("github.com/seldszar/onyt/pkg/onyt")
+
+func traced[T any](ctx context.Context, name string, fn func(ctx context.Context) (T, error)) (T, error) {
+	ctx, span := tracer.Start(ctx, name)
+	defer span.End()
+
+	start := time.Now()
+	result, err := fn(ctx)
+
+	DefaultMetrics.ObserveStage(name, time.Since(start), err)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return result, err
+}
+
+// Poller refreshes the state of a YouTube channel and emits events when it
+// changes.
+type Poller struct {
+	ChannelId  string
+	Client     *Client
+	Store      *StateStore
+	Events     *EventBus
+	Holodex    *HolodexClient
+	Viewers    *ViewerHistory
+	Sessions   *SessionStore
+	Tombstones *TombstoneStore
+	Errors     *ErrorStats
+	Milestones *MilestoneTracker
+	Keywords   *KeywordMatcher
+	Comments   *CommentFetcher
+	Community  *CommunityTracker
+	Playlists  *PlaylistTracker
+	Sections   *SectionTracker
+	Games      *GameTracker
+	LiveChat   *LiveChatTracker
+	Pause      *Pause
+
+	// SponsorBlock attaches their segments to the latest uploads, Dislikes
+	// their estimated dislikes to the listed videos, and WatchPage the extras
+	// scraped from their watch page, when set.
+	SponsorBlock *SponsorBlockClient
+	Dislikes     *DislikesClient
+	WatchPage    *WatchPageScraper
+
+	// ReuseUploads is how long the uploads are reused instead of being fetched
+	// again while the uploads playlist is unchanged, disabled when zero.
+	ReuseUploads time.Duration
+
+	// LiveDetection overrides the live detection methods of the backend when
+	// set, and MaxVideos limits the number of latest uploads when positive.
+	LiveDetection []string
+	MaxVideos     int
+
+	mu        sync.RWMutex
+	lastError error
+
+	// refreshMu serializes the full and the live refreshes.
+	refreshMu sync.Mutex
+
+	// endedIds are the live videos found ended by RefreshLive, which already
+	// ran a full refresh for them.
+	endedIds map[string]bool
+
+	uploadIds        []string
+	uploadsFetchedAt time.Time
+}
+
+func NewPoller(channelId string, client *Client, events *EventBus) *Poller {
+	return &Poller{
+		ChannelId:  channelId,
+		Client:     client,
+		Store:      NewStateStore(),
+		Events:     events,
+		Viewers:    NewViewerHistory(720),
+		Sessions:   NewSessionStore(100),
+		Tombstones: NewTombstoneStore(100),
+		Errors:     NewErrorStats(),
+	}
+}
+
+func (p *Poller) emit(eventType string, data any) {
+	log.Info().Str("type", eventType).Str("channel", p.ChannelId).Msg("Event emitted")
+
+	DefaultMetrics.CountEvent(eventType)
+
+	if p.Events == nil {
+		return
+	}
+
+	p.Events.Emit(Event{
+		Type:      eventType,
+		ChannelId: p.ChannelId,
+		Time:      time.Now(),
+		Data:      data,
+	})
+}
+
+// Run refreshes the state at the given interval until the context is done.
+func (p *Poller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Refresh(ctx); err != nil {
+			log.Err(err).Str("channel", p.ChannelId).Msg("Unable to refresh state")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Poller) fetchExtras(ctx context.Context, channelId string, liveVideo *youtube.Video, videos []*youtube.Video) *Extras {
+	var videoId string
+
+	switch {
+	case liveVideo != nil:
+		videoId = liveVideo.Id
+	case len(videos) > 0:
+		videoId = videos[0].Id
+	}
+
+	data, err := p.Holodex.Fetch(ctx, channelId, videoId)
+
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to fetch Holodex data")
+	}
+
+	if data == nil {
+		return nil
+	}
+
+	return &Extras{
+		Holodex: data,
+	}
+}
+
+func sameText(a, b *youtube.Video) bool {
+	if a.Snippet == nil || b.Snippet == nil {
+		return a.Snippet == b.Snippet
+	}
+
+	return a.Snippet.Title == b.Snippet.Title && a.Snippet.Description == b.Snippet.Description
+}
+
+func (p *Poller) emitArrivals(previous *State, liveVideos, videos []*youtube.Video) {
+	known := make(map[string]bool)
+
+	for _, list := range [][]*Video{previous.LiveVideos, previous.Videos, previous.UpcomingVideos} {
+		for _, v := range list {
+			known[v.Id] = true
+		}
+	}
+
+	for _, v := range videos {
+		if known[v.Id] {
+			continue
+		}
+
+		p.emit("video_uploaded", &Video{Video: v})
+
+		if p.Keywords != nil {
+			for _, match := range p.Keywords.Match("upload", v) {
+				p.emit("keyword_match", match)
+			}
+		}
+	}
+
+	previousLiveVideos := make(map[string]*youtube.Video, len(previous.LiveVideos))
+
+	for _, v := range previous.LiveVideos {
+		previousLiveVideos[v.Id] = v.Video
+	}
+
+	for _, v := range liveVideos {
+		prev, ok := previousLiveVideos[v.Id]
+
+		if !ok {
+			p.emit("live_started", &Video{Video: v})
+		}
+
+		if p.Keywords == nil || ok && sameText(prev, v) {
+			continue
+		}
+
+		for _, match := range p.Keywords.Match("live", v) {
+			p.emit("keyword_match", match)
+		}
+	}
+}
+
+func (p *Poller) Refresh(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "refresh", trace.WithAttributes(attribute.String("onyt.channel_id", p.ChannelId)))
+	defer span.End()
+
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+
+	start := time.Now()
+	err := p.refresh(ctx)
+
+	DefaultMetrics.ObserveStage("refresh", time.Since(start), err)
+
+	if err != nil {
+		DefaultMetrics.CountError(err)
+
+		kind := ClassifyError(err)
+
+		p.Errors.Record(kind, err, time.Now())
+
+		err = &RefreshError{
+			Kind: kind,
+			Err:  err,
+		}
+	}
+
+	p.mu.Lock()
+	p.lastError = err
+	p.mu.Unlock()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(attribute.String("onyt.error_kind", ClassifyError(err)))
+
+		return err
+	}
+
+	return nil
+}
+
+// RunLive refreshes the live videos at the given interval while the channel
+// is live, until the context is done.
+func (p *Poller) RunLive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if len(p.Store.Get().LiveVideos) == 0 || p.Pause.Paused() {
+			continue
+		}
+
+		if err := p.RefreshLive(ctx); err != nil {
+			log.Err(err).Str("channel", p.ChannelId).Msg("Unable to refresh live videos")
+		}
+	}
+}
+
+// RefreshLive only fetches the live videos again, updating their statistics
+// and streaming details. A stream which ended is left to the next full
+// refresh, which is run right away once per stream.
+func (p *Poller) RefreshLive(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "refresh live", trace.WithAttributes(attribute.String("onyt.channel_id", p.ChannelId)))
+	defer span.End()
+
+	p.refreshMu.Lock()
+
+	previous := p.Store.Get()
+	videoIds := make([]string, 0, len(previous.LiveVideos))
+
+	for _, v := range previous.LiveVideos {
+		videoIds = append(videoIds, v.Id)
+	}
+
+	if len(videoIds) == 0 {
+		p.refreshMu.Unlock()
+		return nil
+	}
+
+	fetched, err := traced(ctx, "fetch videos", func(ctx context.Context) ([]*youtube.Video, error) {
+		return p.Client.FetchVideos(ctx, videoIds)
+	})
+
+	if err != nil {
+		p.refreshMu.Unlock()
+
+		DefaultMetrics.CountError(err)
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return err
+	}
+
+	liveVideos := make([]*youtube.Video, 0, len(fetched))
+	liveIds := make(map[string]bool, len(fetched))
+
+	for _, v := range fetched {
+		if d := v.LiveStreamingDetails; d != nil && d.ActualEndTime != "" {
+			continue
+		}
+
+		liveVideos = append(liveVideos, v)
+		liveIds[v.Id] = true
+	}
+
+	// A full refresh is only run once for the streams which ended, in case
+	// the live detection still reports them.
+	endedIds := make(map[string]bool)
+	escalate := false
+
+	for _, id := range videoIds {
+		if liveIds[id] {
+			continue
+		}
+
+		endedIds[id] = true
+
+		if !p.endedIds[id] {
+			escalate = true
+		}
+	}
+
+	p.endedIds = endedIds
+
+	if !escalate && len(liveVideos) > 0 {
+		p.storeLive(ctx, previous, liveVideos)
+	}
+
+	p.refreshMu.Unlock()
+
+	if escalate {
+		return p.Refresh(ctx)
+	}
+
+	return nil
+}
+
+func (p *Poller) storeLive(ctx context.Context, previous *State, liveVideos []*youtube.Video) {
+	next := *previous
+
+	next.LiveVideos = WrapVideos(liveVideos)
+	next.LiveVideo = next.LiveVideos[0]
+	next.Links = collectLinks(&next)
+
+	if p.Games != nil {
+		p.Games.Track(ctx, next.LiveVideos)
+	}
+
+	if p.Dislikes != nil {
+		p.Dislikes.Enrich(ctx, next.LiveVideos)
+	}
+
+	if p.WatchPage != nil {
+		p.WatchPage.Enrich(ctx, next.LiveVideos)
+	}
+
+	p.Store.Set(&next)
+	p.emitCategoryChanges(previous, &next)
+
+	if d := next.LiveVideo.LiveStreamingDetails; d != nil {
+		p.Viewers.Add(next.LiveVideo.Id, time.Now(), d.ConcurrentViewers)
+	}
+
+	previousLiveVideos := make(map[string]*youtube.Video, len(previous.LiveVideos))
+
+	for _, v := range previous.LiveVideos {
+		previousLiveVideos[v.Id] = v.Video
+	}
+
+	for _, v := range liveVideos {
+		if update := diffLiveVideo(previousLiveVideos[v.Id], v); update != nil {
+			p.emit("live_updated", update)
+		}
+
+		if p.Keywords == nil || sameText(previousLiveVideos[v.Id], v) {
+			continue
+		}
+
+		for _, match := range p.Keywords.Match("live", v) {
+			p.emit("keyword_match", match)
+		}
+	}
+}
+
+// emitCategoryChanges emits category_changed for the live videos whose
+// category or game changed since the previous state.
+func (p *Poller) emitCategoryChanges(previous, next *State) {
+	categories := make(map[string]*Category, len(previous.LiveVideos))
+
+	for _, v := range previous.LiveVideos {
+		categories[v.Id] = v.Fields().Category
+	}
+
+	for _, v := range next.LiveVideos {
+		from, ok := categories[v.Id]
+
+		if to := v.Fields().Category; ok && !sameCategory(from, to) {
+			p.emit("category_changed", &CategoryChange{
+				VideoId: v.Id,
+				From:    from,
+				To:      to,
+			})
+		}
+	}
+}
+
+// LastError returns the error of the last refresh, nil if it succeeded.
+func (p *Poller) LastError() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.lastError
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// reusableUploads returns the uploads of the previous state which don't need
+// to be fetched again, the uploads playlist being unchanged.
+func (p *Poller) reusableUploads(previous *State, uploadIds []string, liveStreams *LiveStreams, now time.Time) map[string]*youtube.Video {
+	if p.ReuseUploads <= 0 {
+		return nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.uploadsFetchedAt.IsZero() || now.Sub(p.uploadsFetchedAt) >= p.ReuseUploads || !sameStrings(p.uploadIds, uploadIds) {
+		return nil
+	}
+
+	live := make(map[string]bool)
+
+	for _, id := range uniqueStrings(liveStreams.VideoIds, liveStreams.UpcomingVideoIds) {
+		live[id] = true
+	}
+
+	reused := make(map[string]*youtube.Video, len(previous.Videos))
+
+	for _, v := range previous.Videos {
+		if !live[v.Id] {
+			reused[v.Id] = v.Video
+		}
+	}
+
+	return reused
+}
+
+func (p *Poller) refresh(ctx context.Context) error {
+	channel, err := traced(ctx, "fetch channel", func(ctx context.Context) (*youtube.Channel, error) {
+		return p.Client.FetchChannel(ctx, p.ChannelId)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	liveStreams, err := traced(ctx, "detect live", func(ctx context.Context) (*LiveStreams, error) {
+		if p.LiveDetection != nil {
+			ctx = withLiveDetection(ctx, p.LiveDetection)
+		}
+
+		return p.Client.DetectLive(ctx, channel.Id)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	uploadIds, err := traced(ctx, "fetch playlist", func(ctx context.Context) ([]string, error) {
+		return p.Client.FetchUploadIds(ctx, channel)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if p.MaxVideos > 0 && len(uploadIds) > p.MaxVideos {
+		uploadIds = uploadIds[:p.MaxVideos]
+	}
+
+	now := time.Now()
+	previous := p.Store.Get()
+
+	reused := p.reusableUploads(previous, uploadIds, liveStreams, now)
+	videoIds := make([]string, 0)
+
+	for _, id := range uniqueStrings(liveStreams.VideoIds, liveStreams.UpcomingVideoIds, uploadIds) {
+		if _, ok := reused[id]; !ok {
+			videoIds = append(videoIds, id)
+		}
+	}
+
+	var fetched []*youtube.Video
+
+	if len(videoIds) > 0 {
+		fetched, err = traced(ctx, "fetch videos", func(ctx context.Context) ([]*youtube.Video, error) {
+			return p.Client.FetchVideos(ctx, videoIds)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if reused == nil {
+		p.mu.Lock()
+		p.uploadIds = uploadIds
+		p.uploadsFetchedAt = now
+		p.mu.Unlock()
+	}
+
+	for _, v := range reused {
+		fetched = append(fetched, v)
+	}
+
+	for _, tombstone := range p.Tombstones.Track(fetched, time.Now()) {
+		p.emit("video_removed", tombstone)
+	}
+
+	videosById := make(map[string]*youtube.Video, len(fetched))
+
+	for _, v := range fetched {
+		videosById[v.Id] = v
+	}
+
+	liveVideos := make([]*youtube.Video, 0)
+	isLive := make(map[string]bool)
+
+	// The live detection may still report a stream which ended.
+	for _, id := range liveStreams.VideoIds {
+		if v, ok := videosById[id]; ok && (v.LiveStreamingDetails == nil || v.LiveStreamingDetails.ActualEndTime == "") {
+			liveVideos = append(liveVideos, v)
+			isLive[id] = true
+		}
+	}
+
+	var liveVideo *youtube.Video
+
+	if len(liveVideos) > 0 {
+		liveVideo = liveVideos[0]
+	}
+
+	upcomingVideos := make([]*youtube.Video, 0)
+
+	for _, id := range liveStreams.UpcomingVideoIds {
+		if v, ok := videosById[id]; ok {
+			upcomingVideos = append(upcomingVideos, v)
+		}
+	}
+
+	videos := make([]*youtube.Video, 0, len(uploadIds))
+
+	for _, id := range uploadIds {
+		if v, ok := videosById[id]; ok && !isLive[id] {
+			videos = append(videos, v)
+		}
+	}
+
+	var extras *Extras
+
+	if p.Holodex != nil {
+		extras = p.fetchExtras(ctx, channel.Id, liveVideo, videos)
+	}
+
+	next := &State{
+		Channel:        channel,
+		LiveVideos:     WrapVideos(liveVideos),
+		Videos:         WrapVideos(videos),
+		UpcomingVideos: WrapVideos(upcomingVideos),
+		Extras:         extras,
+	}
+
+	if len(next.LiveVideos) > 0 {
+		next.LiveVideo = next.LiveVideos[0]
+	}
+
+	next.Links = collectLinks(next)
+
+	if p.Games != nil {
+		p.Games.Track(ctx, next.LiveVideos)
+	}
+
+	if p.SponsorBlock != nil {
+		p.SponsorBlock.Enrich(ctx, next.Videos)
+	}
+
+	if p.Dislikes != nil {
+		for _, videos := range [][]*Video{next.LiveVideos, next.Videos, next.UpcomingVideos} {
+			p.Dislikes.Enrich(ctx, videos)
+		}
+	}
+
+	if p.WatchPage != nil {
+		for _, videos := range [][]*Video{next.LiveVideos, p.WatchPage.Latest(next.Videos), next.UpcomingVideos} {
+			p.WatchPage.Enrich(ctx, videos)
+		}
+	}
+
+	p.Store.Set(next)
+	p.emitCategoryChanges(previous, next)
+
+	if p.Comments != nil {
+		commentVideoIds := make([]string, 0, len(liveVideos)+1)
+
+		for _, v := range liveVideos {
+			commentVideoIds = append(commentVideoIds, v.Id)
+		}
+
+		if len(videos) > 0 {
+			commentVideoIds = append(commentVideoIds, videos[0].Id)
+		}
+
+		p.Comments.Refresh(ctx, commentVideoIds)
+	}
+
+	if liveVideo != nil && liveVideo.LiveStreamingDetails != nil {
+		p.Viewers.Add(liveVideo.Id, now, liveVideo.LiveStreamingDetails.ConcurrentViewers)
+	} else if liveVideo == nil {
+		p.Viewers.Reset()
+	}
+
+	previousLiveVideos := make(map[string]*youtube.Video, len(previous.LiveVideos))
+
+	for _, v := range previous.LiveVideos {
+		previousLiveVideos[v.Id] = v.Video
+	}
+
+	for _, v := range liveVideos {
+		if update := diffLiveVideo(previousLiveVideos[v.Id], v); update != nil {
+			p.emit("live_updated", update)
+		}
+	}
+
+	if previous.Channel != nil {
+		p.emitArrivals(previous, liveVideos, videos)
+	}
+
+	for _, session := range p.Sessions.Track(liveVideos, videos, now) {
+		p.emit("live_ended", session)
+	}
+
+	if p.LiveChat != nil {
+		var videoId, chatId string
+
+		if liveVideo != nil && liveVideo.LiveStreamingDetails != nil {
+			videoId, chatId = liveVideo.Id, liveVideo.LiveStreamingDetails.ActiveLiveChatId
+		}
+
+		p.LiveChat.Track(videoId, chatId, p.emit)
+	}
+
+	if p.Playlists != nil {
+		if err := p.Playlists.Refresh(ctx, channel.Id); err != nil {
+			log.Warn().Err(err).Str("channel", p.ChannelId).Msg("Unable to fetch playlists")
+		}
+	}
+
+	if p.Sections != nil {
+		if err := p.Sections.Refresh(ctx, channel.Id); err != nil {
+			log.Warn().Err(err).Str("channel", p.ChannelId).Msg("Unable to fetch channel sections")
+		}
+	}
+
+	if p.Community != nil {
+		posts, err := p.Community.Track(ctx, channel.Id)
+
+		if err != nil {
+			log.Warn().Err(err).Str("channel", p.ChannelId).Msg("Unable to scrape community posts")
+		}
+
+		for _, post := range posts {
+			p.emit("community_post", post)
+		}
+	}
+
+	if p.Milestones != nil {
+		milestones, err := p.Milestones.Track(p.ChannelId, channel)
+
+		if err != nil {
+			log.Err(err).Str("channel", p.ChannelId).Msg("Unable to track milestones")
+		}
+
+		for _, milestone := range milestones {
+			p.emit("milestone_reached", milestone)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package onyt
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Sessions older than the half-life weigh half as much in the prediction.
+const predictionHalfLife = 30 * 24 * time.Hour
+
+type StreamWindow struct {
+	Weekday     string    `json:"weekday"`
+	Hour        int       `json:"hour"`
+	Probability float64   `json:"probability"`
+	Next        time.Time `json:"next"`
+}
+
+// SchedulePrediction estimates when a channel usually goes live from the start
+// times of its past sessions.
+type SchedulePrediction struct {
+	Timezone  string          `json:"timezone"`
+	Sessions  int             `json:"sessions"`
+	Histogram [7][24]float64  `json:"histogram"`
+	Hours     [24]float64     `json:"hours"`
+	UsualHour *int            `json:"usualHour"`
+	Windows   []*StreamWindow `json:"windows"`
+}
+
+// PredictSchedule builds a day-of-week and hour histogram of the start times,
+// giving the most recent sessions more weight, and returns the most likely
+// upcoming windows.
+func PredictSchedule(starts []time.Time, now time.Time, loc *time.Location, count int) *SchedulePrediction {
+	prediction := &SchedulePrediction{
+		Timezone: loc.String(),
+		Sessions: len(starts),
+		Windows:  make([]*StreamWindow, 0),
+	}
+
+	total := 0.0
+
+	for _, start := range starts {
+		weight := math.Pow(0.5, float64(now.Sub(start))/float64(predictionHalfLife))
+
+		if weight > 1 {
+			weight = 1
+		}
+
+		t := start.In(loc)
+
+		prediction.Histogram[t.Weekday()][t.Hour()] += weight
+		total += weight
+	}
+
+	if total == 0 {
+		return prediction
+	}
+
+	for day := range prediction.Histogram {
+		for hour := range prediction.Histogram[day] {
+			prediction.Histogram[day][hour] /= total
+			prediction.Hours[hour] += prediction.Histogram[day][hour]
+		}
+	}
+
+	usual := 0
+
+	for hour, p := range prediction.Hours {
+		if p > prediction.Hours[usual] {
+			usual = hour
+		}
+	}
+
+	prediction.UsualHour = &usual
+
+	local := now.In(loc)
+
+	for day := range prediction.Histogram {
+		for hour, p := range prediction.Histogram[day] {
+			if p == 0 {
+				continue
+			}
+
+			offset := (day - int(local.Weekday()) + 7) % 7
+			next := time.Date(local.Year(), local.Month(), local.Day()+offset, hour, 0, 0, 0, loc)
+
+			// Windows already over this week happen again the next one.
+			if !next.Add(time.Hour).After(local) {
+				next = next.AddDate(0, 0, 7)
+			}
+
+			prediction.Windows = append(prediction.Windows, &StreamWindow{
+				Weekday:     time.Weekday(day).String(),
+				Hour:        hour,
+				Probability: p,
+				Next:        next,
+			})
+		}
+	}
+
+	sort.Slice(prediction.Windows, func(i, j int) bool {
+		a, b := prediction.Windows[i], prediction.Windows[j]
+
+		if a.Probability != b.Probability {
+			return a.Probability > b.Probability
+		}
+
+		return a.Next.Before(b.Next)
+	})
+
+	if len(prediction.Windows) > count {
+		prediction.Windows = prediction.Windows[:count]
+	}
+
+	return prediction
+}
@@ -0,0 +1,148 @@
+package onyt
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// The modes of a proxy pool.
+const (
+	ProxyRoundRobin = "round-robin"
+	ProxyFailover   = "failover"
+)
+
+type poolProxy struct {
+	url       *url.URL
+	transport *http.Transport
+}
+
+// ProxyPool is a transport sending the scrapes through HTTP or SOCKS proxies,
+// either in turn or sticking to one until it fails. The failed scrapes are
+// retried through the next proxy, the other requests being sent directly.
+type ProxyPool struct {
+	Transport http.RoundTripper
+	Mode      string
+
+	proxies []*poolProxy
+
+	mu   sync.Mutex
+	next int
+}
+
+func NewProxyPool(proxies []string, mode string, transport http.RoundTripper) (*ProxyPool, error) {
+	if mode != ProxyRoundRobin && mode != ProxyFailover {
+		return nil, fmt.Errorf("unknown proxy mode: %s", mode)
+	}
+
+	pool := &ProxyPool{
+		Transport: transport,
+		Mode:      mode,
+		proxies:   make([]*poolProxy, 0, len(proxies)),
+	}
+
+	for _, value := range proxies {
+		u, err := url.Parse(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme: %s", value)
+		}
+
+		proxyTransport := http.DefaultTransport.(*http.Transport).Clone()
+		proxyTransport.Proxy = http.ProxyURL(u)
+
+		pool.proxies = append(pool.proxies, &poolProxy{
+			url:       u,
+			transport: proxyTransport,
+		})
+	}
+
+	return pool, nil
+}
+
+// pick returns the index of the proxy the next scrape starts with.
+func (p *ProxyPool) pick() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.next % len(p.proxies)
+
+	if p.Mode == ProxyRoundRobin {
+		p.next++
+	}
+
+	return i
+}
+
+// fail moves the failover pool past the proxy, unless another scrape already
+// did.
+func (p *ProxyPool) fail(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Mode == ProxyFailover && p.next%len(p.proxies) == i {
+		p.next++
+	}
+}
+
+func (p *ProxyPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !scrapeHosts[req.URL.Host] || len(p.proxies) == 0 {
+		return p.Transport.RoundTrip(req)
+	}
+
+	start := p.pick()
+
+	var lastErr error
+
+	for attempt := 0; attempt < len(p.proxies); attempt++ {
+		i := (start + attempt) % len(p.proxies)
+		proxy := p.proxies[i]
+
+		if attempt > 0 {
+			if req.Body != nil && req.GetBody == nil {
+				break
+			}
+
+			clone := req.Clone(req.Context())
+
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+
+				if err != nil {
+					return nil, err
+				}
+
+				clone.Body = body
+			}
+
+			req = clone
+		}
+
+		resp, err := proxy.transport.RoundTrip(req)
+
+		if err == nil {
+			return resp, nil
+		}
+
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+
+		log.Warn().Err(err).Str("proxy", proxy.url.Redacted()).Msg("Unable to scrape through proxy")
+
+		p.fail(i)
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
@@ -0,0 +1,84 @@
+package onyt
+
+import (
+	"sync"
+	"time"
+)
+
+var pacific = func() *time.Location {
+	if location, err := time.LoadLocation("America/Los_Angeles"); err == nil {
+		return location
+	}
+
+	return time.FixedZone("PST", -8*3600)
+}()
+
+type QuotaUsage struct {
+	Used     int64 `json:"used"`
+	Limit    int64 `json:"limit"`
+	ResetsAt int64 `json:"resetsAt"`
+}
+
+// QuotaMeter counts the YouTube Data API quota units spent since the daily
+// reset, at midnight Pacific time. A zero limit disables Allow checks.
+type QuotaMeter struct {
+	mu    sync.Mutex
+	day   string
+	used  int64
+	Limit int64
+}
+
+func NewQuotaMeter(limit int64) *QuotaMeter {
+	return &QuotaMeter{
+		Limit: limit,
+	}
+}
+
+func (q *QuotaMeter) reset(now time.Time) {
+	if day := now.In(pacific).Format("2006-01-02"); day != q.day {
+		q.day = day
+		q.used = 0
+	}
+}
+
+func (q *QuotaMeter) Add(units int64) {
+	if q == nil {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.reset(time.Now())
+	q.used += units
+}
+
+// Allow returns whether the units can be spent without exceeding the limit.
+func (q *QuotaMeter) Allow(units int64) bool {
+	if q == nil || q.Limit == 0 {
+		return true
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.reset(time.Now())
+
+	return q.used+units <= q.Limit
+}
+
+func (q *QuotaMeter) Usage() *QuotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	q.reset(now)
+
+	local := now.In(pacific)
+
+	return &QuotaUsage{
+		Used:     q.used,
+		Limit:    q.Limit,
+		ResetsAt: time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, pacific).Unix(),
+	}
+}
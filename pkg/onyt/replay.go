@@ -0,0 +1,191 @@
+package onyt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Exchange is a recorded request along with its response.
+type Exchange struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody string      `json:"requestBody,omitempty"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        string      `json:"body"`
+}
+
+// exchangeURL normalizes the URL of a request, leaving out the API key.
+func exchangeURL(u *url.URL) string {
+	query := u.Query()
+	query.Del("key")
+
+	v := *u
+	v.RawQuery = query.Encode()
+	v.Fragment = ""
+
+	return v.String()
+}
+
+func exchangeKeys(method string, u *url.URL, body string) []string {
+	return []string{
+		method + " " + exchangeURL(u) + " " + body,
+		method + " " + exchangeURL(u),
+		method + " " + u.Host + u.Path,
+	}
+}
+
+type replayedExchanges struct {
+	exchanges []*Exchange
+	next      int
+}
+
+// Replay is a transport answering requests with recorded exchanges instead of
+// sending them. Requests are matched on their method, URL and body, then on
+// their method and URL, and finally on their method and path. Exchanges
+// recorded several times are replayed in the order of their file names, the
+// last one being repeated.
+type Replay struct {
+	mu        sync.Mutex
+	exchanges map[string]*replayedExchanges
+}
+
+// NewReplay loads the exchanges of the JSON files found in the directory.
+func NewReplay(dir string) (*Replay, error) {
+	r := &Replay{
+		exchanges: make(map[string]*replayedExchanges),
+	}
+
+	paths := make([]string, 0)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && filepath.Ext(path) == ".json" {
+			paths = append(paths, path)
+		}
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		exchange := &Exchange{}
+
+		if err := json.Unmarshal(data, exchange); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		u, err := url.Parse(exchange.URL)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if exchange.Method == "" {
+			exchange.Method = http.MethodGet
+		}
+
+		if exchange.Status == 0 {
+			exchange.Status = http.StatusOK
+		}
+
+		for _, key := range exchangeKeys(exchange.Method, u, exchange.RequestBody) {
+			replayed, ok := r.exchanges[key]
+
+			if !ok {
+				replayed = &replayedExchanges{}
+				r.exchanges[key] = replayed
+			}
+
+			replayed.exchanges = append(replayed.exchanges, exchange)
+		}
+	}
+
+	return r, nil
+}
+
+func (r *Replay) lookup(req *http.Request, body string) *Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range exchangeKeys(req.Method, req.URL, body) {
+		replayed, ok := r.exchanges[key]
+
+		if !ok {
+			continue
+		}
+
+		exchange := replayed.exchanges[replayed.next]
+
+		if replayed.next < len(replayed.exchanges)-1 {
+			replayed.next++
+		}
+
+		return exchange
+	}
+
+	return nil
+}
+
+func (r *Replay) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+
+	if req.Body != nil {
+		var err error
+
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	exchange := r.lookup(req, string(body))
+
+	if exchange == nil {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, exchangeURL(req.URL))
+	}
+
+	header := exchange.Header.Clone()
+
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	if header.Get("content-type") == "" && strings.HasPrefix(strings.TrimSpace(exchange.Body), "{") {
+		header.Set("content-type", "application/json; charset=utf-8")
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Status, http.StatusText(exchange.Status)),
+		StatusCode:    exchange.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(exchange.Body))),
+		ContentLength: int64(len(exchange.Body)),
+		Request:       req,
+	}, nil
+}
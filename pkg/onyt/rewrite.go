@@ -0,0 +1,50 @@
+package onyt
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// youtubeHosts lists the hosts of the API and the scraped pages.
+var youtubeHosts = map[string]bool{
+	"www.youtube.com":        true,
+	"youtube.googleapis.com": true,
+	"www.googleapis.com":     true,
+}
+
+// Rewrite is a transport sending the requests meant for YouTube to another
+// server, such as the mock server.
+type Rewrite struct {
+	Base      *url.URL
+	Transport http.RoundTripper
+}
+
+func NewRewrite(base string, transport http.RoundTripper) (*Rewrite, error) {
+	u, err := url.Parse(base)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rewrite{
+		Base:      u,
+		Transport: transport,
+	}, nil
+}
+
+func (r *Rewrite) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !youtubeHosts[req.URL.Host] {
+		return r.Transport.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+
+	req.URL.Scheme = r.Base.Scheme
+	req.URL.Host = r.Base.Host
+	req.URL.Path = strings.TrimSuffix(r.Base.Path, "/") + req.URL.Path
+	req.URL.RawPath = ""
+	req.Host = ""
+
+	return r.Transport.RoundTrip(req)
+}
@@ -0,0 +1,128 @@
+package onyt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+var (
+	rumbleLiveSelector  = cascadia.MustCompile(".videostream__status--live, .video-item--live, .videostream__badge--live")
+	rumbleTitleSelector = cascadia.MustCompile("h1")
+	rumbleLinkSelector  = cascadia.MustCompile("a.videostream__link, a.video-item--a")
+)
+
+type RumbleSource struct {
+	channel string
+}
+
+func NewRumbleSource(channel string) *RumbleSource {
+	return &RumbleSource{
+		channel: channel,
+	}
+}
+
+func (s *RumbleSource) Platform() string {
+	return "rumble"
+}
+
+func (s *RumbleSource) Refresh(ctx context.Context) (*SourceState, error) {
+	channelURL := fmt.Sprintf("https://rumble.com/c/%s", url.PathEscape(s.channel))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, channelURL, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected rumble status: %s", resp.Status)
+	}
+
+	doc, err := html.Parse(resp.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SourceState{
+		Platform: s.Platform(),
+		Channel: &SourceChannel{
+			Id:   s.channel,
+			Name: s.channel,
+			URL:  channelURL,
+		},
+		Streams:     make([]*SourceStream, 0),
+		Videos:      make([]*SourceVideo, 0),
+		RefreshedAt: time.Now().Unix(),
+	}
+
+	if node := cascadia.Query(doc, rumbleTitleSelector); node != nil {
+		result.Channel.Name = strings.TrimSpace(textContent(node))
+	}
+
+	for _, badge := range cascadia.QueryAll(doc, rumbleLiveSelector) {
+		stream := &SourceStream{
+			URL: channelURL,
+		}
+
+		for n := badge.Parent; n != nil; n = n.Parent {
+			if link := cascadia.Query(n, rumbleLinkSelector); link != nil {
+				stream.Title = strings.TrimSpace(textContent(link))
+				stream.URL = "https://rumble.com" + attr(link, "href")
+				stream.Id = strings.Trim(attr(link, "href"), "/")
+
+				break
+			}
+		}
+
+		result.Live = true
+		result.Streams = append(result.Streams, stream)
+	}
+
+	return result, nil
+}
+
+func attr(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
+
+func textContent(node *html.Node) string {
+	var sb strings.Builder
+
+	var walk func(n *html.Node)
+
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+
+	walk(node)
+
+	return sb.String()
+}
@@ -0,0 +1,313 @@
+package onyt
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+type ScheduleStats struct {
+	Key             string `json:"key"`
+	OffsetSeconds   int64  `json:"offsetSeconds"`
+	LastStartedAt   int64  `json:"lastStartedAt"`
+	LastRefreshedAt int64  `json:"lastRefreshedAt"`
+	LastDurationMs  int64  `json:"lastDurationMs"`
+	Refreshes       uint64 `json:"refreshes"`
+	Failures        uint64 `json:"failures"`
+	Skipped         uint64 `json:"skipped"`
+}
+
+// Scheduler refreshes the sources at the same interval, spreading their
+// refreshes across it and bounding how many run at once.
+type Scheduler struct {
+	Interval time.Duration
+	Workers  int
+
+	// Intervals override the interval of the sources by key.
+	Intervals map[string]time.Duration
+
+	// Group is the number of sources sharing the same offset, so their
+	// video fetches can be batched.
+	Group int
+
+	// Pause skips the scheduled refreshes while set, the forced ones being
+	// still run.
+	Pause *Pause
+
+	mu      sync.RWMutex
+	stats   map[string]*ScheduleStats
+	cancels map[string]context.CancelFunc
+	sources map[string]Source
+	run     *scheduleRun
+}
+
+// scheduleRun holds what the sources added while running are scheduled with.
+type scheduleRun struct {
+	ctx     context.Context
+	wg      sync.WaitGroup
+	workers chan struct{}
+	refresh func(ctx context.Context, source Source) error
+}
+
+var (
+	errSchedulerStopped = errors.New("scheduler not running")
+
+	// ErrUnknownSource is returned when refreshing a source not scheduled.
+	ErrUnknownSource = errors.New("unknown source")
+)
+
+func NewScheduler(interval time.Duration, workers int) *Scheduler {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	if workers <= 0 {
+		workers = 1
+	}
+
+	return &Scheduler{
+		Interval: interval,
+		Workers:  workers,
+		stats:    make(map[string]*ScheduleStats),
+		cancels:  make(map[string]context.CancelFunc),
+		sources:  make(map[string]Source),
+	}
+}
+
+// SourceKey identifies the source in the schedule stats, being the channel ID
+// of the YouTube sources and the platform of the others.
+func SourceKey(source Source) string {
+	if s, ok := source.(*YouTubeSource); ok {
+		return s.poller.ChannelId
+	}
+
+	return source.Platform()
+}
+
+// Run refreshes the sources until the context is done. A refresh still
+// running when the next one is due is skipped rather than queued.
+func (s *Scheduler) Run(ctx context.Context, sources []Source, refresh func(ctx context.Context, source Source) error) {
+	run := &scheduleRun{
+		ctx:     ctx,
+		workers: make(chan struct{}, s.Workers),
+		refresh: refresh,
+	}
+
+	group := s.Group
+
+	if group <= 0 {
+		group = 1
+	}
+
+	slots := (len(sources) + group - 1) / group
+
+	s.mu.Lock()
+	s.run = run
+
+	for i, source := range sources {
+		s.start(run, source, s.Interval*time.Duration(i/group)/time.Duration(slots))
+	}
+
+	s.mu.Unlock()
+
+	<-ctx.Done()
+
+	run.wg.Wait()
+
+	s.mu.Lock()
+	s.run = nil
+	s.mu.Unlock()
+}
+
+// start schedules the source at the offset, the lock being held.
+func (s *Scheduler) start(run *scheduleRun, source Source, offset time.Duration) {
+	key := SourceKey(source)
+
+	// The sources refreshed more often than the others are spread across
+	// their own interval.
+	offset %= s.interval(key)
+
+	stats := &ScheduleStats{
+		Key:           key,
+		OffsetSeconds: int64(offset / time.Second),
+	}
+
+	ctx, cancel := context.WithCancel(run.ctx)
+
+	s.stats[stats.Key] = stats
+	s.cancels[stats.Key] = cancel
+	s.sources[stats.Key] = source
+
+	run.wg.Add(1)
+
+	go func() {
+		defer run.wg.Done()
+
+		s.runSource(ctx, source, stats, offset, run.workers, run.refresh)
+	}()
+}
+
+// Add schedules a source added at runtime, refreshed right away.
+func (s *Scheduler) Add(source Source) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.run == nil {
+		return errSchedulerStopped
+	}
+
+	s.start(s.run, source, 0)
+
+	return nil
+}
+
+// Remove stops refreshing the source with the key.
+func (s *Scheduler) Remove(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cancel, ok := s.cancels[key]; ok {
+		cancel()
+	}
+
+	delete(s.cancels, key)
+	delete(s.sources, key)
+	delete(s.stats, key)
+}
+
+// RefreshNow refreshes the source with the key without waiting for its next
+// tick, once a worker is available.
+func (s *Scheduler) RefreshNow(ctx context.Context, key string) error {
+	s.mu.RLock()
+	run := s.run
+	source, ok := s.sources[key]
+	stats := s.stats[key]
+	s.mu.RUnlock()
+
+	if run == nil {
+		return errSchedulerStopped
+	}
+
+	if !ok {
+		return ErrUnknownSource
+	}
+
+	select {
+	case run.workers <- struct{}{}:
+		defer func() { <-run.workers }()
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return s.refresh(ctx, source, stats, run.refresh)
+}
+
+// interval returns the interval the source with the key is refreshed at.
+func (s *Scheduler) interval(key string) time.Duration {
+	if interval, ok := s.Intervals[key]; ok && interval > 0 {
+		return interval
+	}
+
+	return s.Interval
+}
+
+func (s *Scheduler) runSource(ctx context.Context, source Source, stats *ScheduleStats, offset time.Duration, workers chan struct{}, refresh func(ctx context.Context, source Source) error) {
+	interval := s.interval(stats.Key)
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(offset):
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if !s.Pause.Paused() {
+			select {
+			case workers <- struct{}{}:
+				s.refresh(ctx, source, stats, refresh)
+				<-workers
+
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) refresh(ctx context.Context, source Source, stats *ScheduleStats, refresh func(ctx context.Context, source Source) error) error {
+	startedAt := time.Now()
+
+	s.mu.Lock()
+	stats.LastStartedAt = startedAt.Unix()
+	s.mu.Unlock()
+
+	err := refresh(ctx, source)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats.LastDurationMs = time.Since(startedAt).Milliseconds()
+	stats.Refreshes++
+
+	if err != nil {
+		stats.Failures++
+	} else {
+		stats.LastRefreshedAt = time.Now().Unix()
+	}
+
+	// The ticker drops the ticks missed by a refresh outlasting the interval,
+	// which are counted as skipped.
+	if missed := time.Since(startedAt) / s.interval(stats.Key); missed > 0 {
+		stats.Skipped += uint64(missed)
+	}
+
+	return err
+}
+
+// Stats returns the schedule stats of the source, nil when unknown.
+func (s *Scheduler) Stats(key string) *ScheduleStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats, ok := s.stats[key]
+
+	if !ok {
+		return nil
+	}
+
+	result := *stats
+
+	return &result
+}
+
+// List returns the schedule stats of every source, ordered by offset.
+func (s *Scheduler) List() []*ScheduleStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*ScheduleStats, 0, len(s.stats))
+
+	for _, stats := range s.stats {
+		item := *stats
+		result = append(result, &item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].OffsetSeconds < result[j].OffsetSeconds
+	})
+
+	return result
+}
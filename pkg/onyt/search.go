@@ -0,0 +1,93 @@
+package onyt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/api/youtube/v3"
+)
+
+type searchHit struct {
+	at          time.Time
+	liveVideoId string
+}
+
+// SearchFallback detects live streams with the search API, which costs 100
+// quota units per call, reusing the last result of each channel within the
+// interval. The concurrent searches of a channel share a single call.
+type SearchFallback struct {
+	mu       sync.Mutex
+	interval time.Duration
+	hits     map[string]searchHit
+	calls    singleflight.Group
+}
+
+func NewSearchFallback(interval time.Duration) *SearchFallback {
+	return &SearchFallback{
+		interval: interval,
+		hits:     make(map[string]searchHit),
+	}
+}
+
+func (s *SearchFallback) Fetch(ctx context.Context, src *youtube.Service, quota *QuotaMeter, channelId string) (string, error) {
+	s.mu.Lock()
+	hit, ok := s.hits[channelId]
+	s.mu.Unlock()
+
+	if ok && time.Since(hit.at) < s.interval {
+		return hit.liveVideoId, nil
+	}
+
+	liveVideoId, err, _ := s.calls.Do(channelId, func() (any, error) {
+		quota.Add(100)
+
+		liveVideoId, err := fetchLiveVideoIdFromSearch(ctx, src, channelId)
+
+		if err != nil {
+			return "", err
+		}
+
+		s.mu.Lock()
+
+		s.hits[channelId] = searchHit{
+			at:          time.Now(),
+			liveVideoId: liveVideoId,
+		}
+
+		s.mu.Unlock()
+
+		return liveVideoId, nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return liveVideoId.(string), nil
+}
+
+func fetchLiveVideoIdFromSearch(ctx context.Context, src *youtube.Service, channelId string) (string, error) {
+	call := src.Search.List([]string{"id"}).
+		ChannelId(channelId).
+		EventType("live").
+		Type("video").
+		MaxResults(1)
+
+	if region := localeFrom(ctx).Region; region != "" {
+		call.RegionCode(region)
+	}
+
+	resp, err := call.Context(ctx).Do()
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Items) > 0 && resp.Items[0].Id != nil {
+		return resp.Items[0].Id.VideoId, nil
+	}
+
+	return "", nil
+}
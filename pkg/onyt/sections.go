@@ -0,0 +1,240 @@
+package onyt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/youtube/v3"
+)
+
+type FeaturedChannel struct {
+	Id           string `json:"id"`
+	URL          string `json:"url"`
+	Title        string `json:"title"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+	Live         bool   `json:"live"`
+	LiveVideoId  string `json:"liveVideoId,omitempty"`
+}
+
+type ChannelSection struct {
+	Id          string             `json:"id"`
+	Type        string             `json:"type"`
+	Title       string             `json:"title"`
+	Position    int64              `json:"position"`
+	PlaylistIds []string           `json:"playlistIds"`
+	Channels    []*FeaturedChannel `json:"channels"`
+}
+
+// SectionTracker lists the sections of a channel at most once per interval,
+// along with its featured channels. With a live detector, the featured
+// channels are checked for live streams on every refresh.
+type SectionTracker struct {
+	Service  *youtube.Service
+	Quota    *QuotaMeter
+	Interval time.Duration
+	Live     LiveDetector
+	Locale   Locale
+
+	mu       sync.RWMutex
+	lastAt   time.Time
+	sections []*ChannelSection
+}
+
+func NewSectionTracker(src *youtube.Service, quota *QuotaMeter, interval time.Duration) *SectionTracker {
+	return &SectionTracker{
+		Service:  src,
+		Quota:    quota,
+		Interval: interval,
+		sections: make([]*ChannelSection, 0),
+	}
+}
+
+func (t *SectionTracker) fetchSections(ctx context.Context, channelId string) ([]*ChannelSection, error) {
+	t.Quota.Add(1)
+
+	call := t.Service.ChannelSections.List([]string{"contentDetails", "snippet"}).
+		ChannelId(channelId)
+
+	if t.Locale.Language != "" {
+		call.Hl(t.Locale.Language)
+	}
+
+	resp, err := call.Context(ctx).Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make([]*ChannelSection, 0, len(resp.Items))
+	featured := make(map[string]*FeaturedChannel)
+	featuredIds := make([]string, 0)
+
+	for _, item := range resp.Items {
+		section := &ChannelSection{
+			Id:          item.Id,
+			PlaylistIds: make([]string, 0),
+			Channels:    make([]*FeaturedChannel, 0),
+		}
+
+		if item.Snippet != nil {
+			section.Type = item.Snippet.Type
+			section.Title = item.Snippet.Title
+
+			if item.Snippet.Position != nil {
+				section.Position = *item.Snippet.Position
+			}
+
+			if l := item.Snippet.Localized; l != nil && t.Locale.Language != "" {
+				section.Title = l.Title
+			}
+		}
+
+		if item.ContentDetails != nil {
+			section.PlaylistIds = append(section.PlaylistIds, item.ContentDetails.Playlists...)
+
+			for _, id := range item.ContentDetails.Channels {
+				channel, ok := featured[id]
+
+				if !ok {
+					channel = &FeaturedChannel{
+						Id:  id,
+						URL: fmt.Sprintf("https://www.youtube.com/channel/%s", id),
+					}
+
+					featured[id] = channel
+					featuredIds = append(featuredIds, id)
+				}
+
+				section.Channels = append(section.Channels, channel)
+			}
+		}
+
+		sections = append(sections, section)
+	}
+
+	for start := 0; start < len(featuredIds); start += 50 {
+		end := start + 50
+
+		if end > len(featuredIds) {
+			end = len(featuredIds)
+		}
+
+		t.Quota.Add(1)
+
+		resp, err := t.Service.Channels.List([]string{"snippet"}).
+			Id(featuredIds[start:end]...).
+			Context(ctx).
+			Do()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range resp.Items {
+			if channel, ok := featured[item.Id]; ok && item.Snippet != nil {
+				channel.Title = item.Snippet.Title
+				channel.ThumbnailURL = BestThumbnail(item.Snippet.Thumbnails)
+			}
+		}
+	}
+
+	return sections, nil
+}
+
+// checkLive updates the live status of the featured channels, keeping the
+// previous one of a channel whose check failed.
+func (t *SectionTracker) checkLive(ctx context.Context, sections []*ChannelSection) {
+	checked := make(map[string]bool)
+
+	for _, section := range sections {
+		for _, channel := range section.Channels {
+			if checked[channel.Id] {
+				continue
+			}
+
+			checked[channel.Id] = true
+
+			streams, err := t.Live(withLocale(ctx, t.Locale), channel.Id)
+
+			if err != nil {
+				log.Warn().Err(err).Str("channel", channel.Id).Msg("Unable to check featured channel live status")
+				continue
+			}
+
+			t.mu.Lock()
+
+			channel.Live = len(streams.VideoIds) > 0
+			channel.LiveVideoId = ""
+
+			if channel.Live {
+				channel.LiveVideoId = streams.VideoIds[0]
+			}
+
+			t.mu.Unlock()
+		}
+	}
+}
+
+// Refresh updates the sections once the interval elapsed since the last
+// attempt, and the live status of the featured channels.
+func (t *SectionTracker) Refresh(ctx context.Context, channelId string) error {
+	if t.Service == nil {
+		return nil
+	}
+
+	t.mu.RLock()
+	due := time.Since(t.lastAt) >= t.Interval
+	sections := t.sections
+	t.mu.RUnlock()
+
+	if due {
+		t.mu.Lock()
+		t.lastAt = time.Now()
+		t.mu.Unlock()
+
+		fetched, err := t.fetchSections(ctx, channelId)
+
+		if err != nil {
+			return err
+		}
+
+		sections = fetched
+	}
+
+	if t.Live != nil {
+		t.checkLive(ctx, sections)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sections = sections
+
+	return nil
+}
+
+// Sections returns a copy of the sections, so their live status isn't
+// updated while served.
+func (t *SectionTracker) Sections() []*ChannelSection {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]*ChannelSection, len(t.sections))
+
+	for i, section := range t.sections {
+		copied := *section
+		copied.Channels = make([]*FeaturedChannel, len(section.Channels))
+
+		for j, channel := range section.Channels {
+			c := *channel
+			copied.Channels[j] = &c
+		}
+
+		result[i] = &copied
+	}
+
+	return result
+}
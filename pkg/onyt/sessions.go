@@ -0,0 +1,155 @@
+package onyt
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+type Session struct {
+	VideoId         string  `json:"videoId"`
+	Title           string  `json:"title"`
+	StartedAt       int64   `json:"startedAt"`
+	EndedAt         int64   `json:"endedAt"`
+	DurationSeconds int64   `json:"durationSeconds"`
+	PeakViewers     uint64  `json:"peakViewers"`
+	AverageViewers  float64 `json:"averageViewers"`
+	LikeCount       uint64  `json:"likeCount"`
+}
+
+type sessionTracker struct {
+	session       *Session
+	viewerTotal   uint64
+	viewerSamples uint64
+}
+
+type SessionStore struct {
+	mu       sync.RWMutex
+	current  map[string]*sessionTracker
+	history  []*Session
+	capacity int
+}
+
+func NewSessionStore(capacity int) *SessionStore {
+	return &SessionStore{
+		current:  make(map[string]*sessionTracker),
+		history:  make([]*Session, 0),
+		capacity: capacity,
+	}
+}
+
+func (s *SessionStore) Track(liveVideos []*youtube.Video, videos []*youtube.Video, now time.Time) []*Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	live := make(map[string]bool, len(liveVideos))
+
+	for _, v := range liveVideos {
+		live[v.Id] = true
+	}
+
+	ended := make([]*Session, 0)
+
+	for id, tracker := range s.current {
+		if !live[id] {
+			ended = append(ended, s.finish(tracker, videos, now))
+		}
+	}
+
+	for _, v := range liveVideos {
+		tracker, ok := s.current[v.Id]
+
+		if !ok {
+			tracker = startSession(v, now)
+			s.current[v.Id] = tracker
+		}
+
+		tracker.update(v)
+	}
+
+	return ended
+}
+
+func startSession(liveVideo *youtube.Video, now time.Time) *sessionTracker {
+	session := &Session{
+		VideoId:   liveVideo.Id,
+		StartedAt: now.Unix(),
+	}
+
+	if d := liveVideo.LiveStreamingDetails; d != nil {
+		if t, err := time.Parse(time.RFC3339, d.ActualStartTime); err == nil {
+			session.StartedAt = t.Unix()
+		}
+	}
+
+	return &sessionTracker{
+		session: session,
+	}
+}
+
+func (t *sessionTracker) update(liveVideo *youtube.Video) {
+	if liveVideo.Snippet != nil {
+		t.session.Title = liveVideo.Snippet.Title
+	}
+
+	if liveVideo.Statistics != nil {
+		t.session.LikeCount = liveVideo.Statistics.LikeCount
+	}
+
+	if d := liveVideo.LiveStreamingDetails; d != nil {
+		if d.ConcurrentViewers > t.session.PeakViewers {
+			t.session.PeakViewers = d.ConcurrentViewers
+		}
+
+		t.viewerTotal += d.ConcurrentViewers
+		t.viewerSamples++
+	}
+}
+
+func (s *SessionStore) finish(tracker *sessionTracker, videos []*youtube.Video, now time.Time) *Session {
+	session := tracker.session
+	session.EndedAt = now.Unix()
+
+	for _, v := range videos {
+		if v.Id != session.VideoId {
+			continue
+		}
+
+		if v.Statistics != nil {
+			session.LikeCount = v.Statistics.LikeCount
+		}
+
+		if d := v.LiveStreamingDetails; d != nil {
+			if t, err := time.Parse(time.RFC3339, d.ActualEndTime); err == nil {
+				session.EndedAt = t.Unix()
+			}
+		}
+	}
+
+	session.DurationSeconds = session.EndedAt - session.StartedAt
+
+	if tracker.viewerSamples > 0 {
+		session.AverageViewers = float64(tracker.viewerTotal) / float64(tracker.viewerSamples)
+	}
+
+	s.history = append(s.history, session)
+
+	if len(s.history) > s.capacity {
+		s.history = s.history[len(s.history)-s.capacity:]
+	}
+
+	delete(s.current, session.VideoId)
+
+	return session
+}
+
+func (s *SessionStore) History() []*Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Session, len(s.history))
+	copy(result, s.history)
+
+	return result
+}
@@ -0,0 +1,201 @@
+package onyt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type SourceChannel struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	AvatarURL string `json:"avatarUrl"`
+	Followers uint64 `json:"followers"`
+}
+
+type SourceStream struct {
+	Id           string `json:"id"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+	Category     string `json:"category"`
+	Viewers      uint64 `json:"viewers"`
+	StartedAt    int64  `json:"startedAt"`
+}
+
+type SourceVideo struct {
+	Id              string `json:"id"`
+	Title           string `json:"title"`
+	URL             string `json:"url"`
+	ThumbnailURL    string `json:"thumbnailUrl"`
+	DurationSeconds int64  `json:"durationSeconds"`
+	Views           uint64 `json:"views"`
+	PublishedAt     int64  `json:"publishedAt"`
+}
+
+type SourceState struct {
+	Platform    string          `json:"platform"`
+	Channel     *SourceChannel  `json:"channel"`
+	Live        bool            `json:"live"`
+	Streams     []*SourceStream `json:"streams"`
+	Videos      []*SourceVideo  `json:"videos"`
+	RefreshedAt int64           `json:"refreshedAt"`
+}
+
+// Source is a streaming platform channel exposed in the unified state schema.
+type Source interface {
+	Platform() string
+	Refresh(ctx context.Context) (*SourceState, error)
+}
+
+type SourceStore struct {
+	mu     sync.RWMutex
+	states map[Source]*SourceState
+	order  []Source
+}
+
+func NewSourceStore(sources []Source) *SourceStore {
+	return &SourceStore{
+		states: make(map[Source]*SourceState, len(sources)),
+		order:  sources,
+	}
+}
+
+func (s *SourceStore) Set(source Source, state *SourceState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[source] = state
+}
+
+// Add appends a source added at runtime.
+func (s *SourceStore) Add(source Source) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.order = append(s.order, source)
+}
+
+// Remove forgets a source removed at runtime.
+func (s *SourceStore) Remove(source Source) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, item := range s.order {
+		if item == source {
+			s.order = append(s.order[:i:i], s.order[i+1:]...)
+			break
+		}
+	}
+
+	delete(s.states, source)
+}
+
+func (s *SourceStore) List() []*SourceState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*SourceState, 0, len(s.order))
+
+	for _, source := range s.order {
+		if state, ok := s.states[source]; ok {
+			result = append(result, state)
+		}
+	}
+
+	return result
+}
+
+type YouTubeSource struct {
+	poller *Poller
+}
+
+func NewYouTubeSource(poller *Poller) *YouTubeSource {
+	return &YouTubeSource{
+		poller: poller,
+	}
+}
+
+func (s *YouTubeSource) Platform() string {
+	return "youtube"
+}
+
+func (s *YouTubeSource) Refresh(ctx context.Context) (*SourceState, error) {
+	if err := s.poller.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	state := s.poller.Store.Get()
+
+	result := &SourceState{
+		Platform:    s.Platform(),
+		Live:        len(state.LiveVideos) > 0,
+		Streams:     make([]*SourceStream, 0, len(state.LiveVideos)),
+		Videos:      make([]*SourceVideo, 0, len(state.Videos)),
+		RefreshedAt: time.Now().Unix(),
+	}
+
+	if c := state.Channel; c != nil {
+		result.Channel = &SourceChannel{
+			Id:  c.Id,
+			URL: fmt.Sprintf("https://www.youtube.com/channel/%s", c.Id),
+		}
+
+		if c.Snippet != nil {
+			result.Channel.Name = c.Snippet.Title
+			result.Channel.AvatarURL = BestThumbnail(c.Snippet.Thumbnails)
+		}
+
+		if c.Statistics != nil {
+			result.Channel.Followers = c.Statistics.SubscriberCount
+		}
+	}
+
+	for _, v := range state.LiveVideos {
+		fields := v.Fields()
+
+		stream := &SourceStream{
+			Id:           v.Id,
+			URL:          fmt.Sprintf("https://www.youtube.com/watch?v=%s", v.Id),
+			ThumbnailURL: fields.ThumbnailURL,
+			StartedAt:    time.Now().Unix() - fields.UptimeSeconds,
+		}
+
+		if v.Snippet != nil {
+			stream.Title = v.Snippet.Title
+			stream.Category = v.Snippet.CategoryId
+		}
+
+		if v.LiveStreamingDetails != nil {
+			stream.Viewers = v.LiveStreamingDetails.ConcurrentViewers
+		}
+
+		result.Streams = append(result.Streams, stream)
+	}
+
+	for _, v := range state.Videos {
+		fields := v.Fields()
+
+		video := &SourceVideo{
+			Id:              v.Id,
+			URL:             fmt.Sprintf("https://www.youtube.com/watch?v=%s", v.Id),
+			ThumbnailURL:    fields.ThumbnailURL,
+			DurationSeconds: fields.DurationSeconds,
+			PublishedAt:     fields.PublishedAtUnix,
+		}
+
+		if v.Snippet != nil {
+			video.Title = v.Snippet.Title
+		}
+
+		if v.Statistics != nil {
+			video.Views = v.Statistics.ViewCount
+		}
+
+		result.Videos = append(result.Videos, video)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,140 @@
+package onyt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// sponsorBlockTTL is how long the segments of a video are cached, as they
+// are submitted over time.
+const sponsorBlockTTL = time.Hour
+
+// Segment is a range of a video submitted to SponsorBlock.
+type Segment struct {
+	Category     string  `json:"category"`
+	ActionType   string  `json:"actionType"`
+	StartSeconds float64 `json:"startSeconds"`
+	EndSeconds   float64 `json:"endSeconds"`
+	UUID         string  `json:"uuid"`
+}
+
+type sponsorBlockSegment struct {
+	Category   string    `json:"category"`
+	ActionType string    `json:"actionType"`
+	Segment    []float64 `json:"segment"`
+	UUID       string    `json:"UUID"`
+}
+
+// SponsorBlockClient fetches the segments of the latest uploads, such as the
+// sponsor, intro and outro ranges, for the player frontends.
+type SponsorBlockClient struct {
+	URL        string
+	Categories []string
+
+	// Videos is the number of latest uploads enriched with their segments.
+	Videos int
+
+	cache *TTLCache[[]*Segment]
+}
+
+func NewSponsorBlockClient(baseURL string, categories []string, videos int) *SponsorBlockClient {
+	return &SponsorBlockClient{
+		URL:        strings.TrimSuffix(baseURL, "/"),
+		Categories: categories,
+		Videos:     videos,
+		cache:      NewTTLCache[[]*Segment](),
+	}
+}
+
+// Segments returns the segments of the video, none when it has no segments.
+func (c *SponsorBlockClient) Segments(ctx context.Context, videoId string) ([]*Segment, error) {
+	if segments, ok := c.cache.Get(videoId); ok {
+		return segments, nil
+	}
+
+	categories, err := json.Marshal(c.Categories)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{
+		"videoID":    {videoId},
+		"categories": {string(categories)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL+"/api/skipSegments?"+query.Encode(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	segments := make([]*Segment, 0)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var items []*sponsorBlockSegment
+
+		if err := json.NewDecoder(resp.Body).Decode(&items); err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			if len(item.Segment) != 2 {
+				continue
+			}
+
+			segments = append(segments, &Segment{
+				Category:     item.Category,
+				ActionType:   item.ActionType,
+				StartSeconds: item.Segment[0],
+				EndSeconds:   item.Segment[1],
+				UUID:         item.UUID,
+			})
+		}
+
+	// SponsorBlock answers a 404 when the video has no segments.
+	case http.StatusNotFound:
+
+	default:
+		return nil, fmt.Errorf("unexpected sponsorblock status: %s", resp.Status)
+	}
+
+	c.cache.Set(videoId, segments, sponsorBlockTTL)
+
+	return segments, nil
+}
+
+// Enrich attaches their segments to the latest videos, leaving out the ones
+// whose segments couldn't be fetched.
+func (c *SponsorBlockClient) Enrich(ctx context.Context, videos []*Video) {
+	for i, v := range videos {
+		if i == c.Videos {
+			return
+		}
+
+		segments, err := c.Segments(ctx, v.Id)
+
+		if err != nil {
+			log.Warn().Err(err).Str("video", v.Id).Msg("Unable to fetch SponsorBlock segments")
+			continue
+		}
+
+		v.segments = segments
+	}
+}
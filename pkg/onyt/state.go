@@ -0,0 +1,131 @@
+package onyt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+type State struct {
+	Channel        *youtube.Channel `json:"channel"`
+	LiveVideo      *Video           `json:"liveVideo"`
+	LiveVideos     []*Video         `json:"liveVideos"`
+	Videos         []*Video         `json:"videos"`
+	UpcomingVideos []*Video         `json:"upcomingVideos"`
+	Links          []*Link          `json:"links"`
+	Extras         *Extras          `json:"extras,omitempty"`
+}
+
+// stateRetention is the number of versions retained by a store, the current
+// one included.
+const stateRetention = 32
+
+// StateStore holds the latest state of a channel, along with the previous
+// versions up to stateRetention. States are replaced as a whole and must not
+// be modified once stored.
+type StateStore struct {
+	mu        sync.RWMutex
+	state     *State
+	version   uint64
+	updatedAt time.Time
+	changed   chan struct{}
+	retained  []*State
+}
+
+func NewStateStore() *StateStore {
+	state := new(State)
+
+	return &StateStore{
+		state:    state,
+		changed:  make(chan struct{}),
+		retained: []*State{state},
+	}
+}
+
+func (s *StateStore) Get() *State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state
+}
+
+// UpdatedAt returns when the last state was stored, the zero time if none
+// was.
+func (s *StateStore) UpdatedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.updatedAt
+}
+
+// Snapshot returns the current state, its version, and a channel closed
+// once a newer state is stored.
+func (s *StateStore) Snapshot() (*State, uint64, <-chan struct{}) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state, s.version, s.changed
+}
+
+// Version returns the state stored with the version, if still retained.
+func (s *StateStore) Version(version uint64) (*State, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if version > s.version || s.version-version >= uint64(len(s.retained)) {
+		return nil, false
+	}
+
+	return s.retained[len(s.retained)-1-int(s.version-version)], true
+}
+
+// Versions returns the oldest and latest retained versions.
+func (s *StateStore) Versions() (uint64, uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.version + 1 - uint64(len(s.retained)), s.version
+}
+
+func (s *StateStore) Set(state *State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = state
+	s.version++
+	s.updatedAt = time.Now()
+
+	if len(s.retained) == stateRetention {
+		s.retained = s.retained[1:]
+	}
+
+	s.retained = append(s.retained, state)
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Watch calls fn with every state stored after the call until the context is
+// done.
+func (s *StateStore) Watch(ctx context.Context, fn func(state *State, version uint64)) {
+	_, _, changed := s.Snapshot()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		}
+
+		var (
+			state   *State
+			version uint64
+		)
+
+		state, version, changed = s.Snapshot()
+
+		fn(state, version)
+	}
+}
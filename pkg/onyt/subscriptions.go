@@ -0,0 +1,49 @@
+package onyt
+
+import (
+	"context"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+type Subscription struct {
+	ChannelId string `json:"channelId"`
+	Title     string `json:"title"`
+}
+
+// FetchSubscriptions lists the channels the account authenticated with the
+// service subscribes to, at 1 quota unit per 50 channels.
+func FetchSubscriptions(ctx context.Context, service *youtube.Service, quota *QuotaMeter) ([]*Subscription, error) {
+	subscriptions := make([]*Subscription, 0)
+
+	call := service.Subscriptions.List([]string{"snippet"}).
+		Mine(true).
+		MaxResults(50)
+
+	for {
+		quota.Add(1)
+
+		resp, err := call.Context(ctx).Do()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range resp.Items {
+			if item.Snippet == nil || item.Snippet.ResourceId == nil || item.Snippet.ResourceId.ChannelId == "" {
+				continue
+			}
+
+			subscriptions = append(subscriptions, &Subscription{
+				ChannelId: item.Snippet.ResourceId.ChannelId,
+				Title:     item.Snippet.Title,
+			})
+		}
+
+		if resp.NextPageToken == "" {
+			return subscriptions, nil
+		}
+
+		call.PageToken(resp.NextPageToken)
+	}
+}
@@ -0,0 +1,151 @@
+package onyt
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	minThrottleBackoff = time.Minute
+	maxThrottleBackoff = time.Hour
+)
+
+// ThrottledError is returned instead of sending a request to a host which
+// asked to slow down.
+type ThrottledError struct {
+	Host  string
+	Until time.Time
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s is throttled until %s", e.Host, e.Until.Format(time.RFC3339))
+}
+
+type HostThrottle struct {
+	Host    string    `json:"host"`
+	Until   time.Time `json:"until"`
+	Strikes int       `json:"strikes"`
+}
+
+// Throttle is a transport honoring the 429 responses and Retry-After headers
+// of each host. Hosts without a Retry-After delay are backed off
+// exponentially while they keep throttling.
+type Throttle struct {
+	Transport http.RoundTripper
+
+	mu    sync.Mutex
+	hosts map[string]*HostThrottle
+}
+
+func NewThrottle(transport http.RoundTripper) *Throttle {
+	return &Throttle{
+		Transport: transport,
+		hosts:     make(map[string]*HostThrottle),
+	}
+}
+
+// DefaultThrottle throttles the requests of the scrapers and unofficial APIs.
+var DefaultThrottle = NewThrottle(http.DefaultTransport)
+
+var httpClient = &http.Client{
+	Transport: DefaultThrottle,
+	Jar:       DefaultCookieJar,
+}
+
+func retryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(header); err == nil {
+		return t.Sub(now), true
+	}
+
+	return 0, false
+}
+
+func (t *Throttle) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	now := time.Now()
+
+	t.mu.Lock()
+	throttle, ok := t.hosts[host]
+
+	if ok && now.Before(throttle.Until) {
+		t.mu.Unlock()
+		return nil, &ThrottledError{host, throttle.Until}
+	}
+
+	t.mu.Unlock()
+
+	resp, err := t.Transport.RoundTrip(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delay, hasDelay := retryAfter(resp.Header.Get("retry-after"), now)
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode == http.StatusServiceUnavailable && hasDelay):
+		throttle, ok := t.hosts[host]
+
+		if !ok {
+			throttle = &HostThrottle{
+				Host: host,
+			}
+
+			t.hosts[host] = throttle
+		}
+
+		throttle.Strikes++
+
+		if !hasDelay {
+			delay = minThrottleBackoff << (throttle.Strikes - 1)
+
+			if delay > maxThrottleBackoff || delay <= 0 {
+				delay = maxThrottleBackoff
+			}
+		}
+
+		throttle.Until = now.Add(delay)
+
+	case resp.StatusCode < 400:
+		delete(t.hosts, host)
+	}
+
+	return resp, nil
+}
+
+// Hosts returns the hosts currently throttled.
+func (t *Throttle) Hosts() []*HostThrottle {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	result := make([]*HostThrottle, 0, len(t.hosts))
+
+	for _, throttle := range t.hosts {
+		if now.Before(throttle.Until) {
+			h := *throttle
+			result = append(result, &h)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Host < result[j].Host
+	})
+
+	return result
+}
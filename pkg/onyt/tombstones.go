@@ -0,0 +1,99 @@
+package onyt
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+type Tombstone struct {
+	VideoId     string `json:"videoId"`
+	Title       string `json:"title"`
+	PublishedAt string `json:"publishedAt"`
+	RemovedAt   int64  `json:"removedAt"`
+}
+
+type TombstoneStore struct {
+	mu         sync.RWMutex
+	seen       map[string]*youtube.Video
+	tombstones []*Tombstone
+	capacity   int
+}
+
+func NewTombstoneStore(capacity int) *TombstoneStore {
+	return &TombstoneStore{
+		tombstones: make([]*Tombstone, 0),
+		capacity:   capacity,
+	}
+}
+
+func publishedAt(v *youtube.Video) time.Time {
+	if v.Snippet == nil {
+		return time.Time{}
+	}
+
+	t, _ := time.Parse(time.RFC3339, v.Snippet.PublishedAt)
+
+	return t
+}
+
+func (s *TombstoneStore) Track(videos []*youtube.Video, now time.Time) []*Tombstone {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := make(map[string]*youtube.Video, len(videos))
+
+	var oldest time.Time
+
+	for _, v := range videos {
+		current[v.Id] = v
+
+		if t := publishedAt(v); oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+	}
+
+	removed := make([]*Tombstone, 0)
+
+	for id, v := range s.seen {
+		if _, ok := current[id]; ok {
+			continue
+		}
+
+		if len(current) > 0 && publishedAt(v).Before(oldest) {
+			continue
+		}
+
+		tombstone := &Tombstone{
+			VideoId:   id,
+			RemovedAt: now.Unix(),
+		}
+
+		if v.Snippet != nil {
+			tombstone.Title = v.Snippet.Title
+			tombstone.PublishedAt = v.Snippet.PublishedAt
+		}
+
+		removed = append(removed, tombstone)
+	}
+
+	s.seen = current
+	s.tombstones = append(s.tombstones, removed...)
+
+	if len(s.tombstones) > s.capacity {
+		s.tombstones = s.tombstones[len(s.tombstones)-s.capacity:]
+	}
+
+	return removed
+}
+
+func (s *TombstoneStore) List() []*Tombstone {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Tombstone, len(s.tombstones))
+	copy(result, s.tombstones)
+
+	return result
+}
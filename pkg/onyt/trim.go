@@ -0,0 +1,136 @@
+package onyt
+
+import (
+	"google.golang.org/api/youtube/v3"
+)
+
+// TrimFields are the heavy fields which can be left out of a state.
+var TrimFields = []string{"description", "thumbnails", "tags", "localizations"}
+
+// bestThumbnails keeps the largest size of the thumbnails only.
+func bestThumbnails(details *youtube.ThumbnailDetails) *youtube.ThumbnailDetails {
+	if details == nil {
+		return nil
+	}
+
+	switch {
+	case details.Maxres != nil:
+		return &youtube.ThumbnailDetails{Maxres: details.Maxres}
+	case details.Standard != nil:
+		return &youtube.ThumbnailDetails{Standard: details.Standard}
+	case details.High != nil:
+		return &youtube.ThumbnailDetails{High: details.High}
+	case details.Medium != nil:
+		return &youtube.ThumbnailDetails{Medium: details.Medium}
+	}
+
+	return details
+}
+
+// Trim returns a copy of the video without the fields, keeping the
+// convenience fields as computed from the whole video.
+func (v *Video) Trim(fields map[string]bool) *Video {
+	if v == nil || v.Video == nil || len(fields) == 0 {
+		return v
+	}
+
+	computed := v.Fields()
+
+	video := *v.Video
+
+	if fields["localizations"] {
+		video.Localizations = nil
+	}
+
+	if v.Snippet != nil {
+		snippet := *v.Snippet
+
+		if fields["description"] {
+			snippet.Description = ""
+
+			if snippet.Localized != nil {
+				localized := *snippet.Localized
+				localized.Description = ""
+
+				snippet.Localized = &localized
+			}
+		}
+
+		if fields["thumbnails"] {
+			snippet.Thumbnails = bestThumbnails(snippet.Thumbnails)
+		}
+
+		if fields["tags"] {
+			snippet.Tags = nil
+		}
+
+		video.Snippet = &snippet
+	}
+
+	return &Video{
+		Video:  &video,
+		fields: &computed,
+	}
+}
+
+func trimVideos(videos []*Video, fields map[string]bool) []*Video {
+	if videos == nil {
+		return nil
+	}
+
+	result := make([]*Video, len(videos))
+
+	for i, v := range videos {
+		result[i] = v.Trim(fields)
+	}
+
+	return result
+}
+
+// TrimState returns a copy of the state without the fields, leaving the
+// stored state untouched.
+func TrimState(state *State, fields map[string]bool) *State {
+	if len(fields) == 0 {
+		return state
+	}
+
+	trimmed := *state
+
+	if c := state.Channel; c != nil {
+		channel := *c
+
+		if fields["localizations"] {
+			channel.Localizations = nil
+		}
+
+		if c.Snippet != nil {
+			snippet := *c.Snippet
+
+			if fields["description"] {
+				snippet.Description = ""
+
+				if snippet.Localized != nil {
+					localized := *snippet.Localized
+					localized.Description = ""
+
+					snippet.Localized = &localized
+				}
+			}
+
+			if fields["thumbnails"] {
+				snippet.Thumbnails = bestThumbnails(snippet.Thumbnails)
+			}
+
+			channel.Snippet = &snippet
+		}
+
+		trimmed.Channel = &channel
+	}
+
+	trimmed.LiveVideo = state.LiveVideo.Trim(fields)
+	trimmed.LiveVideos = trimVideos(state.LiveVideos, fields)
+	trimmed.Videos = trimVideos(state.Videos, fields)
+	trimmed.UpcomingVideos = trimVideos(state.UpcomingVideos, fields)
+
+	return &trimmed
+}
@@ -0,0 +1,252 @@
+package onyt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errTwitchUserNotFound = errors.New("twitch user not found")
+
+type twitchToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type twitchUser struct {
+	Id              string `json:"id"`
+	Login           string `json:"login"`
+	DisplayName     string `json:"display_name"`
+	ProfileImageURL string `json:"profile_image_url"`
+}
+
+type twitchStream struct {
+	Id           string `json:"id"`
+	Title        string `json:"title"`
+	GameName     string `json:"game_name"`
+	ViewerCount  uint64 `json:"viewer_count"`
+	StartedAt    string `json:"started_at"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+type twitchVideo struct {
+	Id           string `json:"id"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ViewCount    uint64 `json:"view_count"`
+	Duration     string `json:"duration"`
+	PublishedAt  string `json:"published_at"`
+}
+
+type twitchFollowers struct {
+	Total uint64 `json:"total"`
+}
+
+type TwitchSource struct {
+	clientId     string
+	clientSecret string
+	login        string
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewTwitchSource(clientId string, clientSecret string, login string) *TwitchSource {
+	return &TwitchSource{
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		login:        login,
+	}
+}
+
+func (s *TwitchSource) Platform() string {
+	return "twitch"
+}
+
+func (s *TwitchSource) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accessToken != "" && time.Now().Before(s.expiresAt) {
+		return s.accessToken, nil
+	}
+
+	form := url.Values{
+		"client_id":     {s.clientId},
+		"client_secret": {s.clientSecret},
+		"grant_type":    {"client_credentials"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://id.twitch.tv/oauth2/token", strings.NewReader(form.Encode()))
+
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("content-type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected twitch token status: %s", resp.Status)
+	}
+
+	var token twitchToken
+
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+
+	s.accessToken = token.AccessToken
+	s.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+
+	return s.accessToken, nil
+}
+
+func (s *TwitchSource) helix(ctx context.Context, path string, query url.Values, v any) error {
+	token, err := s.token(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.twitch.tv/helix"+path+"?"+query.Encode(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("authorization", "Bearer "+token)
+	req.Header.Set("client-id", s.clientId)
+
+	resp, err := httpClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		s.mu.Lock()
+		s.accessToken = ""
+		s.mu.Unlock()
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected twitch status for %s: %s", path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (s *TwitchSource) Refresh(ctx context.Context) (*SourceState, error) {
+	var users struct {
+		Data []*twitchUser `json:"data"`
+	}
+
+	if err := s.helix(ctx, "/users", url.Values{"login": {s.login}}, &users); err != nil {
+		return nil, err
+	}
+
+	if len(users.Data) == 0 {
+		return nil, errTwitchUserNotFound
+	}
+
+	user := users.Data[0]
+
+	var followers twitchFollowers
+
+	if err := s.helix(ctx, "/channels/followers", url.Values{"broadcaster_id": {user.Id}}, &followers); err != nil {
+		return nil, err
+	}
+
+	var streams struct {
+		Data []*twitchStream `json:"data"`
+	}
+
+	if err := s.helix(ctx, "/streams", url.Values{"user_id": {user.Id}}, &streams); err != nil {
+		return nil, err
+	}
+
+	var videos struct {
+		Data []*twitchVideo `json:"data"`
+	}
+
+	if err := s.helix(ctx, "/videos", url.Values{"user_id": {user.Id}, "type": {"archive"}, "first": {"25"}}, &videos); err != nil {
+		return nil, err
+	}
+
+	result := &SourceState{
+		Platform: s.Platform(),
+		Channel: &SourceChannel{
+			Id:        user.Id,
+			Name:      user.DisplayName,
+			URL:       fmt.Sprintf("https://www.twitch.tv/%s", user.Login),
+			AvatarURL: user.ProfileImageURL,
+			Followers: followers.Total,
+		},
+		Live:        len(streams.Data) > 0,
+		Streams:     make([]*SourceStream, 0, len(streams.Data)),
+		Videos:      make([]*SourceVideo, 0, len(videos.Data)),
+		RefreshedAt: time.Now().Unix(),
+	}
+
+	for _, v := range streams.Data {
+		stream := &SourceStream{
+			Id:           v.Id,
+			Title:        v.Title,
+			URL:          result.Channel.URL,
+			ThumbnailURL: twitchThumbnail(v.ThumbnailURL),
+			Category:     v.GameName,
+			Viewers:      v.ViewerCount,
+		}
+
+		if t, err := time.Parse(time.RFC3339, v.StartedAt); err == nil {
+			stream.StartedAt = t.Unix()
+		}
+
+		result.Streams = append(result.Streams, stream)
+	}
+
+	for _, v := range videos.Data {
+		video := &SourceVideo{
+			Id:           v.Id,
+			Title:        v.Title,
+			URL:          v.URL,
+			ThumbnailURL: twitchThumbnail(v.ThumbnailURL),
+			Views:        v.ViewCount,
+		}
+
+		if d, err := time.ParseDuration(v.Duration); err == nil {
+			video.DurationSeconds = int64(d.Seconds())
+		}
+
+		if t, err := time.Parse(time.RFC3339, v.PublishedAt); err == nil {
+			video.PublishedAt = t.Unix()
+		}
+
+		result.Videos = append(result.Videos, video)
+	}
+
+	return result, nil
+}
+
+func twitchThumbnail(template string) string {
+	return strings.NewReplacer("%{width}", "1280", "%{height}", "720", "{width}", "1280", "{height}", "720").
+		Replace(template)
+}
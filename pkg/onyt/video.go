@@ -0,0 +1,189 @@
+package onyt
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// Video wraps a YouTube video with computed convenience fields.
+type Video struct {
+	*youtube.Video
+
+	// fields are the convenience fields computed before the video was
+	// trimmed, as some are parsed from the trimmed fields.
+	fields *VideoFields
+
+	// game is the game played on the live stream and extras the data of its
+	// watch page, when scraped, segments the SponsorBlock segments of the
+	// video and dislikes its estimated dislikes, when fetched.
+	game     string
+	extras   *VideoExtras
+	segments []*Segment
+	dislikes *Dislikes
+}
+
+type VideoFields struct {
+	DurationSeconds int64      `json:"durationSeconds"`
+	PublishedAtUnix int64      `json:"publishedAtUnix"`
+	ThumbnailURL    string     `json:"thumbnailUrl"`
+	EngagementRatio float64    `json:"engagementRatio"`
+	UptimeSeconds   int64      `json:"uptimeSeconds,omitempty"`
+	Chapters        []*Chapter `json:"chapters"`
+	Category        *Category  `json:"category,omitempty"`
+	Topics          []string   `json:"topics,omitempty"`
+	Segments        []*Segment `json:"segments,omitempty"`
+	Dislikes        *Dislikes  `json:"dislikes,omitempty"`
+
+	Extras *VideoExtras `json:"extras,omitempty"`
+}
+
+var durationRe = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+func ParseDuration(value string) int64 {
+	sm := durationRe.FindStringSubmatch(value)
+
+	if sm == nil {
+		return 0
+	}
+
+	units := []int64{7 * 86400, 86400, 3600, 60, 1}
+	seconds := int64(0)
+
+	for i, unit := range units {
+		if n, err := strconv.ParseInt(sm[i+1], 10, 64); err == nil {
+			seconds += n * unit
+		}
+	}
+
+	return seconds
+}
+
+func BestThumbnail(details *youtube.ThumbnailDetails) string {
+	if details == nil {
+		return ""
+	}
+
+	for _, thumbnail := range []*youtube.Thumbnail{details.Maxres, details.Standard, details.High, details.Medium, details.Default} {
+		if thumbnail != nil {
+			return thumbnail.Url
+		}
+	}
+
+	return ""
+}
+
+func (v *Video) Fields() VideoFields {
+	if v.fields != nil {
+		return *v.fields
+	}
+
+	fields := VideoFields{}
+
+	// The fields of a video without its resource are computed as if empty.
+	video := v.Video
+
+	if video == nil {
+		video = new(youtube.Video)
+	}
+
+	if video.ContentDetails != nil {
+		fields.DurationSeconds = ParseDuration(video.ContentDetails.Duration)
+	}
+
+	if video.Snippet != nil {
+		if t, err := time.Parse(time.RFC3339, video.Snippet.PublishedAt); err == nil {
+			fields.PublishedAtUnix = t.Unix()
+		}
+
+		fields.ThumbnailURL = BestThumbnail(video.Snippet.Thumbnails)
+		fields.Chapters = ParseChapters(video.Snippet.Description, fields.DurationSeconds)
+		fields.Category = videoCategory(video, v.game)
+	} else {
+		fields.Chapters = make([]*Chapter, 0)
+	}
+
+	fields.Topics = videoTopics(video)
+	fields.Segments = v.segments
+	fields.Dislikes = v.dislikes
+	fields.Extras = v.extras
+
+	if video.Statistics != nil && video.Statistics.ViewCount > 0 {
+		fields.EngagementRatio = float64(video.Statistics.LikeCount) / float64(video.Statistics.ViewCount)
+	}
+
+	if d := video.LiveStreamingDetails; d != nil && d.ActualEndTime == "" {
+		if t, err := time.Parse(time.RFC3339, d.ActualStartTime); err == nil {
+			fields.UptimeSeconds = int64(time.Since(t).Seconds())
+		}
+	}
+
+	return fields
+}
+
+func (v *Video) MarshalJSON() ([]byte, error) {
+	extra, err := json.Marshal(v.Fields())
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the computed fields are marshaled without the resource, which
+	// would be null.
+	if v.Video == nil {
+		return extra, nil
+	}
+
+	base, err := json.Marshal(v.Video)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(base) <= 2 {
+		return extra, nil
+	}
+
+	return append(append(base[:len(base)-1], ','), extra[1:]...), nil
+}
+
+// UnmarshalJSON decodes a video marshaled along with its computed fields,
+// restoring the scraped and fetched ones, such as its SponsorBlock segments,
+// the other fields being computed again.
+func (v *Video) UnmarshalJSON(data []byte) error {
+	video := new(youtube.Video)
+
+	if err := json.Unmarshal(data, video); err != nil {
+		return err
+	}
+
+	var fields VideoFields
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	v.Video = video
+	v.segments = fields.Segments
+	v.dislikes = fields.Dislikes
+	v.extras = fields.Extras
+
+	if fields.Category != nil {
+		v.game = fields.Category.Game
+	}
+
+	return nil
+}
+
+func WrapVideos(videos []*youtube.Video) []*Video {
+	result := make([]*Video, len(videos))
+
+	for i, v := range videos {
+		result[i] = &Video{Video: v}
+	}
+
+	return result
+}
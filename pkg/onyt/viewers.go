@@ -0,0 +1,75 @@
+package onyt
+
+import (
+	"sync"
+	"time"
+)
+
+type ViewerSample struct {
+	Timestamp int64  `json:"timestamp"`
+	Viewers   uint64 `json:"viewers"`
+}
+
+type ViewerHistory struct {
+	mu      sync.RWMutex
+	videoId string
+	samples []ViewerSample
+	start   int
+	size    int
+}
+
+func NewViewerHistory(capacity int) *ViewerHistory {
+	return &ViewerHistory{
+		samples: make([]ViewerSample, capacity),
+	}
+}
+
+func (h *ViewerHistory) Add(videoId string, t time.Time, viewers uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.videoId != videoId {
+		h.reset(videoId)
+	}
+
+	if len(h.samples) == 0 {
+		return
+	}
+
+	h.samples[(h.start+h.size)%len(h.samples)] = ViewerSample{
+		Timestamp: t.Unix(),
+		Viewers:   viewers,
+	}
+
+	if h.size < len(h.samples) {
+		h.size++
+	} else {
+		h.start = (h.start + 1) % len(h.samples)
+	}
+}
+
+func (h *ViewerHistory) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.reset("")
+}
+
+func (h *ViewerHistory) reset(videoId string) {
+	h.videoId = videoId
+	h.start = 0
+	h.size = 0
+}
+
+func (h *ViewerHistory) Samples() (string, []ViewerSample) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	result := make([]ViewerSample, h.size)
+
+	for i := range result {
+		result[i] = h.samples[(h.start+i)%len(h.samples)]
+	}
+
+	return h.videoId, result
+}
@@ -0,0 +1,242 @@
+package onyt
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/youtube/v3"
+)
+
+// YouTubeBackend uses the YouTube Data API, along with live page scraping
+// for live detection. The service may be nil when no API key is available,
+// in which case only live detection works.
+type YouTubeBackend struct {
+	Service        *youtube.Service
+	LiveDetection  []string
+	SearchFallback *SearchFallback
+	Batcher        *VideoBatcher
+	Quota          *QuotaMeter
+	Locale         Locale
+
+	// ChannelTTL is how long the channel metadata is cached, fetched on each
+	// refresh when zero, and StatisticsTTL its statistics, refreshed on their
+	// own in the meantime. The uploads playlist ID is cached for good.
+	ChannelTTL    time.Duration
+	StatisticsTTL time.Duration
+
+	channels   *TTLCache[*youtube.Channel]
+	statistics *TTLCache[*youtube.ChannelStatistics]
+	playlists  *TTLCache[string]
+	uploads    *TTLCache[*cachedUploads]
+}
+
+type cachedUploads struct {
+	etag     string
+	videoIds []string
+}
+
+func NewYouTubeBackend(src *youtube.Service) *YouTubeBackend {
+	return &YouTubeBackend{
+		Service:        src,
+		LiveDetection:  []string{"canonical"},
+		SearchFallback: NewSearchFallback(15 * time.Minute),
+		channels:       NewTTLCache[*youtube.Channel](),
+		statistics:     NewTTLCache[*youtube.ChannelStatistics](),
+		playlists:      NewTTLCache[string](),
+		uploads:        NewTTLCache[*cachedUploads](),
+	}
+}
+
+func (b *YouTubeBackend) Name() string {
+	return "youtube"
+}
+
+func (b *YouTubeBackend) FetchChannel(ctx context.Context, channelId string) (*youtube.Channel, error) {
+	if b.Service == nil {
+		return nil, ErrNoAPIKey
+	}
+
+	if channel, ok := b.channels.Get(channelId); ok {
+		return b.refreshStatistics(ctx, channel)
+	}
+
+	b.Quota.Add(1)
+
+	call := b.Service.Channels.List([]string{"contentDetails", "localizations", "snippet", "statistics"}).
+		Id(channelId)
+
+	if b.Locale.Language != "" {
+		call.Hl(b.Locale.Language)
+	}
+
+	resp, err := call.Context(ctx).Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Items) == 0 {
+		return nil, ErrChannelNotFound
+	}
+
+	channel := resp.Items[0]
+
+	if b.Locale.Language != "" {
+		localizeChannel(channel)
+	}
+
+	if b.ChannelTTL > 0 {
+		b.channels.Set(channelId, channel, b.ChannelTTL)
+	}
+
+	if b.StatisticsTTL > 0 && channel.Statistics != nil {
+		b.statistics.Set(channelId, channel.Statistics, b.StatisticsTTL)
+	}
+
+	if d := channel.ContentDetails; d != nil && d.RelatedPlaylists != nil && d.RelatedPlaylists.Uploads != "" {
+		b.playlists.Set(channelId, d.RelatedPlaylists.Uploads, 0)
+	}
+
+	return channel, nil
+}
+
+// refreshStatistics returns a copy of the cached channel with its statistics,
+// fetched again once expired.
+func (b *YouTubeBackend) refreshStatistics(ctx context.Context, channel *youtube.Channel) (*youtube.Channel, error) {
+	statistics, ok := b.statistics.Get(channel.Id)
+
+	if !ok {
+		b.Quota.Add(1)
+
+		resp, err := b.Service.Channels.List([]string{"statistics"}).
+			Id(channel.Id).
+			Context(ctx).
+			Do()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(resp.Items) == 0 {
+			return nil, ErrChannelNotFound
+		}
+
+		statistics = resp.Items[0].Statistics
+
+		if b.StatisticsTTL > 0 && statistics != nil {
+			b.statistics.Set(channel.Id, statistics, b.StatisticsTTL)
+		}
+	}
+
+	refreshed := *channel
+	refreshed.Statistics = statistics
+
+	return &refreshed, nil
+}
+
+func (b *YouTubeBackend) FetchUploadIds(ctx context.Context, channel *youtube.Channel) ([]string, error) {
+	if b.Service == nil {
+		return nil, ErrNoAPIKey
+	}
+
+	b.Quota.Add(1)
+
+	playlistId, ok := b.playlists.Get(channel.Id)
+
+	if !ok {
+		playlistId = channel.ContentDetails.RelatedPlaylists.Uploads
+	}
+
+	call := b.Service.PlaylistItems.List([]string{"contentDetails", "snippet"}).
+		PlaylistId(playlistId).
+		MaxResults(25)
+
+	// The playlist is requested conditionally, its items being reused while
+	// its etag is unchanged.
+	cached, ok := b.uploads.Get(playlistId)
+
+	if ok {
+		call.IfNoneMatch(cached.etag)
+	}
+
+	resp, err := call.Context(ctx).Do()
+
+	if ok && googleapi.IsNotModified(err) {
+		return cached.videoIds, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	uploadIds := make([]string, 0, len(resp.Items))
+
+	for _, v := range resp.Items {
+		uploadIds = append(uploadIds, v.ContentDetails.VideoId)
+	}
+
+	b.uploads.Set(playlistId, &cachedUploads{resp.Etag, uploadIds}, 0)
+
+	return uploadIds, nil
+}
+
+func (b *YouTubeBackend) FetchVideos(ctx context.Context, videoIds []string) ([]*youtube.Video, error) {
+	if b.Service == nil {
+		return nil, ErrNoAPIKey
+	}
+
+	if b.Batcher != nil {
+		return b.Batcher.Fetch(ctx, videoIds)
+	}
+
+	videos := make([]*youtube.Video, 0, len(videoIds))
+
+	for start := 0; start < len(videoIds); start += maxBatchIds {
+		end := start + maxBatchIds
+
+		if end > len(videoIds) {
+			end = len(videoIds)
+		}
+
+		items, err := b.fetchVideos(ctx, videoIds[start:end])
+
+		if err != nil {
+			return nil, err
+		}
+
+		videos = append(videos, items...)
+	}
+
+	return videos, nil
+}
+
+// BatchVideos coalesces the videos.list calls made during the window.
+func (b *YouTubeBackend) BatchVideos(window time.Duration) {
+	b.Batcher = NewVideoBatcher(window, b.fetchVideos)
+}
+
+func (b *YouTubeBackend) fetchVideos(ctx context.Context, videoIds []string) ([]*youtube.Video, error) {
+	b.Quota.Add(1)
+
+	call := b.Service.Videos.List([]string{"contentDetails", "snippet", "statistics", "liveStreamingDetails", "topicDetails"}).
+		Id(videoIds...)
+
+	if b.Locale.Language != "" {
+		call.Hl(b.Locale.Language)
+	}
+
+	resp, err := call.Context(ctx).Do()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Locale.Language != "" {
+		for _, v := range resp.Items {
+			localizeVideo(v)
+		}
+	}
+
+	return resp.Items, nil
+}
@@ -0,0 +1,312 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: onyt/v1/onyt.proto
+
+package onytpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	OnytService_GetState_FullMethodName     = "/onyt.v1.OnytService/GetState"
+	OnytService_GetChannel_FullMethodName   = "/onyt.v1.OnytService/GetChannel"
+	OnytService_ListChannels_FullMethodName = "/onyt.v1.OnytService/ListChannels"
+	OnytService_WatchState_FullMethodName   = "/onyt.v1.OnytService/WatchState"
+	OnytService_WatchEvents_FullMethodName  = "/onyt.v1.OnytService/WatchEvents"
+)
+
+// OnytServiceClient is the client API for OnytService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OnytServiceClient interface {
+	GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*State, error)
+	GetChannel(ctx context.Context, in *GetChannelRequest, opts ...grpc.CallOption) (*Channel, error)
+	ListChannels(ctx context.Context, in *ListChannelsRequest, opts ...grpc.CallOption) (*ListChannelsResponse, error)
+	WatchState(ctx context.Context, in *WatchStateRequest, opts ...grpc.CallOption) (OnytService_WatchStateClient, error)
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (OnytService_WatchEventsClient, error)
+}
+
+type onytServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOnytServiceClient(cc grpc.ClientConnInterface) OnytServiceClient {
+	return &onytServiceClient{cc}
+}
+
+func (c *onytServiceClient) GetState(ctx context.Context, in *GetStateRequest, opts ...grpc.CallOption) (*State, error) {
+	out := new(State)
+	err := c.cc.Invoke(ctx, OnytService_GetState_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *onytServiceClient) GetChannel(ctx context.Context, in *GetChannelRequest, opts ...grpc.CallOption) (*Channel, error) {
+	out := new(Channel)
+	err := c.cc.Invoke(ctx, OnytService_GetChannel_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *onytServiceClient) ListChannels(ctx context.Context, in *ListChannelsRequest, opts ...grpc.CallOption) (*ListChannelsResponse, error) {
+	out := new(ListChannelsResponse)
+	err := c.cc.Invoke(ctx, OnytService_ListChannels_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *onytServiceClient) WatchState(ctx context.Context, in *WatchStateRequest, opts ...grpc.CallOption) (OnytService_WatchStateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &OnytService_ServiceDesc.Streams[0], OnytService_WatchState_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &onytServiceWatchStateClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type OnytService_WatchStateClient interface {
+	Recv() (*State, error)
+	grpc.ClientStream
+}
+
+type onytServiceWatchStateClient struct {
+	grpc.ClientStream
+}
+
+func (x *onytServiceWatchStateClient) Recv() (*State, error) {
+	m := new(State)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *onytServiceClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (OnytService_WatchEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &OnytService_ServiceDesc.Streams[1], OnytService_WatchEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &onytServiceWatchEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type OnytService_WatchEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type onytServiceWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *onytServiceWatchEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// OnytServiceServer is the server API for OnytService service.
+// All implementations must embed UnimplementedOnytServiceServer
+// for forward compatibility
+type OnytServiceServer interface {
+	GetState(context.Context, *GetStateRequest) (*State, error)
+	GetChannel(context.Context, *GetChannelRequest) (*Channel, error)
+	ListChannels(context.Context, *ListChannelsRequest) (*ListChannelsResponse, error)
+	WatchState(*WatchStateRequest, OnytService_WatchStateServer) error
+	WatchEvents(*WatchEventsRequest, OnytService_WatchEventsServer) error
+	mustEmbedUnimplementedOnytServiceServer()
+}
+
+// UnimplementedOnytServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedOnytServiceServer struct {
+}
+
+func (UnimplementedOnytServiceServer) GetState(context.Context, *GetStateRequest) (*State, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetState not implemented")
+}
+func (UnimplementedOnytServiceServer) GetChannel(context.Context, *GetChannelRequest) (*Channel, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChannel not implemented")
+}
+func (UnimplementedOnytServiceServer) ListChannels(context.Context, *ListChannelsRequest) (*ListChannelsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListChannels not implemented")
+}
+func (UnimplementedOnytServiceServer) WatchState(*WatchStateRequest, OnytService_WatchStateServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchState not implemented")
+}
+func (UnimplementedOnytServiceServer) WatchEvents(*WatchEventsRequest, OnytService_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
+func (UnimplementedOnytServiceServer) mustEmbedUnimplementedOnytServiceServer() {}
+
+// UnsafeOnytServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OnytServiceServer will
+// result in compilation errors.
+type UnsafeOnytServiceServer interface {
+	mustEmbedUnimplementedOnytServiceServer()
+}
+
+func RegisterOnytServiceServer(s grpc.ServiceRegistrar, srv OnytServiceServer) {
+	s.RegisterService(&OnytService_ServiceDesc, srv)
+}
+
+func _OnytService_GetState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OnytServiceServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OnytService_GetState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OnytServiceServer).GetState(ctx, req.(*GetStateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OnytService_GetChannel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetChannelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OnytServiceServer).GetChannel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OnytService_GetChannel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OnytServiceServer).GetChannel(ctx, req.(*GetChannelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OnytService_ListChannels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListChannelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OnytServiceServer).ListChannels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OnytService_ListChannels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OnytServiceServer).ListChannels(ctx, req.(*ListChannelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OnytService_WatchState_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OnytServiceServer).WatchState(m, &onytServiceWatchStateServer{stream})
+}
+
+type OnytService_WatchStateServer interface {
+	Send(*State) error
+	grpc.ServerStream
+}
+
+type onytServiceWatchStateServer struct {
+	grpc.ServerStream
+}
+
+func (x *onytServiceWatchStateServer) Send(m *State) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _OnytService_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OnytServiceServer).WatchEvents(m, &onytServiceWatchEventsServer{stream})
+}
+
+type OnytService_WatchEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type onytServiceWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *onytServiceWatchEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// OnytService_ServiceDesc is the grpc.ServiceDesc for OnytService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OnytService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "onyt.v1.OnytService",
+	HandlerType: (*OnytServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetState",
+			Handler:    _OnytService_GetState_Handler,
+		},
+		{
+			MethodName: "GetChannel",
+			Handler:    _OnytService_GetChannel_Handler,
+		},
+		{
+			MethodName: "ListChannels",
+			Handler:    _OnytService_ListChannels_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchState",
+			Handler:       _OnytService_WatchState_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _OnytService_WatchEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "onyt/v1/onyt.proto",
+}
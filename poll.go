@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+const (
+	pollDefaultTimeout = 30 * time.Second
+	pollMaxTimeout     = 2 * time.Minute
+)
+
+func servePoll(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	query := r.URL.Query()
+
+	since, err := strconv.ParseUint(query.Get("since"), 10, 64)
+
+	if err != nil && query.Get("since") != "" {
+		http.Error(w, "invalid since parameter", http.StatusBadRequest)
+		return
+	}
+
+	timeout := pollDefaultTimeout
+
+	if value := query.Get("timeout"); value != "" {
+		seconds, err := strconv.Atoi(value)
+
+		if err != nil || seconds < 0 {
+			http.Error(w, "invalid timeout parameter", http.StatusBadRequest)
+			return
+		}
+
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	if timeout > pollMaxTimeout {
+		timeout = pollMaxTimeout
+	}
+
+	// The wait is bounded by its own timeout.
+	clearWriteDeadline(w)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		state, version, changed := poller.Store.Snapshot()
+
+		w.Header().Set("x-state-version", strconv.FormatUint(version, 10))
+
+		if version > since {
+			writeJSON(w, map[string]any{
+				"version": version,
+				"state":   state,
+			})
+
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-timer.C:
+			w.WriteHeader(http.StatusNotModified)
+			return
+
+		case <-changed:
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitIdle is the duration after which the bucket of an idle client is
+// forgotten, being full again by then.
+const rateLimitIdle = 10 * time.Minute
+
+type tokenBucket struct {
+	tokens float64
+	seenAt time.Time
+}
+
+// RateLimiter is a token bucket per client IP, refilled at Rate tokens per
+// second up to Burst tokens.
+type RateLimiter struct {
+	Rate  float64
+	Burst int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	sweptAt time.Time
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+
+	return &RateLimiter{
+		Rate:    rate,
+		Burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Allow takes a token from the bucket of the client, returning how long to
+// wait for the next one when there is none left.
+func (l *RateLimiter) Allow(ip string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.sweptAt) > rateLimitIdle {
+		for key, bucket := range l.buckets {
+			if now.Sub(bucket.seenAt) > rateLimitIdle {
+				delete(l.buckets, key)
+			}
+		}
+
+		l.sweptAt = now
+	}
+
+	bucket, ok := l.buckets[ip]
+
+	if !ok {
+		bucket = &tokenBucket{
+			tokens: float64(l.Burst),
+			seenAt: now,
+		}
+
+		l.buckets[ip] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(l.Burst), bucket.tokens+now.Sub(bucket.seenAt).Seconds()*l.Rate)
+	bucket.seenAt = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / l.Rate * float64(time.Second))
+	}
+
+	bucket.tokens--
+
+	return true, 0
+}
+
+// longLived tells whether the request is a stream or a long poll, which are
+// left out of the concurrency cap.
+func longLived(r *http.Request) bool {
+	for _, suffix := range []string{"/stream", "/events", "/poll"} {
+		if strings.HasSuffix(r.URL.Path, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func rateLimitMiddleware(limiter *RateLimiter, maxConcurrent int, trustProxy bool, next http.Handler) http.Handler {
+	var slots chan struct{}
+
+	if maxConcurrent > 0 {
+		slots = make(chan struct{}, maxConcurrent)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limiter != nil {
+			if ok, wait := limiter.Allow(clientIP(r, trustProxy), time.Now()); !ok {
+				w.Header().Set("retry-after", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		if slots != nil && !longLived(r) {
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+
+			default:
+				w.Header().Set("retry-after", "1")
+				http.Error(w, "server busy", http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
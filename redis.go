@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+// RedisSync shares channel states and events between Onyt instances. States
+// are stored under "<prefix>:state:<channel>" and their channel ID published
+// on "<prefix>:state" on change, events are published on "<prefix>:events".
+type RedisSync struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisSync(url, prefix string) (*RedisSync, error) {
+	options, err := redis.ParseURL(url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisSync{
+		client: redis.NewClient(options),
+		prefix: prefix,
+	}, nil
+}
+
+func (s *RedisSync) stateKey(channelId string) string {
+	return s.prefix + ":state:" + channelId
+}
+
+// PublishState stores every new state of the poller until the context is
+// done.
+func (s *RedisSync) PublishState(ctx context.Context, poller *onyt.Poller) {
+	poller.Store.Watch(ctx, func(state *onyt.State, version uint64) {
+		data, err := json.Marshal(state)
+
+		if err != nil {
+			log.Err(err).Msg("Unable to encode state")
+			return
+		}
+
+		if err := s.client.Set(ctx, s.stateKey(poller.ChannelId), data, 0).Err(); err != nil {
+			log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to store state in Redis")
+			return
+		}
+
+		if err := s.client.Publish(ctx, s.prefix+":state", poller.ChannelId).Err(); err != nil {
+			log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to publish state to Redis")
+		}
+	})
+}
+
+// PublishEvents publishes the events emitted on the bus and returns a
+// function stopping it.
+func (s *RedisSync) PublishEvents(events *onyt.EventBus) func() {
+	return events.Subscribe(func(evt onyt.Event) {
+		data, err := json.Marshal(evt)
+
+		if err != nil {
+			log.Err(err).Msg("Unable to encode event")
+			return
+		}
+
+		if err := s.client.Publish(context.Background(), s.prefix+":events", data).Err(); err != nil {
+			log.Err(err).Str("type", evt.Type).Msg("Unable to publish event to Redis")
+		}
+	})
+}
+
+func (s *RedisSync) loadState(ctx context.Context, poller *onyt.Poller) {
+	data, err := s.client.Get(ctx, s.stateKey(poller.ChannelId)).Bytes()
+
+	if err == redis.Nil {
+		return
+	}
+
+	if err != nil {
+		log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to load state from Redis")
+		return
+	}
+
+	state := new(onyt.State)
+
+	if err := json.Unmarshal(data, state); err != nil {
+		log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to decode state")
+		return
+	}
+
+	poller.Store.Set(state)
+}
+
+// Follow mirrors the states and events published by another instance into
+// the local pollers and event bus until the context is done.
+func (s *RedisSync) Follow(ctx context.Context, pollers []*onyt.Poller, events *onyt.EventBus) error {
+	byId := make(map[string]*onyt.Poller, len(pollers))
+
+	for _, poller := range pollers {
+		byId[poller.ChannelId] = poller
+	}
+
+	pubsub := s.client.Subscribe(ctx, s.prefix+":state", s.prefix+":events")
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	for _, poller := range pollers {
+		s.loadState(ctx, poller)
+	}
+
+	messages := pubsub.Channel()
+
+	for {
+		var msg *redis.Message
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg = <-messages:
+		}
+
+		switch msg.Channel {
+		case s.prefix + ":state":
+			if poller, ok := byId[msg.Payload]; ok {
+				s.loadState(ctx, poller)
+			}
+
+		case s.prefix + ":events":
+			var evt onyt.Event
+
+			if err := json.Unmarshal([]byte(msg.Payload), &evt); err != nil {
+				log.Err(err).Msg("Unable to decode event")
+				continue
+			}
+
+			if _, ok := byId[evt.ChannelId]; ok {
+				events.Emit(evt)
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog/log"
+)
+
+type ErrorReport struct {
+	Message string            `json:"message"`
+	Tags    map[string]string `json:"tags"`
+	Stack   string            `json:"stack,omitempty"`
+	Panic   bool              `json:"panic"`
+	Time    time.Time         `json:"time"`
+}
+
+type ErrorReporter struct {
+	sentry      bool
+	webhookURL  string
+	dedupe      time.Duration
+	rateLimit   int
+	mu          sync.Mutex
+	seen        map[string]time.Time
+	windowStart time.Time
+	windowCount int
+}
+
+func NewErrorReporter(sentryEnabled bool, webhookURL string, dedupe time.Duration, rateLimit int) *ErrorReporter {
+	return &ErrorReporter{
+		sentry:     sentryEnabled,
+		webhookURL: webhookURL,
+		dedupe:     dedupe,
+		rateLimit:  rateLimit,
+		seen:       make(map[string]time.Time),
+	}
+}
+
+func (r *ErrorReporter) allow(fingerprint string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if last, ok := r.seen[fingerprint]; ok && now.Sub(last) < r.dedupe {
+		return false
+	}
+
+	if now.Sub(r.windowStart) >= time.Hour {
+		r.windowStart = now
+		r.windowCount = 0
+	}
+
+	if r.rateLimit > 0 && r.windowCount >= r.rateLimit {
+		return false
+	}
+
+	for key, last := range r.seen {
+		if now.Sub(last) >= r.dedupe {
+			delete(r.seen, key)
+		}
+	}
+
+	r.seen[fingerprint] = now
+	r.windowCount++
+
+	return true
+}
+
+func (r *ErrorReporter) Report(err error, tags map[string]string) {
+	r.report(&ErrorReport{
+		Message: err.Error(),
+		Tags:    tags,
+		Time:    time.Now(),
+	}, err)
+}
+
+// Recover reports a recovered panic, and must be called directly by a
+// deferred statement.
+func (r *ErrorReporter) Recover(tags map[string]string) {
+	p := recover()
+
+	if p == nil {
+		return
+	}
+
+	log.Error().Interface("panic", p).Msg("Recovered from panic")
+
+	r.report(&ErrorReport{
+		Message: fmt.Sprint(p),
+		Tags:    tags,
+		Stack:   string(debug.Stack()),
+		Panic:   true,
+		Time:    time.Now(),
+	}, fmt.Errorf("panic: %v", p))
+}
+
+func (r *ErrorReporter) report(report *ErrorReport, err error) {
+	if r == nil || !r.allow(fmt.Sprint(report.Tags, report.Message), report.Time) {
+		return
+	}
+
+	if r.sentry {
+		sentry.WithScope(func(scope *sentry.Scope) {
+			scope.SetTags(report.Tags)
+
+			if report.Panic {
+				scope.SetLevel(sentry.LevelFatal)
+			}
+
+			sentry.CaptureException(err)
+		})
+	}
+
+	if r.webhookURL != "" {
+		go r.sendWebhook(report)
+	}
+}
+
+func (r *ErrorReporter) sendWebhook(report *ErrorReport) {
+	body, err := json.Marshal(report)
+
+	if err != nil {
+		return
+	}
+
+	resp, err := http.Post(r.webhookURL, "application/json", bytes.NewReader(body))
+
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to send error webhook")
+		return
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Warn().Str("status", resp.Status).Msg("Error webhook rejected the report")
+	}
+}
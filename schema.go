@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+	"google.golang.org/api/youtube/v3"
+)
+
+// Schema v2 maps the YouTube types to types owned by Onyt, which don't
+// follow the changes of the API client.
+
+type ThumbnailV2 struct {
+	Size   string `json:"size"`
+	URL    string `json:"url"`
+	Width  int64  `json:"width,omitempty"`
+	Height int64  `json:"height,omitempty"`
+}
+
+type ChannelStatsV2 struct {
+	Subscribers uint64 `json:"subscribers"`
+	Views       uint64 `json:"views"`
+	Videos      uint64 `json:"videos"`
+}
+
+type ChannelV2 struct {
+	Id          string          `json:"id"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	Handle      string          `json:"handle,omitempty"`
+	URL         string          `json:"url"`
+	Stats       *ChannelStatsV2 `json:"stats"`
+	Thumbnails  []*ThumbnailV2  `json:"thumbnails"`
+}
+
+type VideoStatsV2 struct {
+	Views    uint64 `json:"views"`
+	Likes    uint64 `json:"likes"`
+	Comments uint64 `json:"comments"`
+	Viewers  uint64 `json:"viewers,omitempty"`
+
+	// EstimatedDislikes are estimated by Return YouTube Dislike, when enabled.
+	EstimatedDislikes *int64 `json:"estimatedDislikes,omitempty"`
+}
+
+type LiveDetailsV2 struct {
+	ScheduledStartAt string `json:"scheduledStartAt,omitempty"`
+	StartedAt        string `json:"startedAt,omitempty"`
+	EndedAt          string `json:"endedAt,omitempty"`
+}
+
+type VideoV2 struct {
+	Id              string          `json:"id"`
+	Title           string          `json:"title"`
+	Description     string          `json:"description,omitempty"`
+	URL             string          `json:"url"`
+	PublishedAt     string          `json:"publishedAt"`
+	DurationSeconds int64           `json:"durationSeconds"`
+	Status          string          `json:"status"`
+	Tags            []string        `json:"tags,omitempty"`
+	Stats           *VideoStatsV2   `json:"stats"`
+	Live            *LiveDetailsV2  `json:"live,omitempty"`
+	Thumbnails      []*ThumbnailV2  `json:"thumbnails"`
+	Chapters        []*onyt.Chapter `json:"chapters"`
+	Category        *onyt.Category  `json:"category,omitempty"`
+	Topics          []string        `json:"topics,omitempty"`
+	Segments        []*onyt.Segment `json:"segments,omitempty"`
+
+	Extras *onyt.VideoExtras `json:"extras,omitempty"`
+}
+
+type StateV2 struct {
+	SchemaVersion  int          `json:"schemaVersion"`
+	Channel        *ChannelV2   `json:"channel"`
+	LiveVideo      *VideoV2     `json:"liveVideo"`
+	LiveVideos     []*VideoV2   `json:"liveVideos"`
+	Videos         []*VideoV2   `json:"videos"`
+	UpcomingVideos []*VideoV2   `json:"upcomingVideos"`
+	Links          []*onyt.Link `json:"links"`
+
+	Freshness
+}
+
+// toThumbnailsV2 returns the sizes of the thumbnails, the smallest first.
+func toThumbnailsV2(details *youtube.ThumbnailDetails) []*ThumbnailV2 {
+	result := make([]*ThumbnailV2, 0)
+
+	if details == nil {
+		return result
+	}
+
+	sizes := []struct {
+		name      string
+		thumbnail *youtube.Thumbnail
+	}{
+		{"default", details.Default},
+		{"medium", details.Medium},
+		{"high", details.High},
+		{"standard", details.Standard},
+		{"maxres", details.Maxres},
+	}
+
+	for _, size := range sizes {
+		if t := size.thumbnail; t != nil {
+			result = append(result, &ThumbnailV2{
+				Size:   size.name,
+				URL:    t.Url,
+				Width:  t.Width,
+				Height: t.Height,
+			})
+		}
+	}
+
+	return result
+}
+
+func toChannelV2(c *youtube.Channel) *ChannelV2 {
+	if c == nil {
+		return nil
+	}
+
+	result := &ChannelV2{
+		Id:         c.Id,
+		URL:        fmt.Sprintf("https://www.youtube.com/channel/%s", c.Id),
+		Stats:      new(ChannelStatsV2),
+		Thumbnails: make([]*ThumbnailV2, 0),
+	}
+
+	if c.Snippet != nil {
+		result.Title = c.Snippet.Title
+		result.Description = c.Snippet.Description
+		result.Handle = c.Snippet.CustomUrl
+		result.Thumbnails = toThumbnailsV2(c.Snippet.Thumbnails)
+	}
+
+	if c.Statistics != nil {
+		result.Stats.Subscribers = c.Statistics.SubscriberCount
+		result.Stats.Views = c.Statistics.ViewCount
+		result.Stats.Videos = c.Statistics.VideoCount
+	}
+
+	return result
+}
+
+func toVideoV2(v *onyt.Video) *VideoV2 {
+	if v == nil || v.Video == nil {
+		return nil
+	}
+
+	fields := v.Fields()
+
+	result := &VideoV2{
+		Id:              v.Id,
+		URL:             fmt.Sprintf("https://www.youtube.com/watch?v=%s", v.Id),
+		DurationSeconds: fields.DurationSeconds,
+		Status:          "none",
+		Stats:           new(VideoStatsV2),
+		Thumbnails:      make([]*ThumbnailV2, 0),
+		Chapters:        fields.Chapters,
+		Category:        fields.Category,
+		Topics:          fields.Topics,
+		Segments:        fields.Segments,
+		Extras:          fields.Extras,
+	}
+
+	if v.Snippet != nil {
+		result.Title = v.Snippet.Title
+		result.Description = v.Snippet.Description
+		result.PublishedAt = v.Snippet.PublishedAt
+		result.Tags = v.Snippet.Tags
+		result.Thumbnails = toThumbnailsV2(v.Snippet.Thumbnails)
+
+		if v.Snippet.LiveBroadcastContent != "" {
+			result.Status = v.Snippet.LiveBroadcastContent
+		}
+	}
+
+	if v.Statistics != nil {
+		result.Stats.Views = v.Statistics.ViewCount
+		result.Stats.Likes = v.Statistics.LikeCount
+		result.Stats.Comments = v.Statistics.CommentCount
+	}
+
+	if fields.Dislikes != nil {
+		result.Stats.EstimatedDislikes = &fields.Dislikes.Count
+	}
+
+	if d := v.LiveStreamingDetails; d != nil {
+		result.Stats.Viewers = d.ConcurrentViewers
+
+		result.Live = &LiveDetailsV2{
+			ScheduledStartAt: d.ScheduledStartTime,
+			StartedAt:        d.ActualStartTime,
+			EndedAt:          d.ActualEndTime,
+		}
+	}
+
+	return result
+}
+
+func toVideosV2(videos []*onyt.Video) []*VideoV2 {
+	result := make([]*VideoV2, 0, len(videos))
+
+	for _, v := range videos {
+		if video := toVideoV2(v); video != nil {
+			result = append(result, video)
+		}
+	}
+
+	return result
+}
+
+func toStateV2(state *onyt.State, freshness Freshness) *StateV2 {
+	result := &StateV2{
+		SchemaVersion:  2,
+		Channel:        toChannelV2(state.Channel),
+		LiveVideo:      toVideoV2(state.LiveVideo),
+		LiveVideos:     toVideosV2(state.LiveVideos),
+		Videos:         toVideosV2(state.Videos),
+		UpcomingVideos: toVideosV2(state.UpcomingVideos),
+		Links:          state.Links,
+		Freshness:      freshness,
+	}
+
+	if result.Links == nil {
+		result.Links = make([]*onyt.Link, 0)
+	}
+
+	return result
+}
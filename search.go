@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+const maxSearchLimit = 100
+
+// searchQuery quotes the terms of the query, so that any text is a valid FTS5
+// query, the last term matching as a prefix.
+func searchQuery(q string) string {
+	terms := strings.Fields(q)
+
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+
+	if len(terms) > 0 {
+		terms[len(terms)-1] += "*"
+	}
+
+	return strings.Join(terms, " ")
+}
+
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request, channelId string) {
+	if s.Database == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+
+	q := query.Get("q")
+
+	if strings.TrimSpace(q) == "" {
+		http.Error(w, "missing query", http.StatusBadRequest)
+		return
+	}
+
+	// Raw queries use the FTS5 syntax, and may thus be invalid.
+	raw := query.Get("raw") == "1"
+
+	if !raw {
+		q = searchQuery(q)
+	}
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+
+	if err != nil || limit <= 0 || limit > maxSearchLimit {
+		limit = 20
+	}
+
+	offset, _ := strconv.Atoi(query.Get("offset"))
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	results, err := s.Database.SearchUploads(channelId, q, limit, offset)
+
+	if err != nil {
+		if raw {
+			http.Error(w, "invalid query", http.StatusBadRequest)
+			return
+		}
+
+		log.Err(err).Str("query", q).Msg("Unable to search uploads")
+		http.Error(w, "unable to search uploads", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, results)
+}
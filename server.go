@@ -0,0 +1,669 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/net/netutil"
+)
+
+// Freshness tells whether the served state is up to date, distinguishing an
+// offline channel from a failing poller.
+type Freshness struct {
+	LastRefreshedAt int64  `json:"lastRefreshedAt"`
+	LastError       string `json:"lastError,omitempty"`
+	Stale           bool   `json:"stale"`
+}
+
+type ChannelSummary struct {
+	Id              string `json:"id"`
+	Title           string `json:"title"`
+	ThumbnailURL    string `json:"thumbnailUrl"`
+	SubscriberCount uint64 `json:"subscriberCount"`
+	Live            bool   `json:"live"`
+	LiveVideoId     string `json:"liveVideoId,omitempty"`
+
+	Freshness
+}
+
+type StateResponse struct {
+	*onyt.State
+
+	Freshness
+}
+
+type LiveStatus struct {
+	Channel    *ChannelSummary `json:"channel"`
+	Live       bool            `json:"live"`
+	LiveVideo  *onyt.Video     `json:"liveVideo"`
+	LiveVideos []*onyt.Video   `json:"liveVideos"`
+}
+
+var liveChatEvents = map[string]bool{
+	"super_chat":       true,
+	"super_sticker":    true,
+	"new_member":       true,
+	"member_milestone": true,
+	"membership_gift":  true,
+}
+
+type Server struct {
+	mu      sync.RWMutex
+	pollers []*onyt.Poller
+	byId    map[string]*onyt.Poller
+	sources *onyt.SourceStore
+
+	TrustProxy bool
+	BasePath   string
+	Quota      *onyt.QuotaMeter
+	Scheduler  *onyt.Scheduler
+	Pause      *onyt.Pause
+	Election   *LeaderElection
+	Thumbnails *ThumbnailCache
+	Chat       *ChatResponder
+	Database   *Database
+	Dashboard  *Dashboard
+	Events     *EventLog
+	Deliveries *DeliveryQueue
+	Playlists  *onyt.PlaylistMonitor
+
+	// CacheControl is the default cache-control header of the responses,
+	// none when empty.
+	CacheControl string
+
+	// Trim are the heavy fields left out of the served states, unless listed
+	// by the expand query parameter.
+	Trim []string
+
+	// StaleAfter is the age past which a state is stale, answered with a 503
+	// when StaleUnavailable is set.
+	StaleAfter       time.Duration
+	StaleUnavailable bool
+
+	// RateLimit limits the requests of each client IP, and MaxConcurrent the
+	// requests served at once, streams excepted.
+	RateLimit     *RateLimiter
+	MaxConcurrent int
+
+	// AdminToken guards the admin endpoints managing the channels, which are
+	// disabled when empty.
+	AdminToken string
+	Channels   *ChannelManager
+
+	// Groups are the channels tagged into each group, served with their
+	// aggregate status.
+	Groups map[string][]string
+}
+
+func NewServer(pollers []*onyt.Poller, sources *onyt.SourceStore) *Server {
+	s := &Server{
+		pollers: pollers,
+		byId:    make(map[string]*onyt.Poller, len(pollers)),
+		sources: sources,
+	}
+
+	for _, p := range pollers {
+		s.byId[p.ChannelId] = p
+	}
+
+	return s
+}
+
+// Pollers returns the pollers of the monitored channels, in order.
+func (s *Server) Pollers() []*onyt.Poller {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.pollers
+}
+
+// Poller returns the poller of the channel, if monitored.
+func (s *Server) Poller(channelId string) (*onyt.Poller, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	poller, ok := s.byId[channelId]
+
+	return poller, ok
+}
+
+// primary returns the poller of the first channel, served at the root.
+func (s *Server) primary() (*onyt.Poller, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.pollers) == 0 {
+		return nil, false
+	}
+
+	return s.pollers[0], true
+}
+
+// addPoller serves a channel added at runtime, reporting false when already
+// monitored.
+func (s *Server) addPoller(poller *onyt.Poller) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byId[poller.ChannelId]; ok {
+		return false
+	}
+
+	// The slice is copied so the callers ranging over it are left untouched.
+	pollers := make([]*onyt.Poller, len(s.pollers), len(s.pollers)+1)
+	copy(pollers, s.pollers)
+
+	s.pollers = append(pollers, poller)
+	s.byId[poller.ChannelId] = poller
+
+	return true
+}
+
+// removePoller stops serving a channel removed at runtime, returning its
+// poller.
+func (s *Server) removePoller(channelId string) (*onyt.Poller, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	poller, ok := s.byId[channelId]
+
+	if !ok {
+		return nil, false
+	}
+
+	pollers := make([]*onyt.Poller, 0, len(s.pollers))
+
+	for _, p := range s.pollers {
+		if p != poller {
+			pollers = append(pollers, p)
+		}
+	}
+
+	s.pollers = pollers
+	delete(s.byId, channelId)
+
+	return poller, true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().
+		Set("content-type", "application/json")
+
+	json.NewEncoder(w).
+		Encode(v)
+}
+
+func (s *Server) freshness(poller *onyt.Poller) Freshness {
+	updatedAt := poller.Store.UpdatedAt()
+
+	f := Freshness{
+		Stale: updatedAt.IsZero() || (s.StaleAfter > 0 && time.Since(updatedAt) > s.StaleAfter),
+	}
+
+	if !updatedAt.IsZero() {
+		f.LastRefreshedAt = updatedAt.Unix()
+	}
+
+	if err := poller.LastError(); err != nil {
+		f.LastError = err.Error()
+	}
+
+	return f
+}
+
+// trimmed returns the fields to leave out of the state served to the
+// request, expand=all restoring them all.
+func (s *Server) trimmed(r *http.Request) map[string]bool {
+	fields := make(map[string]bool, len(s.Trim))
+
+	for _, field := range s.Trim {
+		fields[field] = true
+	}
+
+	for _, value := range r.URL.Query()["expand"] {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field == "all" {
+				return nil
+			}
+
+			delete(fields, field)
+		}
+	}
+
+	return fields
+}
+
+func (s *Server) summarize(poller *onyt.Poller) *ChannelSummary {
+	state := poller.Store.Get()
+
+	summary := &ChannelSummary{
+		Id:        poller.ChannelId,
+		Live:      state.LiveVideo != nil,
+		Freshness: s.freshness(poller),
+	}
+
+	if c := state.Channel; c != nil {
+		if c.Snippet != nil {
+			summary.Title = c.Snippet.Title
+			summary.ThumbnailURL = onyt.BestThumbnail(c.Snippet.Thumbnails)
+		}
+
+		if c.Statistics != nil {
+			summary.SubscriberCount = c.Statistics.SubscriberCount
+		}
+	}
+
+	if state.LiveVideo != nil {
+		summary.LiveVideoId = state.LiveVideo.Id
+	}
+
+	return summary
+}
+
+func (s *Server) liveStatus(poller *onyt.Poller, trim map[string]bool) *LiveStatus {
+	state := onyt.TrimState(poller.Store.Get(), trim)
+
+	status := &LiveStatus{
+		Channel:    s.summarize(poller),
+		Live:       state.LiveVideo != nil,
+		LiveVideo:  state.LiveVideo,
+		LiveVideos: state.LiveVideos,
+	}
+
+	if status.LiveVideos == nil {
+		status.LiveVideos = make([]*onyt.Video, 0)
+	}
+
+	return status
+}
+
+func (s *Server) serveChannel(w http.ResponseWriter, r *http.Request, poller *onyt.Poller, path string) {
+	freshness := s.freshness(poller)
+
+	if path == "/status" {
+		writeJSON(w, s.channelStatus(poller))
+		return
+	}
+
+	if s.StaleUnavailable && freshness.Stale {
+		w.Header().Set("content-type", "application/json")
+		w.Header().Set("retry-after", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		writeJSON(w, freshness)
+		return
+	}
+
+	switch path {
+	case "", "/":
+		writeState(w, r, poller, freshness, s.trimmed(r))
+
+	case "/live":
+		writeJSON(w, s.liveStatus(poller, s.trimmed(r)))
+
+	case "/export.csv":
+		serveExportCSV(w, r, poller)
+
+	case "/export.xml":
+		serveExportXML(w, r, poller)
+
+	case "/live/viewers/history":
+		videoId, samples := poller.Viewers.Samples()
+
+		writeJSON(w, map[string]any{
+			"videoId": videoId,
+			"samples": samples,
+		})
+
+	case "/live/chat":
+		if poller.LiveChat == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		totals, recent := poller.LiveChat.Totals()
+
+		writeJSON(w, map[string]any{
+			"totals": totals,
+			"recent": recent,
+		})
+
+	case "/live/chat/events":
+		if poller.LiveChat == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		serveEventStream(w, r, poller, liveChatEvents)
+
+	case "/poll":
+		servePoll(w, r, poller)
+
+	case "/diff":
+		s.serveDiff(w, r, poller)
+
+	case "/sessions":
+		writeJSON(w, poller.Sessions.History())
+
+	case "/stream":
+		serveStream(w, r, poller)
+
+	case "/videos":
+		serveVideos(w, r, poller)
+
+	case "/latest":
+		serveLatest(w, r, poller)
+
+	case "/random":
+		s.serveRandom(w, r, poller)
+
+	case "/videos/removed":
+		writeJSON(w, poller.Tombstones.List())
+
+	case "/badge/live.svg", "/badge/subscribers.svg", "/badge/videos.svg", "/badge/views.svg":
+		serveBadge(w, r, poller, strings.TrimPrefix(path, "/badge/"))
+
+	case "/card.png":
+		serveCard(w, r, poller)
+
+	case "/oembed":
+		serveOEmbed(w, r, poller)
+
+	case "/schedule/prediction":
+		s.servePrediction(w, r, poller)
+
+	case "/analytics/uploads":
+		s.serveUploadAnalytics(w, r, poller)
+
+	case "/search":
+		s.serveSearch(w, r, poller.ChannelId)
+
+	case "/chat/latest", "/chat/subs", "/chat/uptime":
+		if s.Chat == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		s.Chat.serve(w, r, poller, strings.TrimPrefix(path, "/chat/"))
+
+	case "/playlists":
+		if poller.Playlists == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeJSON(w, poller.Playlists.Playlists())
+
+	case "/sections":
+		if poller.Sections == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeJSON(w, poller.Sections.Sections())
+
+	case "/community":
+		if poller.Community == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeJSON(w, poller.Community.Posts())
+
+	default:
+		if videoId, ok := strings.CutSuffix(strings.TrimPrefix(path, "/videos/"), "/comments"); ok && poller.Comments != nil {
+			if comments, ok := poller.Comments.Get(videoId); ok {
+				writeJSON(w, comments)
+				return
+			}
+		}
+
+		http.NotFound(w, r)
+	}
+}
+
+// serveMonitoredPlaylist answers a monitored playlist along with its videos.
+func (s *Server) serveMonitoredPlaylist(w http.ResponseWriter, r *http.Request) {
+	playlist, ok := s.Playlists.Playlist(strings.TrimPrefix(r.URL.Path, "/playlists/"))
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	trim := s.trimmed(r)
+	trimmed := *playlist
+	trimmed.Videos = make([]*onyt.Video, len(playlist.Videos))
+
+	for i, v := range playlist.Videos {
+		trimmed.Videos[i] = v.Trim(trim)
+	}
+
+	writeJSON(w, &trimmed)
+}
+
+func (s *Server) servePrimary(w http.ResponseWriter, r *http.Request, path string) {
+	if poller, ok := s.primary(); ok {
+		s.serveChannel(w, r, poller, path)
+		return
+	}
+
+	if path == "" {
+		writeJSON(w, new(onyt.State))
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+func (s *Server) Handler() (http.Handler, error) {
+	mux := http.NewServeMux()
+
+	graphqlHandler, err := s.graphqlHandler()
+
+	if err != nil {
+		return nil, err
+	}
+
+	mux.Handle("/graphql", graphqlHandler)
+
+	mux.HandleFunc("/channels", func(w http.ResponseWriter, r *http.Request) {
+		pollers := s.Pollers()
+		summaries := make([]*ChannelSummary, 0, len(pollers))
+
+		for _, p := range pollers {
+			summaries = append(summaries, s.summarize(p))
+		}
+
+		writeJSON(w, summaries)
+	})
+
+	mux.HandleFunc("/channels/", func(w http.ResponseWriter, r *http.Request) {
+		id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/channels/"), "/")
+
+		poller, ok := s.Poller(id)
+
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		s.serveChannel(w, r, poller, "/"+rest)
+	})
+
+	if s.Dashboard != nil {
+		mux.Handle("/ui", s.Dashboard)
+		mux.Handle("/ui/", s.Dashboard)
+	}
+
+	if s.AdminToken != "" && s.Channels != nil {
+		mux.HandleFunc("/admin/channels", s.serveAdminChannels)
+		mux.HandleFunc("/admin/channels/", s.serveAdminChannels)
+		mux.HandleFunc("/refresh", s.serveRefresh)
+		mux.HandleFunc("/admin/pause", s.servePause)
+		mux.HandleFunc("/admin/resume", s.servePause)
+
+		if s.Deliveries != nil {
+			mux.HandleFunc("/admin/deadletter", s.serveDeadLetters)
+			mux.HandleFunc("/admin/deadletter/", s.serveDeadLetters)
+		}
+	}
+
+	mux.HandleFunc("/live", func(w http.ResponseWriter, r *http.Request) {
+		live := make([]*LiveStatus, 0)
+		trim := s.trimmed(r)
+
+		for _, p := range s.Pollers() {
+			if status := s.liveStatus(p, trim); status.Live {
+				live = append(live, status)
+			}
+		}
+
+		writeJSON(w, live)
+	})
+
+	mux.HandleFunc("/groups", s.serveGroups)
+	mux.HandleFunc("/groups/", s.serveGroup)
+
+	mux.HandleFunc("/sources", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.sources.List())
+	})
+
+	mux.HandleFunc("/sources/live", func(w http.ResponseWriter, r *http.Request) {
+		live := make([]*onyt.SourceState, 0)
+
+		for _, s := range s.sources.List() {
+			if s.Live {
+				live = append(live, s)
+			}
+		}
+
+		writeJSON(w, map[string]any{
+			"live":    len(live) > 0,
+			"sources": live,
+		})
+	})
+
+	if s.Thumbnails != nil {
+		mux.Handle("/thumb/", s.Thumbnails)
+	}
+
+	if s.Playlists != nil {
+		mux.HandleFunc("/playlists/", s.serveMonitoredPlaylist)
+	}
+
+	if s.Events != nil {
+		mux.HandleFunc("/events/history", s.serveEventHistory)
+	}
+
+	if s.Database != nil {
+		mux.HandleFunc("/grafana", s.serveGrafana)
+		mux.HandleFunc("/grafana/", s.serveGrafana)
+	}
+
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		s.serveSearch(w, r, "")
+	})
+
+	mux.HandleFunc("/status", s.serveStatus)
+
+	mux.HandleFunc("/quota", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.Quota.Usage())
+	})
+
+	for _, path := range []string{"/analytics/uploads", "/badge/", "/card.png", "/chat/", "/community", "/diff", "/export.csv", "/export.xml", "/latest", "/live/chat", "/live/chat/events", "/live/viewers/history", "/oembed", "/playlists", "/poll", "/random", "/schedule/prediction", "/sections", "/sessions", "/stream", "/videos", "/videos/", "/videos/removed"} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			s.servePrimary(w, r, r.URL.Path)
+		})
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		s.servePrimary(w, r, "")
+	})
+
+	var handler http.Handler = mux
+
+	if base := strings.Trim(s.BasePath, "/"); base != "" {
+		base = "/" + base
+
+		root := http.NewServeMux()
+		root.Handle(base+"/", http.StripPrefix(base, mux))
+
+		root.HandleFunc(base, func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, base+"/", http.StatusMovedPermanently)
+		})
+
+		handler = root
+	}
+
+	return requestIdMiddleware(accessLogMiddleware(s.TrustProxy, rateLimitMiddleware(s.RateLimit, s.MaxConcurrent, s.TrustProxy, jsonOptionsMiddleware(s.CacheControl, handler)))), nil
+}
+
+// HTTPOptions configures the listener of the server, bounding the resources
+// a client can hold.
+type HTTPOptions struct {
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	IdleTimeout    time.Duration
+	MaxHeaderBytes int
+	MaxConns       int
+
+	// TLSCert and TLSKey serve HTTPS, HTTP/2 included, while H2C serves
+	// HTTP/2 without TLS to the proxies supporting it.
+	TLSCert string
+	TLSKey  string
+	H2C     bool
+
+	// Listener is served instead of listening on the port, such as a socket
+	// passed by systemd.
+	Listener net.Listener
+}
+
+func (s *Server) ListenAndServe(port int, options HTTPOptions) error {
+	handler, err := s.Handler()
+
+	if err != nil {
+		return err
+	}
+
+	listener := options.Listener
+
+	if listener == nil {
+		if listener, err = net.Listen("tcp", fmt.Sprintf(":%d", port)); err != nil {
+			return err
+		}
+	}
+
+	if options.MaxConns > 0 {
+		listener = netutil.LimitListener(listener, options.MaxConns)
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadTimeout:       options.ReadTimeout,
+		ReadHeaderTimeout: options.ReadTimeout,
+		WriteTimeout:      options.WriteTimeout,
+		IdleTimeout:       options.IdleTimeout,
+		MaxHeaderBytes:    options.MaxHeaderBytes,
+	}
+
+	if options.TLSCert != "" {
+		return srv.ServeTLS(listener, options.TLSCert, options.TLSKey)
+	}
+
+	if options.H2C {
+		srv.Handler = h2c.NewHandler(handler, &http2.Server{
+			IdleTimeout: options.IdleTimeout,
+		})
+	}
+
+	return srv.Serve(listener)
+}
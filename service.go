@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+// serviceName is the name Onyt is installed under by the service manager.
+const serviceName = "onyt"
+
+var serviceCommand = &cli.Command{
+	Name:  "service",
+	Usage: "Run Onyt at boot, as a Windows service or a systemd unit on Linux",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "install",
+			Usage:     "Install the service, running Onyt with the given arguments",
+			ArgsUsage: "[-- onyt arguments]",
+			Action: func(ctx *cli.Context) error {
+				return installService(serviceName, ctx.Args().Slice())
+			},
+		},
+		{
+			Name:  "uninstall",
+			Usage: "Remove the service",
+			Action: func(ctx *cli.Context) error {
+				return uninstallService(serviceName)
+			},
+		},
+		{
+			Name:  "start",
+			Usage: "Start the service",
+			Action: func(ctx *cli.Context) error {
+				return startService(serviceName)
+			},
+		},
+		{
+			Name:  "stop",
+			Usage: "Stop the service",
+			Action: func(ctx *cli.Context) error {
+				return stopService(serviceName)
+			},
+		},
+	},
+}
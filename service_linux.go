@@ -0,0 +1,118 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// systemdUnitDir is where the units installed by the administrator live.
+const systemdUnitDir = "/etc/systemd/system"
+
+const systemdUnit = `[Unit]
+Description=Onyt, monitoring YouTube channels
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=notify
+ExecStart=%s
+Restart=on-failure
+RestartSec=5
+WatchdogSec=60
+DynamicUser=yes
+StateDirectory=onyt
+WorkingDirectory=/var/lib/onyt
+ProtectSystem=strict
+ProtectHome=yes
+PrivateTmp=yes
+NoNewPrivileges=yes
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// quoteUnitArg quotes the argument for ExecStart, escaping the characters
+// systemd would otherwise expand.
+func quoteUnitArg(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+func systemctl(args ...string) error {
+	cmd := exec.Command("systemctl", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+func installService(name string, args []string) error {
+	exe, err := os.Executable()
+
+	if err != nil {
+		return err
+	}
+
+	if exe, err = filepath.Abs(exe); err != nil {
+		return err
+	}
+
+	command := make([]string, 0, len(args)+1)
+	command = append(command, quoteUnitArg(exe))
+
+	for _, arg := range args {
+		command = append(command, quoteUnitArg(arg))
+	}
+
+	path := filepath.Join(systemdUnitDir, name+".service")
+
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("service %s already installed", name)
+	}
+
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(systemdUnit, strings.Join(command, " "))), 0644); err != nil {
+		return err
+	}
+
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+
+	return systemctl("enable", name)
+}
+
+func uninstallService(name string) error {
+	if err := systemctl("disable", "--now", name); err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(systemdUnitDir, name+".service")); err != nil {
+		return err
+	}
+
+	return systemctl("daemon-reload")
+}
+
+func startService(name string) error {
+	return systemctl("start", name)
+}
+
+func stopService(name string) error {
+	return systemctl("stop", name)
+}
+
+func runApp(app *cli.App, args []string) error {
+	return app.Run(args)
+}
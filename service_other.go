@@ -0,0 +1,31 @@
+//go:build !linux && !windows
+
+package main
+
+import (
+	"errors"
+
+	"github.com/urfave/cli/v2"
+)
+
+var errServiceUnsupported = errors.New("services are only supported on Windows and Linux")
+
+func installService(name string, args []string) error {
+	return errServiceUnsupported
+}
+
+func uninstallService(name string) error {
+	return errServiceUnsupported
+}
+
+func startService(name string) error {
+	return errServiceUnsupported
+}
+
+func stopService(name string) error {
+	return errServiceUnsupported
+}
+
+func runApp(app *cli.App, args []string) error {
+	return app.Run(args)
+}
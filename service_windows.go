@@ -0,0 +1,201 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+func installService(name string, args []string) error {
+	exe, err := os.Executable()
+
+	if err != nil {
+		return err
+	}
+
+	if exe, err = filepath.Abs(exe); err != nil {
+		return err
+	}
+
+	m, err := mgr.Connect()
+
+	if err != nil {
+		return err
+	}
+
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already installed", name)
+	}
+
+	s, err := m.CreateService(name, exe, mgr.Config{
+		DisplayName: "Onyt",
+		Description: "Monitors YouTube channels and serves their videos and live streams",
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	return s.SetRecoveryActions([]mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+	}, uint32((24 * time.Hour).Seconds()))
+}
+
+func uninstallService(name string) error {
+	m, err := mgr.Connect()
+
+	if err != nil {
+		return err
+	}
+
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	return s.Delete()
+}
+
+func startService(name string) error {
+	m, err := mgr.Connect()
+
+	if err != nil {
+		return err
+	}
+
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	return s.Start()
+}
+
+func stopService(name string) error {
+	m, err := mgr.Connect()
+
+	if err != nil {
+		return err
+	}
+
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+
+	if err != nil {
+		return err
+	}
+
+	defer s.Close()
+
+	status, err := s.Control(svc.Stop)
+
+	if err != nil {
+		return err
+	}
+
+	for deadline := time.Now().Add(30 * time.Second); status.State != svc.Stopped; {
+		if time.Now().After(deadline) {
+			return errors.New("timed out waiting for the service to stop")
+		}
+
+		time.Sleep(300 * time.Millisecond)
+
+		if status, err = s.Query(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serviceHandler runs the application until the service manager stops it.
+type serviceHandler struct {
+	app  *cli.App
+	args []string
+	err  error
+}
+
+func (h *serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- h.app.RunContext(ctx, h.args)
+	}()
+
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case h.err = <-done:
+			changes <- svc.Status{State: svc.StopPending}
+			return false, 0
+
+		case r := <-requests:
+			switch r.Cmd {
+			case svc.Interrogate:
+				changes <- r.CurrentStatus
+
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending}
+				cancel()
+
+				select {
+				case <-done:
+				case <-time.After(10 * time.Second):
+				}
+
+				return false, 0
+			}
+		}
+	}
+}
+
+// runApp runs the application as a service when started by the service
+// manager.
+func runApp(app *cli.App, args []string) error {
+	isService, err := svc.IsWindowsService()
+
+	if err != nil || !isService {
+		return app.Run(args)
+	}
+
+	handler := &serviceHandler{
+		app:  app,
+		args: args,
+	}
+
+	if err := svc.Run(serviceName, handler); err != nil {
+		return err
+	}
+
+	return handler.err
+}
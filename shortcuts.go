@@ -0,0 +1,111 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+type VideoLink struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	PublishedAt int64  `json:"publishedAt"`
+}
+
+func newVideoLink(id, title string, publishedAt int64) *VideoLink {
+	return &VideoLink{
+		Id:          id,
+		Title:       title,
+		URL:         fmt.Sprintf("https://www.youtube.com/watch?v=%s", id),
+		PublishedAt: publishedAt,
+	}
+}
+
+// writeVideoLink redirects to the video with ?redirect=1, answers with a line
+// of text with ?format=text, and with JSON otherwise.
+func writeVideoLink(w http.ResponseWriter, r *http.Request, link *VideoLink) {
+	query := r.URL.Query()
+
+	w.Header().Set("cache-control", "no-cache")
+
+	switch {
+	case query.Get("redirect") == "1":
+		http.Redirect(w, r, link.URL, http.StatusFound)
+
+	case query.Get("format") == "text":
+		w.Header().Set("content-type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, truncateChat(fmt.Sprintf("%s %s", link.Title, link.URL)))
+
+	default:
+		writeJSON(w, link)
+	}
+}
+
+func latestUpload(state *onyt.State) *onyt.Video {
+	for _, v := range state.Videos {
+		if v.Snippet != nil && v.Snippet.LiveBroadcastContent != "none" && v.Snippet.LiveBroadcastContent != "" {
+			continue
+		}
+
+		if videoType(v) != "short" {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func serveLatest(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	v := latestUpload(poller.Store.Get())
+
+	if v == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	link := newVideoLink(v.Id, "", v.Fields().PublishedAtUnix)
+
+	if v.Snippet != nil {
+		link.Title = v.Snippet.Title
+	}
+
+	writeVideoLink(w, r, link)
+}
+
+func (s *Server) serveRandom(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	var link *VideoLink
+
+	if s.Database != nil {
+		var err error
+
+		link, err = s.Database.RandomUpload(poller.ChannelId)
+
+		if err != nil && err != sql.ErrNoRows {
+			log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to pick a random upload")
+			http.Error(w, "unable to pick a random upload", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Without an archive, the video is picked among the latest ones.
+	if videos := poller.Store.Get().Videos; link == nil && len(videos) > 0 {
+		v := videos[rand.Intn(len(videos))]
+		link = newVideoLink(v.Id, "", v.Fields().PublishedAtUnix)
+
+		if v.Snippet != nil {
+			link.Title = v.Snippet.Title
+		}
+	}
+
+	if link == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	writeVideoLink(w, r, link)
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
+	"github.com/segmentio/kafka-go"
+	"github.com/seldszar/onyt/pkg/onyt"
+	"google.golang.org/protobuf/proto"
+)
+
+type SinkMessage struct {
+	Kind      string
+	Name      string
+	ChannelId string
+	Data      []byte
+}
+
+// Sink publishes events and state snapshots to an external message broker.
+type Sink interface {
+	Publish(ctx context.Context, msg SinkMessage) error
+	Close() error
+}
+
+type NATSSink struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func NewNATSSink(url, prefix string) (*NATSSink, error) {
+	conn, err := nats.Connect(url, nats.Name("onyt"), nats.MaxReconnects(-1))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &NATSSink{
+		conn:   conn,
+		prefix: prefix,
+	}, nil
+}
+
+func (s *NATSSink) Publish(ctx context.Context, msg SinkMessage) error {
+	subject := fmt.Sprintf("%s.events.%s", s.prefix, msg.Name)
+
+	if msg.Kind == "state" {
+		subject = fmt.Sprintf("%s.state.%s", s.prefix, msg.ChannelId)
+	}
+
+	return s.conn.Publish(subject, msg.Data)
+}
+
+func (s *NATSSink) Close() error {
+	return s.conn.Drain()
+}
+
+type KafkaSink struct {
+	writer     *kafka.Writer
+	topic      string
+	stateTopic string
+}
+
+func NewKafkaSink(brokers []string, topic, stateTopic string) *KafkaSink {
+	return &KafkaSink{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			BatchTimeout:           50 * time.Millisecond,
+			AllowAutoTopicCreation: true,
+		},
+		topic:      topic,
+		stateTopic: stateTopic,
+	}
+}
+
+func (s *KafkaSink) Publish(ctx context.Context, msg SinkMessage) error {
+	message := kafka.Message{
+		Topic: s.topic,
+		Key:   []byte(msg.ChannelId),
+		Value: msg.Data,
+		Headers: []kafka.Header{
+			{Key: "type", Value: []byte(msg.Name)},
+		},
+	}
+
+	if msg.Kind == "state" {
+		message.Topic = s.stateTopic
+	}
+
+	return s.writer.WriteMessages(ctx, message)
+}
+
+func (s *KafkaSink) Close() error {
+	return s.writer.Close()
+}
+
+// sinkQueueSize is the number of messages waiting for the sinks, past which
+// the others are dropped.
+const sinkQueueSize = 1024
+
+// SinkPublisher encodes the emitted events, and optionally every new state,
+// before handing them to its sinks in the background, so a slow sink doesn't
+// hold the event bus.
+type SinkPublisher struct {
+	Sinks     []Sink
+	Format    string
+	Snapshots bool
+
+	queue chan SinkMessage
+}
+
+func NewSinkPublisher(format string, snapshots bool) *SinkPublisher {
+	return &SinkPublisher{
+		Format:    format,
+		Snapshots: snapshots,
+		queue:     make(chan SinkMessage, sinkQueueSize),
+	}
+}
+
+func (p *SinkPublisher) encodeEvent(evt onyt.Event) ([]byte, error) {
+	if p.Format != "protobuf" {
+		return json.Marshal(evt)
+	}
+
+	message, err := toProtoEvent(evt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(message)
+}
+
+func (p *SinkPublisher) encodeState(state *onyt.State, version uint64) ([]byte, error) {
+	if p.Format != "protobuf" {
+		return json.Marshal(state)
+	}
+
+	return proto.Marshal(toProtoState(state, version))
+}
+
+// publish queues the message, dropping it when the sinks are lagging behind.
+func (p *SinkPublisher) publish(ctx context.Context, msg SinkMessage) {
+	select {
+	case p.queue <- msg:
+	default:
+		log.Warn().Str("kind", msg.Kind).Str("channel", msg.ChannelId).Msg("Sink queue full, dropping message")
+	}
+}
+
+// send hands the queued messages to the sinks until the context is done,
+// closing them afterwards.
+func (p *SinkPublisher) send(ctx context.Context) {
+	defer func() {
+		for _, sink := range p.Sinks {
+			sink.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case msg := <-p.queue:
+			for _, sink := range p.Sinks {
+				if err := sink.Publish(ctx, msg); err != nil {
+					log.Err(err).Str("kind", msg.Kind).Str("channel", msg.ChannelId).Msg("Unable to publish message")
+				}
+			}
+		}
+	}
+}
+
+// Watch publishes the states of the channel until the context is done, when
+// the snapshots are enabled.
+func (p *SinkPublisher) Watch(ctx context.Context, poller *onyt.Poller) {
+	if !p.Snapshots {
+		return
+	}
+
+	poller.Store.Watch(ctx, func(state *onyt.State, version uint64) {
+		data, err := p.encodeState(state, version)
+
+		if err != nil {
+			log.Err(err).Msg("Unable to encode state")
+			return
+		}
+
+		p.publish(ctx, SinkMessage{
+			Kind:      "state",
+			ChannelId: poller.ChannelId,
+			Data:      data,
+		})
+	})
+}
+
+// Start publishes the events until the context is done.
+func (p *SinkPublisher) Start(ctx context.Context, events *onyt.EventBus) {
+	unsubscribe := events.Subscribe(func(evt onyt.Event) {
+		data, err := p.encodeEvent(evt)
+
+		if err != nil {
+			log.Err(err).Str("type", evt.Type).Msg("Unable to encode event")
+			return
+		}
+
+		p.publish(ctx, SinkMessage{
+			Kind:      "event",
+			Name:      evt.Type,
+			ChannelId: evt.ChannelId,
+			Data:      data,
+		})
+	})
+
+	go p.send(ctx)
+
+	go func() {
+		<-ctx.Done()
+
+		unsubscribe()
+	}()
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+type slackNotifier struct {
+	URL  string            `yaml:"url"`
+	URLs map[string]string `yaml:"urls"`
+}
+
+func newSlackNotifier(config *NotifierConfig) (Notifier, error) {
+	n := new(slackNotifier)
+
+	if err := config.Decode(n); err != nil {
+		return nil, err
+	}
+
+	if n.URL == "" && len(n.URLs) == 0 {
+		return nil, errors.New("missing webhook url")
+	}
+
+	return n, nil
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackElement struct {
+	Type     string     `json:"type"`
+	Text     *slackText `json:"text,omitempty"`
+	URL      string     `json:"url,omitempty"`
+	Style    string     `json:"style,omitempty"`
+	ImageURL string     `json:"image_url,omitempty"`
+	AltText  string     `json:"alt_text,omitempty"`
+}
+
+type slackBlock struct {
+	Type      string        `json:"type"`
+	Text      *slackText    `json:"text,omitempty"`
+	Accessory *slackElement `json:"accessory,omitempty"`
+	Elements  []any         `json:"elements,omitempty"`
+}
+
+type slackMessage struct {
+	Text   string        `json:"text"`
+	Blocks []*slackBlock `json:"blocks,omitempty"`
+}
+
+// slackEscape escapes the control characters of the mrkdwn format.
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+func slackStats(v *onyt.Video) string {
+	var stats []string
+
+	if d := v.LiveStreamingDetails; d != nil && d.ConcurrentViewers > 0 {
+		stats = append(stats, fmt.Sprintf(":eyes: %s watching", formatCount(d.ConcurrentViewers)))
+	}
+
+	if s := v.Statistics; s != nil {
+		if s.ViewCount > 0 {
+			stats = append(stats, fmt.Sprintf(":arrow_forward: %s views", formatCount(s.ViewCount)))
+		}
+
+		if s.LikeCount > 0 {
+			stats = append(stats, fmt.Sprintf(":thumbsup: %s likes", formatCount(s.LikeCount)))
+		}
+	}
+
+	return strings.Join(stats, "   ")
+}
+
+func slackBlocks(n *Notification) []*slackBlock {
+	v, ok := n.Data.(*onyt.Video)
+
+	if !ok || v.Snippet == nil {
+		return nil
+	}
+
+	url := fmt.Sprintf("https://www.youtube.com/watch?v=%s", v.Id)
+	channel := slackEscape(channelTitle(n))
+
+	var heading, button string
+
+	switch n.Type {
+	case "live_started":
+		heading, button = fmt.Sprintf(":red_circle: *%s* is live", channel), "Watch stream"
+
+	case "video_uploaded":
+		heading, button = fmt.Sprintf("*%s* uploaded a new video", channel), "Watch video"
+
+	default:
+		return nil
+	}
+
+	if n.Message != "" {
+		heading = n.Message
+	}
+
+	section := &slackBlock{
+		Type: "section",
+		Text: &slackText{"mrkdwn", fmt.Sprintf("%s\n<%s|%s>", heading, url, slackEscape(v.Snippet.Title))},
+	}
+
+	if thumbnail := onyt.BestThumbnail(v.Snippet.Thumbnails); thumbnail != "" {
+		section.Accessory = &slackElement{
+			Type:     "image",
+			ImageURL: thumbnail,
+			AltText:  v.Snippet.Title,
+		}
+	}
+
+	blocks := []*slackBlock{section}
+
+	if stats := slackStats(v); stats != "" {
+		blocks = append(blocks, &slackBlock{
+			Type:     "context",
+			Elements: []any{&slackText{"mrkdwn", stats}},
+		})
+	}
+
+	blocks = append(blocks, &slackBlock{
+		Type: "actions",
+		Elements: []any{
+			&slackElement{
+				Type:  "button",
+				Text:  &slackText{"plain_text", button},
+				URL:   url,
+				Style: "primary",
+			},
+		},
+	})
+
+	return blocks
+}
+
+func (s *slackNotifier) Notify(ctx context.Context, n *Notification) error {
+	url, ok := s.URLs[n.ChannelId]
+
+	if !ok {
+		url = s.URL
+	}
+
+	if url == "" {
+		return nil
+	}
+
+	return postJSON(ctx, url, &slackMessage{
+		Text:   defaultMessage(n),
+		Blocks: slackBlocks(n),
+	})
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// smtpNotifier sends emails, either immediately or as a digest of the
+// notifications received over an interval.
+type smtpNotifier struct {
+	Host     string        `yaml:"host"`
+	Port     int           `yaml:"port"`
+	Username string        `yaml:"username"`
+	Password string        `yaml:"password"`
+	TLS      string        `yaml:"tls"`
+	From     string        `yaml:"from"`
+	To       []string      `yaml:"to"`
+	Subject  string        `yaml:"subject"`
+	Digest   time.Duration `yaml:"digest"`
+
+	subject *template.Template
+	from    string
+	to      []string
+
+	mu      sync.Mutex
+	pending []string
+}
+
+func newSMTPNotifier(config *NotifierConfig) (Notifier, error) {
+	n := &smtpNotifier{
+		TLS: "starttls",
+	}
+
+	if err := config.Decode(n); err != nil {
+		return nil, err
+	}
+
+	if n.Host == "" {
+		return nil, errors.New("missing host")
+	}
+
+	if n.From == "" || len(n.To) == 0 {
+		return nil, errors.New("missing sender or recipients")
+	}
+
+	from, err := mail.ParseAddress(n.From)
+
+	if err != nil {
+		return nil, err
+	}
+
+	n.from = from.Address
+
+	for _, value := range n.To {
+		to, err := mail.ParseAddress(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		n.to = append(n.to, to.Address)
+	}
+
+	switch n.TLS {
+	case "starttls", "none":
+		if n.Port == 0 {
+			n.Port = 587
+		}
+
+	case "tls":
+		if n.Port == 0 {
+			n.Port = 465
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown tls mode: %s", n.TLS)
+	}
+
+	if n.subject, err = parseTemplate("subject", n.Subject); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func (s *smtpNotifier) Notify(ctx context.Context, n *Notification) error {
+	message := defaultMessage(n)
+
+	if s.Digest <= 0 {
+		subject := fmt.Sprintf("%s: %s", channelTitle(n), strings.ReplaceAll(n.Type, "_", " "))
+
+		if s.subject != nil {
+			var err error
+
+			if subject, err = renderTemplate(s.subject, n); err != nil {
+				return err
+			}
+		}
+
+		return s.send(ctx, subject, message)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 {
+		time.AfterFunc(s.Digest, s.flush)
+	}
+
+	s.pending = append(s.pending, fmt.Sprintf("[%s] %s", n.Time.Format(time.RFC1123), message))
+
+	return nil
+}
+
+func (s *smtpNotifier) flush() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	subject := fmt.Sprintf("Onyt digest: %d notifications", len(pending))
+
+	if len(pending) == 1 {
+		subject = "Onyt digest: 1 notification"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := s.send(ctx, subject, strings.Join(pending, "\n\n")); err != nil {
+		log.Err(err).Str("host", s.Host).Msg("Unable to send email digest")
+	}
+}
+
+func (s *smtpNotifier) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	dialer := new(net.Dialer)
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	config := &tls.Config{
+		ServerName: s.Host,
+	}
+
+	if s.TLS == "tls" {
+		conn = tls.Client(conn, config)
+	}
+
+	client, err := smtp.NewClient(conn, s.Host)
+
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if s.TLS == "starttls" {
+		if err := client.StartTLS(config); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+func (s *smtpNotifier) message(subject, body string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", s.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.Join(strings.Fields(subject), " ")))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprint(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprint(&buf, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprint(&buf, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+
+	if _, err := w.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *smtpNotifier) send(ctx context.Context, subject, body string) error {
+	message, err := s.message(subject, body)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := s.dial(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer client.Close()
+
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+
+	for _, to := range s.to {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+type ChannelStatus struct {
+	Id       string              `json:"id"`
+	Errors   []*onyt.ErrorCount  `json:"errors"`
+	Schedule *onyt.ScheduleStats `json:"schedule,omitempty"`
+
+	Freshness
+}
+
+type Status struct {
+	Healthy  bool             `json:"healthy"`
+	Errors   map[string]int64 `json:"errors"`
+	Channels []*ChannelStatus `json:"channels"`
+	Quota    *onyt.QuotaUsage `json:"quota"`
+
+	// PausedSince is when the polling was paused, zero when running.
+	Paused      bool  `json:"paused"`
+	PausedSince int64 `json:"pausedSince,omitempty"`
+
+	// Standby tells whether the instance mirrors the state of the elected
+	// leader.
+	Standby bool `json:"standby,omitempty"`
+
+	Throttled []*onyt.HostThrottle `json:"throttled"`
+}
+
+func (s *Server) channelStatus(poller *onyt.Poller) *ChannelStatus {
+	status := &ChannelStatus{
+		Id:        poller.ChannelId,
+		Errors:    poller.Errors.List(),
+		Freshness: s.freshness(poller),
+	}
+
+	if s.Scheduler != nil {
+		status.Schedule = s.Scheduler.Stats(poller.ChannelId)
+	}
+
+	return status
+}
+
+func (s *Server) serveStatus(w http.ResponseWriter, r *http.Request) {
+	pollers := s.Pollers()
+
+	status := &Status{
+		Healthy:  true,
+		Errors:   make(map[string]int64),
+		Channels: make([]*ChannelStatus, 0, len(pollers)),
+		Quota:    s.Quota.Usage(),
+
+		Paused:      s.Pause.Paused(),
+		PausedSince: s.Pause.Since(),
+		Standby:     s.Election != nil && !s.Election.Leader(),
+
+		Throttled: onyt.DefaultThrottle.Hosts(),
+	}
+
+	for _, p := range pollers {
+		channel := s.channelStatus(p)
+
+		if channel.Stale {
+			status.Healthy = false
+		}
+
+		for _, count := range channel.Errors {
+			status.Errors[count.Kind] += int64(count.Count)
+		}
+
+		status.Channels = append(status.Channels, channel)
+	}
+
+	writeJSON(w, status)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+const (
+	streamResyncInterval = 30
+	streamKeepAlive      = 30 * time.Second
+)
+
+func writeSSE(w http.ResponseWriter, id uint64, event string, data any) error {
+	payload, err := json.Marshal(data)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, payload)
+
+	return err
+}
+
+func waitForChange(w http.ResponseWriter, r *http.Request, flusher http.Flusher, keepAlive <-chan time.Time, changed <-chan struct{}) bool {
+	for {
+		select {
+		case <-r.Context().Done():
+			return false
+
+		case <-keepAlive:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return false
+			}
+
+			flusher.Flush()
+
+		case <-changed:
+			return true
+		}
+	}
+}
+
+// clearWriteDeadline lets the stream outlive the write timeout of the server.
+func clearWriteDeadline(w http.ResponseWriter) {
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+}
+
+func serveStream(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	patch := r.URL.Query().Get("patch") != ""
+
+	clearWriteDeadline(w)
+
+	w.Header().Set("content-type", "text/event-stream")
+	w.Header().Set("cache-control", "no-cache")
+	w.Header().Set("connection", "keep-alive")
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	var (
+		previous    any
+		lastVersion uint64
+		sent        int
+	)
+
+	for {
+		state, version, changed := poller.Store.Snapshot()
+
+		doc, err := toJSONValue(state)
+
+		if err != nil {
+			return
+		}
+
+		if !patch || previous == nil || sent%streamResyncInterval == 0 {
+			err = writeSSE(w, version, "snapshot", map[string]any{
+				"version": version,
+				"state":   doc,
+			})
+		} else if ops := diffJSON("", previous, doc); len(ops) > 0 {
+			err = writeSSE(w, version, "patch", map[string]any{
+				"version":     version,
+				"baseVersion": lastVersion,
+				"operations":  ops,
+			})
+		}
+
+		if err != nil {
+			return
+		}
+
+		flusher.Flush()
+
+		previous = doc
+		lastVersion = version
+		sent++
+
+		if !waitForChange(w, r, flusher, keepAlive.C, changed) {
+			return
+		}
+	}
+}
+
+func serveEventStream(w http.ResponseWriter, r *http.Request, poller *onyt.Poller, types map[string]bool) {
+	flusher, ok := w.(http.Flusher)
+
+	if !ok || poller.Events == nil {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	events := make(chan onyt.Event, 64)
+
+	unsubscribe := poller.Events.Subscribe(func(evt onyt.Event) {
+		if evt.ChannelId != poller.ChannelId || !types[evt.Type] {
+			return
+		}
+
+		select {
+		case events <- evt:
+		default:
+		}
+	})
+
+	defer unsubscribe()
+
+	clearWriteDeadline(w)
+
+	w.Header().Set("content-type", "text/event-stream")
+	w.Header().Set("cache-control", "no-cache")
+	w.Header().Set("connection", "keep-alive")
+
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for id := uint64(1); ; {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+
+		case evt := <-events:
+			if err := writeSSE(w, id, evt.Type, evt); err != nil {
+				return
+			}
+
+			id++
+		}
+
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+	"google.golang.org/api/youtube/v3"
+)
+
+// SubscriptionSync monitors the channels the authenticated account subscribes
+// to, matching the include patterns and none of the exclude ones. The
+// channels it added are removed once unsubscribed, the configured ones being
+// left untouched.
+type SubscriptionSync struct {
+	Service *youtube.Service
+	Quota   *onyt.QuotaMeter
+	Manager *ChannelManager
+
+	// Include and Exclude are patterns, such as "*gaming*", matched against
+	// the channel IDs and titles, regardless of case.
+	Include []string
+	Exclude []string
+
+	mu     sync.Mutex
+	synced map[string]bool
+}
+
+func NewSubscriptionSync(service *youtube.Service, quota *onyt.QuotaMeter, include, exclude []string) *SubscriptionSync {
+	return &SubscriptionSync{
+		Service: service,
+		Quota:   quota,
+		Include: include,
+		Exclude: exclude,
+		synced:  make(map[string]bool),
+	}
+}
+
+func matchesAny(patterns []string, subscription *onyt.Subscription) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+
+		for _, value := range []string{subscription.ChannelId, subscription.Title} {
+			if ok, _ := path.Match(pattern, strings.ToLower(value)); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Fetch returns the IDs of the subscribed channels passing the filters.
+func (s *SubscriptionSync) Fetch(ctx context.Context) ([]string, error) {
+	subscriptions, err := onyt.FetchSubscriptions(ctx, s.Service, s.Quota)
+
+	if err != nil {
+		return nil, err
+	}
+
+	channelIds := make([]string, 0, len(subscriptions))
+
+	for _, subscription := range subscriptions {
+		if len(s.Include) > 0 && !matchesAny(s.Include, subscription) {
+			continue
+		}
+
+		if matchesAny(s.Exclude, subscription) {
+			continue
+		}
+
+		channelIds = append(channelIds, subscription.ChannelId)
+	}
+
+	return channelIds, nil
+}
+
+// Seed adds the subscribed channels to the configured ones on startup.
+func (s *SubscriptionSync) Seed(ctx context.Context, channels []string) ([]string, error) {
+	channelIds, err := s.Fetch(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, channelId := range channelIds {
+		if !contains(channels, channelId) {
+			channels = append(channels, channelId)
+			s.synced[channelId] = true
+		}
+	}
+
+	log.Info().Int("channels", len(channelIds)).Msg("Subscriptions synced")
+
+	return channels, nil
+}
+
+// Sync adds the channels subscribed since the last sync and removes the
+// unsubscribed ones.
+func (s *SubscriptionSync) Sync(ctx context.Context) error {
+	channelIds, err := s.Fetch(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, channelId := range channelIds {
+		if _, ok := s.Manager.server.Poller(channelId); ok {
+			continue
+		}
+
+		if _, err := s.Manager.add(ctx, channelId, false); err != nil {
+			log.Err(err).Str("channel", channelId).Msg("Unable to add subscribed channel")
+			continue
+		}
+
+		s.synced[channelId] = true
+	}
+
+	for channelId := range s.synced {
+		if contains(channelIds, channelId) {
+			continue
+		}
+
+		if err := s.Manager.remove(channelId, false); err != nil && !errors.Is(err, errChannelUnknown) {
+			log.Err(err).Str("channel", channelId).Msg("Unable to remove unsubscribed channel")
+			continue
+		}
+
+		delete(s.synced, channelId)
+	}
+
+	return nil
+}
+
+// Run syncs the subscriptions at the given interval until the context is
+// done.
+func (s *SubscriptionSync) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Sync(ctx); err != nil && ctx.Err() == nil {
+			log.Err(err).Msg("Unable to sync subscriptions")
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/image/draw"
+)
+
+const maxThumbnailWidth = 1920
+
+var (
+	videoIdRe = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+	thumbnailQualities = []string{"maxres", "sd", "hq", "mq", ""}
+)
+
+type thumbnailEntry struct {
+	size     int64
+	storedAt time.Time
+	usedAt   time.Time
+}
+
+// ThumbnailCache proxies video thumbnails, keeping them on disk up to a total
+// size, evicting the least recently used ones first.
+type ThumbnailCache struct {
+	dir     string
+	maxSize int64
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*thumbnailEntry
+	size    int64
+}
+
+func NewThumbnailCache(dir string, maxSize int64, ttl time.Duration) (*ThumbnailCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	c := &ThumbnailCache{
+		dir:     dir,
+		maxSize: maxSize,
+		ttl:     ttl,
+		entries: make(map[string]*thumbnailEntry),
+	}
+
+	files, err := os.ReadDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		info, err := file.Info()
+
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		c.entries[file.Name()] = &thumbnailEntry{
+			size:     info.Size(),
+			storedAt: info.ModTime(),
+			usedAt:   info.ModTime(),
+		}
+
+		c.size += info.Size()
+	}
+
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+
+	return c, nil
+}
+
+func (c *ThumbnailCache) evict() {
+	if c.size <= c.maxSize {
+		return
+	}
+
+	names := make([]string, 0, len(c.entries))
+
+	for name := range c.entries {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return c.entries[names[i]].usedAt.Before(c.entries[names[j]].usedAt)
+	})
+
+	for _, name := range names {
+		if c.size <= c.maxSize {
+			return
+		}
+
+		c.remove(name)
+	}
+}
+
+func (c *ThumbnailCache) remove(name string) {
+	if entry, ok := c.entries[name]; ok {
+		os.Remove(filepath.Join(c.dir, name))
+
+		c.size -= entry.size
+		delete(c.entries, name)
+	}
+}
+
+func (c *ThumbnailCache) get(name string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[name]
+
+	if !ok {
+		return nil, false
+	}
+
+	if time.Since(entry.storedAt) > c.ttl {
+		c.remove(name)
+		return nil, false
+	}
+
+	data, err := os.ReadFile(filepath.Join(c.dir, name))
+
+	if err != nil {
+		c.remove(name)
+		return nil, false
+	}
+
+	entry.usedAt = time.Now()
+
+	return data, true
+}
+
+func (c *ThumbnailCache) put(name string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.WriteFile(filepath.Join(c.dir, name), data, 0o644); err != nil {
+		log.Warn().Err(err).Msg("Unable to cache thumbnail")
+		return
+	}
+
+	c.remove(name)
+
+	now := time.Now()
+
+	c.entries[name] = &thumbnailEntry{
+		size:     int64(len(data)),
+		storedAt: now,
+		usedAt:   now,
+	}
+
+	c.size += int64(len(data))
+	c.evict()
+}
+
+func fetchThumbnail(r *http.Request, videoId, quality string) ([]byte, error) {
+	start := 0
+
+	for i, q := range thumbnailQualities {
+		if q == quality {
+			start = i
+		}
+	}
+
+	for _, q := range thumbnailQualities[start:] {
+		req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, fmt.Sprintf("https://i.ytimg.com/vi/%s/%sdefault.jpg", videoId, q), nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return data, nil
+		}
+
+		if resp.StatusCode != http.StatusNotFound {
+			return nil, fmt.Errorf("unexpected thumbnail status: %s", resp.Status)
+		}
+	}
+
+	return nil, nil
+}
+
+func resizeThumbnail(data []byte, width int) ([]byte, error) {
+	src, err := jpeg.Decode(bytes.NewReader(data))
+
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := src.Bounds()
+
+	if width >= bounds.Dx() {
+		return data, nil
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, bounds.Dy()*width/bounds.Dx()))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *ThumbnailCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	videoId := strings.TrimPrefix(r.URL.Path, "/thumb/")
+
+	if !videoIdRe.MatchString(videoId) {
+		http.NotFound(w, r)
+		return
+	}
+
+	quality := r.URL.Query().Get("quality")
+
+	if quality == "default" {
+		quality = ""
+	}
+
+	width, _ := strconv.Atoi(r.URL.Query().Get("width"))
+
+	if width < 0 || width > maxThumbnailWidth {
+		http.Error(w, "invalid width", http.StatusBadRequest)
+		return
+	}
+
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s/%s/%d", videoId, quality, width)))
+	name := hex.EncodeToString(sum[:]) + ".jpg"
+
+	data, ok := c.get(name)
+
+	if !ok {
+		original, err := fetchThumbnail(r, videoId, quality)
+
+		if err != nil {
+			log.Warn().Err(err).Str("video", videoId).Msg("Unable to fetch thumbnail")
+			http.Error(w, "unable to fetch thumbnail", http.StatusBadGateway)
+			return
+		}
+
+		if original == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if data = original; width > 0 {
+			if data, err = resizeThumbnail(original, width); err != nil {
+				log.Warn().Err(err).Str("video", videoId).Msg("Unable to resize thumbnail")
+				data = original
+			}
+		}
+
+		c.put(name, data)
+	}
+
+	w.Header().Set("content-type", "image/jpeg")
+	w.Header().Set("cache-control", fmt.Sprintf("public, max-age=%d", int(c.ttl.Seconds())))
+	w.Header().Set("access-control-allow-origin", "*")
+
+	w.Write(data)
+}
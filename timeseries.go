@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+// Sample is a point of a time series, tagged with the channel it belongs to.
+type Sample struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]uint64
+	Time        time.Time
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// stateSamples returns the channel statistics and the viewers of each live
+// video of the state.
+func stateSamples(channelId string, state *onyt.State, now time.Time) []*Sample {
+	samples := make([]*Sample, 0, 1+len(state.LiveVideos))
+
+	if c := state.Channel; c != nil && c.Statistics != nil {
+		samples = append(samples, &Sample{
+			Measurement: "channel",
+			Tags: map[string]string{
+				"channel": channelId,
+			},
+			Fields: map[string]uint64{
+				"subscribers": c.Statistics.SubscriberCount,
+				"views":       c.Statistics.ViewCount,
+				"videos":      c.Statistics.VideoCount,
+			},
+			Time: now,
+		})
+	}
+
+	for _, v := range state.LiveVideos {
+		fields := make(map[string]uint64)
+
+		if v.LiveStreamingDetails != nil {
+			fields["viewers"] = v.LiveStreamingDetails.ConcurrentViewers
+		}
+
+		if v.Statistics != nil {
+			fields["likes"] = v.Statistics.LikeCount
+		}
+
+		samples = append(samples, &Sample{
+			Measurement: "live",
+			Tags: map[string]string{
+				"channel": channelId,
+				"video":   v.Id,
+			},
+			Fields: fields,
+			Time:   now,
+		})
+	}
+
+	return samples
+}
+
+// TimeSeriesWriter writes samples to a time series database.
+type TimeSeriesWriter interface {
+	WriteSamples(ctx context.Context, samples []*Sample) error
+}
+
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// InfluxWriter writes the samples in the line protocol to the write endpoint
+// of InfluxDB, such as /api/v2/write?bucket=onyt or /write?db=onyt.
+type InfluxWriter struct {
+	URL    string
+	Token  string
+	Prefix string
+}
+
+func (w *InfluxWriter) WriteSamples(ctx context.Context, samples []*Sample) error {
+	var b bytes.Buffer
+
+	for _, sample := range samples {
+		if len(sample.Fields) == 0 {
+			continue
+		}
+
+		b.WriteString(influxEscaper.Replace(w.Prefix + sample.Measurement))
+
+		for _, key := range sortedKeys(sample.Tags) {
+			fmt.Fprintf(&b, ",%s=%s", influxEscaper.Replace(key), influxEscaper.Replace(sample.Tags[key]))
+		}
+
+		for i, key := range sortedKeys(sample.Fields) {
+			separator := ","
+
+			if i == 0 {
+				separator = " "
+			}
+
+			fmt.Fprintf(&b, "%s%s=%di", separator, influxEscaper.Replace(key), sample.Fields[key])
+		}
+
+		fmt.Fprintf(&b, " %d\n", sample.Time.UnixNano())
+	}
+
+	if b.Len() == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, &b)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("content-type", "text/plain; charset=utf-8")
+
+	if w.Token != "" {
+		req.Header.Set("authorization", "Token "+w.Token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+		return fmt.Errorf("influxdb answered %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
+
+var graphiteEscaper = strings.NewReplacer(" ", "_", ";", "_", "~", "_", "=", "_")
+
+// GraphiteWriter writes the samples in the plaintext protocol to Carbon, as
+// tagged series.
+type GraphiteWriter struct {
+	Addr   string
+	Prefix string
+}
+
+func (w *GraphiteWriter) WriteSamples(ctx context.Context, samples []*Sample) error {
+	var b bytes.Buffer
+
+	for _, sample := range samples {
+		var tags strings.Builder
+
+		for _, key := range sortedKeys(sample.Tags) {
+			fmt.Fprintf(&tags, ";%s=%s", graphiteEscaper.Replace(key), graphiteEscaper.Replace(sample.Tags[key]))
+		}
+
+		for _, key := range sortedKeys(sample.Fields) {
+			fmt.Fprintf(&b, "%s%s.%s%s %d %d\n", w.Prefix, sample.Measurement, key, tags.String(), sample.Fields[key], sample.Time.Unix())
+		}
+	}
+
+	if b.Len() == 0 {
+		return nil
+	}
+
+	dialer := net.Dialer{
+		Timeout: 10 * time.Second,
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", w.Addr)
+
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+
+	_, err = conn.Write(b.Bytes())
+
+	return err
+}
+
+// TimeSeriesExporter writes the statistics of the channels to its writers
+// every refresh.
+type TimeSeriesExporter struct {
+	Writers []TimeSeriesWriter
+}
+
+// Watch exports the states of the poller until the context is done.
+func (e *TimeSeriesExporter) Watch(ctx context.Context, poller *onyt.Poller) {
+	poller.Store.Watch(ctx, func(state *onyt.State, _ uint64) {
+		samples := stateSamples(poller.ChannelId, state, time.Now())
+
+		for _, writer := range e.Writers {
+			if err := writer.WriteSamples(ctx, samples); err != nil {
+				log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to export samples")
+			}
+		}
+	})
+}
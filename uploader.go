@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/rs/zerolog/log"
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+type UploadKey struct {
+	ChannelId string
+	VideoId   string
+	Version   uint64
+	Time      time.Time
+}
+
+// Uploader stores the state of each channel, and the summary of each ended
+// live session, in an S3-compatible bucket.
+type Uploader struct {
+	client     *minio.Client
+	bucket     string
+	stateKey   *template.Template
+	sessionKey *template.Template
+}
+
+// NewUploader creates an uploader for a "s3://<bucket>" or "gs://<bucket>"
+// URL, GCS being reached through its S3-compatible API with HMAC keys.
+func NewUploader(bucketURL, endpoint, accessKey, secretKey, stateKey, sessionKey string) (*Uploader, error) {
+	u, err := url.Parse(bucketURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint == "" {
+		switch u.Scheme {
+		case "s3":
+			endpoint = "s3.amazonaws.com"
+		case "gs":
+			endpoint = "storage.googleapis.com"
+		default:
+			return nil, fmt.Errorf("unknown bucket scheme: %s", u.Scheme)
+		}
+	}
+
+	creds := credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.EnvAWS{},
+		&credentials.EnvMinio{},
+		&credentials.FileAWSCredentials{},
+		&credentials.IAM{},
+	})
+
+	if accessKey != "" {
+		creds = credentials.NewStaticV4(accessKey, secretKey, "")
+	}
+
+	secure := true
+
+	if strings.HasPrefix(endpoint, "http://") {
+		secure = false
+	}
+
+	client, err := minio.New(strings.TrimPrefix(strings.TrimPrefix(endpoint, "http://"), "https://"), &minio.Options{
+		Creds:  creds,
+		Secure: secure,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	uploader := &Uploader{
+		client: client,
+		bucket: u.Host,
+	}
+
+	if uploader.stateKey, err = template.New("state").Parse(stateKey); err != nil {
+		return nil, err
+	}
+
+	if uploader.sessionKey, err = template.New("session").Parse(sessionKey); err != nil {
+		return nil, err
+	}
+
+	return uploader, nil
+}
+
+func (u *Uploader) upload(ctx context.Context, key *template.Template, data UploadKey, v any) error {
+	var name strings.Builder
+
+	if err := key.Execute(&name, data); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = u.client.PutObject(ctx, u.bucket, name.String(), bytes.NewReader(body), int64(len(body)), minio.PutObjectOptions{
+		ContentType: "application/json",
+	})
+
+	return err
+}
+
+// Watch uploads the states of the channel until the context is done.
+func (u *Uploader) Watch(ctx context.Context, poller *onyt.Poller) {
+	poller.Store.Watch(ctx, func(state *onyt.State, version uint64) {
+		key := UploadKey{
+			ChannelId: poller.ChannelId,
+			Version:   version,
+			Time:      time.Now().UTC(),
+		}
+
+		if err := u.upload(ctx, u.stateKey, key, state); err != nil {
+			log.Err(err).Str("channel", poller.ChannelId).Msg("Unable to upload state")
+		}
+	})
+}
+
+// Start uploads the sessions as they end, until the context is done.
+func (u *Uploader) Start(ctx context.Context, events *onyt.EventBus) {
+	unsubscribe := events.Subscribe(func(evt onyt.Event) {
+		session, ok := evt.Data.(*onyt.Session)
+
+		if !ok || evt.Type != "live_ended" {
+			return
+		}
+
+		key := UploadKey{
+			ChannelId: evt.ChannelId,
+			VideoId:   session.VideoId,
+			Time:      evt.Time.UTC(),
+		}
+
+		go func() {
+			if err := u.upload(ctx, u.sessionKey, key, session); err != nil {
+				log.Err(err).Str("channel", evt.ChannelId).Msg("Unable to upload session")
+			}
+		}()
+	})
+
+	go func() {
+		<-ctx.Done()
+
+		unsubscribe()
+	}()
+}
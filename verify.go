@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/api/googleapi/transport"
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+// VerifiedUpload is an archived upload whose availability or title changed
+// since its last check.
+type VerifiedUpload struct {
+	VideoId        string `json:"videoId"`
+	ChannelId      string `json:"channelId"`
+	Title          string `json:"title"`
+	PreviousTitle  string `json:"previousTitle,omitempty"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previousStatus"`
+}
+
+// VerifyReport sums up a check of the archive, with the number of uploads in
+// each status.
+type VerifyReport struct {
+	Checked  int               `json:"checked"`
+	Statuses map[string]int    `json:"statuses"`
+	Changes  []*VerifiedUpload `json:"changes"`
+}
+
+// verifyArchive checks the archived uploads against the API, storing their
+// availability and their current title.
+func verifyArchive(ctx context.Context, database *Database, service *youtube.Service, quota *onyt.QuotaMeter) (*VerifyReport, error) {
+	uploads, err := database.UploadStatuses()
+
+	if err != nil {
+		return nil, err
+	}
+
+	videoIds := make([]string, len(uploads))
+
+	for i, u := range uploads {
+		videoIds[i] = u.VideoId
+	}
+
+	availabilities, err := onyt.FetchAvailabilities(ctx, service, quota, videoIds)
+
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{
+		Checked:  len(uploads),
+		Statuses: make(map[string]int),
+		Changes:  make([]*VerifiedUpload, 0),
+	}
+
+	now := time.Now()
+
+	for _, u := range uploads {
+		availability := availabilities[u.VideoId]
+
+		// The removed and private videos keep their archived title.
+		title := u.Title
+
+		if availability.Title != "" {
+			title = availability.Title
+		}
+
+		if err := database.CheckUpload(u.VideoId, title, availability.Status, now); err != nil {
+			return nil, err
+		}
+
+		report.Statuses[availability.Status]++
+
+		if title == u.Title && availability.Status == u.Status {
+			continue
+		}
+
+		change := &VerifiedUpload{
+			VideoId:        u.VideoId,
+			ChannelId:      u.ChannelId,
+			Title:          title,
+			Status:         availability.Status,
+			PreviousStatus: u.Status,
+		}
+
+		if title != u.Title {
+			change.PreviousTitle = u.Title
+		}
+
+		report.Changes = append(report.Changes, change)
+	}
+
+	return report, nil
+}
+
+func writeVerifyReport(w io.Writer, report *VerifyReport) error {
+	statuses := make([]string, 0, len(report.Statuses))
+
+	for status, count := range report.Statuses {
+		statuses = append(statuses, fmt.Sprintf("%d %s", count, status))
+	}
+
+	sort.Strings(statuses)
+
+	if _, err := fmt.Fprintf(w, "Checked %d uploads: %s\n", report.Checked, strings.Join(statuses, ", ")); err != nil {
+		return err
+	}
+
+	for _, change := range report.Changes {
+		line := fmt.Sprintf("%s\t%s\t%q", change.VideoId, change.ChannelId, change.Title)
+
+		if change.Status != change.PreviousStatus {
+			line += fmt.Sprintf("\t%s (was %s)", change.Status, change.PreviousStatus)
+		}
+
+		if change.PreviousTitle != "" {
+			line += fmt.Sprintf("\trenamed from %q", change.PreviousTitle)
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var verifyCommand = &cli.Command{
+	Name:  "verify",
+	Usage: "Cross-check the uploads archived in the database against the API, marking the removed and privated ones",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "db",
+			EnvVars:  []string{"DATABASE_PATH"},
+			Usage:    "The SQLite database to verify",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "key",
+			Aliases:  []string{"k"},
+			EnvVars:  []string{"API_KEY"},
+			Usage:    "The YouTube API key",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "The report format (text, json)",
+			Value: "text",
+		},
+		&cli.StringFlag{
+			Name:    "youtube-url",
+			EnvVars: []string{"YOUTUBE_URL"},
+			Usage:   "The server answering the API calls and scrapes instead of YouTube, such as the mock server",
+		},
+	},
+	Action: func(ctx *cli.Context) error {
+		format := ctx.String("format")
+
+		if format != "text" && format != "json" {
+			return fmt.Errorf("unknown format: %s", format)
+		}
+
+		if base := ctx.String("youtube-url"); base != "" {
+			rewrite, err := onyt.NewRewrite(base, onyt.DefaultThrottle.Transport)
+
+			if err != nil {
+				return err
+			}
+
+			onyt.DefaultThrottle.Transport = rewrite
+		}
+
+		client := &http.Client{
+			Transport: &transport.APIKey{
+				Key:       ctx.String("key"),
+				Transport: onyt.DefaultThrottle,
+			},
+		}
+
+		service, err := youtube.NewService(ctx.Context, option.WithHTTPClient(client))
+
+		if err != nil {
+			return err
+		}
+
+		database, err := OpenDatabase(ctx.String("db"))
+
+		if err != nil {
+			return err
+		}
+
+		defer database.Close()
+
+		report, err := verifyArchive(ctx.Context, database, service, onyt.NewQuotaMeter(0))
+
+		if err != nil {
+			return err
+		}
+
+		if format == "json" {
+			return json.NewEncoder(os.Stdout).Encode(report)
+		}
+
+		return writeVerifyReport(os.Stdout, report)
+	},
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/seldszar/onyt/pkg/onyt"
+)
+
+const (
+	defaultVideosLimit = 50
+	maxVideosLimit     = 500
+
+	// Shorts are detected by their duration, as the API doesn't flag them.
+	maxShortSeconds = 180
+)
+
+type VideoPage struct {
+	Videos     []*onyt.Video `json:"videos"`
+	Total      int           `json:"total"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+func videoType(v *onyt.Video) string {
+	if d := v.LiveStreamingDetails; d != nil && d.ActualEndTime != "" {
+		return "vod"
+	}
+
+	if duration := v.Fields().DurationSeconds; duration > 0 && duration <= maxShortSeconds {
+		return "short"
+	}
+
+	return "upload"
+}
+
+var videoSorts = map[string]func(v *onyt.Video) uint64{
+	"published": func(v *onyt.Video) uint64 {
+		return uint64(v.Fields().PublishedAtUnix)
+	},
+	"views": func(v *onyt.Video) uint64 {
+		if v.Statistics == nil {
+			return 0
+		}
+
+		return v.Statistics.ViewCount
+	},
+	"likes": func(v *onyt.Video) uint64 {
+		if v.Statistics == nil {
+			return 0
+		}
+
+		return v.Statistics.LikeCount
+	},
+}
+
+func serveVideos(w http.ResponseWriter, r *http.Request, poller *onyt.Poller) {
+	query := r.URL.Query()
+
+	var titleRe *regexp.Regexp
+
+	if pattern := query.Get("title"); pattern != "" {
+		var err error
+
+		if titleRe, err = regexp.Compile(pattern); err != nil {
+			http.Error(w, "invalid title pattern", http.StatusBadRequest)
+			return
+		}
+	}
+
+	sortKey := query.Get("sort")
+
+	if sortKey == "" {
+		sortKey = "published"
+	}
+
+	key, ok := videoSorts[sortKey]
+
+	if !ok {
+		http.Error(w, "invalid sort", http.StatusBadRequest)
+		return
+	}
+
+	kind := query.Get("type")
+
+	if kind != "" && kind != "upload" && kind != "short" && kind != "vod" {
+		http.Error(w, "invalid type", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := strconv.Atoi(query.Get("limit"))
+
+	if err != nil || limit <= 0 {
+		limit = defaultVideosLimit
+	}
+
+	if limit > maxVideosLimit {
+		limit = maxVideosLimit
+	}
+
+	offset, _ := strconv.Atoi(query.Get("offset"))
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	videos := make([]*onyt.Video, 0)
+
+	for _, v := range poller.Store.Get().Videos {
+		if kind != "" && videoType(v) != kind {
+			continue
+		}
+
+		if titleRe != nil && (v.Snippet == nil || !titleRe.MatchString(v.Snippet.Title)) {
+			continue
+		}
+
+		videos = append(videos, v)
+	}
+
+	ascending := query.Get("order") == "asc"
+
+	sort.SliceStable(videos, func(i, j int) bool {
+		a, b := key(videos[i]), key(videos[j])
+
+		if ascending {
+			return a < b
+		}
+
+		return a > b
+	})
+
+	// The cursor is the id of the last video of the previous page, which
+	// stays valid when new videos shift the offsets.
+	if cursor := query.Get("cursor"); cursor != "" {
+		offset = len(videos)
+
+		for i, v := range videos {
+			if v.Id == cursor {
+				offset = i + 1
+				break
+			}
+		}
+	}
+
+	page := &VideoPage{
+		Videos: make([]*onyt.Video, 0),
+		Total:  len(videos),
+	}
+
+	if offset < len(videos) {
+		end := offset + limit
+
+		if end > len(videos) {
+			end = len(videos)
+		}
+
+		page.Videos = videos[offset:end]
+
+		if end < len(videos) {
+			page.NextCursor = videos[end-1].Id
+		}
+	}
+
+	writeJSON(w, page)
+}